@@ -57,7 +57,11 @@ func init() {
 		operatorCmd,
 		agentCmd,
 		osdCmd,
-		configCmd)
+		configCmd,
+		supportBundleCmd,
+		fsDirectoriesCmd,
+		fsPoolLayoutsCmd,
+		fsQuotaCmd)
 }
 
 func createContext() *clusterd.Context {
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/cmd/rook/rook"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/daemon/ceph/filesystem"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+)
+
+var fsDirectoriesCmd = &cobra.Command{
+	Use:   "fs-directories",
+	Short: "Applies the quotas and the export pins of the directories of a filesystem",
+	Long: `Mount a CephFS filesystem with the admin credentials and set the quota and the
+export pin attributes of the given directories, creating the directories that
+do not exist. The state of the directories found before they were changed is
+printed on stdout as json.`,
+}
+
+var (
+	fsDirectoriesFilesystem string
+	fsDirectories           string
+)
+
+func init() {
+	fsDirectoriesCmd.Flags().StringVar(&fsDirectoriesFilesystem, "filesystem-name", "", "the name of the filesystem")
+	fsDirectoriesCmd.Flags().StringVar(&fsDirectories, "directories", "", "the json list of the directories to apply")
+	addCephFlags(fsDirectoriesCmd)
+	flags.SetFlagsFromEnv(fsDirectoriesCmd.Flags(), rook.RookEnvVarPrefix)
+
+	fsDirectoriesCmd.RunE = applyFilesystemDirectories
+}
+
+func applyFilesystemDirectories(cmd *cobra.Command, args []string) error {
+	required := []string{"filesystem-name", "directories", "mon-endpoints", "admin-secret"}
+	if err := flags.VerifyRequiredFlags(fsDirectoriesCmd, required); err != nil {
+		return err
+	}
+
+	rook.SetLogLevel()
+	rook.LogStartupInfo(fsDirectoriesCmd.Flags())
+
+	var dirs []filesystem.DirectoryState
+	if err := json.Unmarshal([]byte(fsDirectories), &dirs); err != nil {
+		rook.TerminateFatal(errors.Wrapf(err, "failed to parse directories %q", fsDirectories))
+	}
+
+	clusterInfo.Monitors = mon.ParseMonEndpoints(cfg.monEndpoints)
+	context := createContext()
+	configFile, err := cephconfig.GenerateAdminConnectionConfig(context, &clusterInfo, os.Getenv(k8sutil.PodNamespaceEnvVar))
+	if err != nil {
+		rook.TerminateFatal(errors.Wrapf(err, "failed to generate the admin config"))
+	}
+
+	mountPoint := path.Join(cfg.dataDir, "mnt", fsDirectoriesFilesystem)
+	observed, err := filesystem.ApplyDirectories(context, configFile, fsDirectoriesFilesystem, mountPoint, dirs)
+	if err != nil {
+		rook.TerminateFatal(errors.Wrapf(err, "failed to apply the directories of filesystem %q", fsDirectoriesFilesystem))
+	}
+
+	output, err := json.Marshal(observed)
+	if err != nil {
+		rook.TerminateFatal(errors.Wrapf(err, "failed to marshal the directories"))
+	}
+	fmt.Println(string(output))
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/cmd/rook/rook"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/daemon/ceph/filesystem"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+)
+
+var fsPoolLayoutsCmd = &cobra.Command{
+	Use:   "fs-pool-layouts",
+	Short: "Finds the directories of a filesystem whose layout uses the given data pools",
+	Long: `Mount a CephFS filesystem with the admin credentials and walk its directories
+to find the ones whose layout places the data of their files in one of the given
+pools. The directories are printed on stdout as json, by pool.`,
+}
+
+var (
+	fsPoolLayoutsFilesystem string
+	fsPoolLayoutsPools      string
+)
+
+func init() {
+	fsPoolLayoutsCmd.Flags().StringVar(&fsPoolLayoutsFilesystem, "filesystem-name", "", "the name of the filesystem")
+	fsPoolLayoutsCmd.Flags().StringVar(&fsPoolLayoutsPools, "pools", "", "the comma separated names of the data pools")
+	addCephFlags(fsPoolLayoutsCmd)
+	flags.SetFlagsFromEnv(fsPoolLayoutsCmd.Flags(), rook.RookEnvVarPrefix)
+
+	fsPoolLayoutsCmd.RunE = findFilesystemPoolLayouts
+}
+
+func findFilesystemPoolLayouts(cmd *cobra.Command, args []string) error {
+	required := []string{"filesystem-name", "pools", "mon-endpoints", "admin-secret"}
+	if err := flags.VerifyRequiredFlags(fsPoolLayoutsCmd, required); err != nil {
+		return err
+	}
+
+	rook.SetLogLevel()
+	rook.LogStartupInfo(fsPoolLayoutsCmd.Flags())
+
+	clusterInfo.Monitors = mon.ParseMonEndpoints(cfg.monEndpoints)
+	context := createContext()
+	configFile, err := cephconfig.GenerateAdminConnectionConfig(context, &clusterInfo, os.Getenv(k8sutil.PodNamespaceEnvVar))
+	if err != nil {
+		rook.TerminateFatal(errors.Wrapf(err, "failed to generate the admin config"))
+	}
+
+	mountPoint := path.Join(cfg.dataDir, "mnt", fsPoolLayoutsFilesystem)
+	layouts, err := filesystem.FindPoolLayouts(context, configFile, fsPoolLayoutsFilesystem, mountPoint, strings.Split(fsPoolLayoutsPools, ","))
+	if err != nil {
+		rook.TerminateFatal(errors.Wrapf(err, "failed to find the pool layouts of filesystem %q", fsPoolLayoutsFilesystem))
+	}
+
+	output, err := json.Marshal(layouts)
+	if err != nil {
+		rook.TerminateFatal(errors.Wrapf(err, "failed to marshal the layouts"))
+	}
+	fmt.Println(string(output))
+	return nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/cmd/rook/rook"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/daemon/ceph/filesystem"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+)
+
+var fsQuotaCmd = &cobra.Command{
+	Use:   "fs-quota",
+	Short: "Sets the quota of bytes of a directory of a filesystem",
+	Long: `Mount a CephFS filesystem with the admin credentials and set the quota of bytes
+of an existing directory, such as the directory of a subvolume group. A max bytes
+of 0 removes the quota. The quota found before it was changed is printed on stdout.`,
+}
+
+var (
+	fsQuotaFilesystem string
+	fsQuotaPath       string
+	fsQuotaMaxBytes   int64
+)
+
+func init() {
+	fsQuotaCmd.Flags().StringVar(&fsQuotaFilesystem, "filesystem-name", "", "the name of the filesystem")
+	fsQuotaCmd.Flags().StringVar(&fsQuotaPath, "path", "", "the path of the directory in the filesystem")
+	fsQuotaCmd.Flags().Int64Var(&fsQuotaMaxBytes, "max-bytes", 0, "the quota of bytes of the directory, 0 for no quota")
+	addCephFlags(fsQuotaCmd)
+	flags.SetFlagsFromEnv(fsQuotaCmd.Flags(), rook.RookEnvVarPrefix)
+
+	fsQuotaCmd.RunE = applyFilesystemQuota
+}
+
+func applyFilesystemQuota(cmd *cobra.Command, args []string) error {
+	required := []string{"filesystem-name", "path", "mon-endpoints", "admin-secret"}
+	if err := flags.VerifyRequiredFlags(fsQuotaCmd, required); err != nil {
+		return err
+	}
+
+	rook.SetLogLevel()
+	rook.LogStartupInfo(fsQuotaCmd.Flags())
+
+	clusterInfo.Monitors = mon.ParseMonEndpoints(cfg.monEndpoints)
+	context := createContext()
+	configFile, err := cephconfig.GenerateAdminConnectionConfig(context, &clusterInfo, os.Getenv(k8sutil.PodNamespaceEnvVar))
+	if err != nil {
+		rook.TerminateFatal(errors.Wrapf(err, "failed to generate the admin config"))
+	}
+
+	mountPoint := path.Join(cfg.dataDir, "mnt", fsQuotaFilesystem)
+	found, err := filesystem.ApplyQuota(context, configFile, fsQuotaFilesystem, mountPoint, fsQuotaPath, fsQuotaMaxBytes)
+	if err != nil {
+		rook.TerminateFatal(errors.Wrapf(err, "failed to set the quota of %q in filesystem %q", fsQuotaPath, fsQuotaFilesystem))
+	}
+	fmt.Println(found)
+	return nil
+}
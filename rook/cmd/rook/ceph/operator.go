@@ -55,6 +55,9 @@ func init() {
 	operatorCmd.Flags().StringVar(&csi.CephFSProvisionerSTSTemplatePath, "csi-cephfs-provisioner-sts-template-path", csi.DefaultCephFSProvisionerSTSTemplatePath, "path to ceph-csi cephfs provisioner statefulset template")
 	operatorCmd.Flags().StringVar(&csi.CephFSProvisionerDepTemplatePath, "csi-cephfs-provisioner-dep-template-path", csi.DefaultCephFSProvisionerDepTemplatePath, "path to ceph-csi cephfs provisioner deployment template")
 
+	operatorCmd.Flags().StringVar(&operator.MetricsBindAddress, "metrics-bind-address", operator.MetricsBindAddress, "the address the operator metrics are served on, 0 to disable the metrics")
+	operatorCmd.Flags().BoolVar(&operator.EnableMetricsServiceMonitor, "enable-metrics-service-monitor", false, "create a service and a ServiceMonitor for the operator metrics")
+
 	operatorCmd.Flags().BoolVar(&disruption.EnableMachineDisruptionBudget, "enable-machine-disruption-budget", false, "enable fencing controllers")
 
 	flags.SetFlagsFromEnv(operatorCmd.Flags(), rook.RookEnvVarPrefix)
@@ -67,6 +67,7 @@ var (
 	pvcBackedOSD            bool
 	blockPath               string
 	lvBackedPV              bool
+	migrateOSDIDs           []int
 )
 
 func addOSDFlags(command *cobra.Command) {
@@ -81,6 +82,7 @@ func addOSDFlags(command *cobra.Command) {
 	provisionCmd.Flags().BoolVar(&cfg.forceFormat, "force-format", false,
 		"true to force the format of any specified devices, even if they already have a filesystem.  BE CAREFUL!")
 	provisionCmd.Flags().BoolVar(&cfg.pvcBacked, "pvc-backed-osd", false, "true to specify a block mode pvc is backing the OSD")
+	provisionCmd.Flags().IntSliceVar(&migrateOSDIDs, "migrate-osd-ids", nil, "comma separated list of destroyed osd ids to prepare again with bluestore")
 	// flags for generating the osd config
 	osdConfigCmd.Flags().IntVar(&osdID, "osd-id", -1, "osd id for which to generate config")
 	osdConfigCmd.Flags().BoolVar(&osdIsDevice, "is-device", false, "whether the osd is a device")
@@ -212,7 +214,7 @@ func prepareOSD(cmd *cobra.Command, args []string) error {
 	ownerRef := cluster.ClusterOwnerRef(clusterInfo.Name, ownerRefID)
 	kv := k8sutil.NewConfigMapKVStore(clusterInfo.Name, context.Clientset, ownerRef)
 	agent := osddaemon.NewAgent(context, dataDevices, cfg.metadataDevice, forceFormat,
-		cfg.storeConfig, &clusterInfo, cfg.nodeName, kv, cfg.pvcBacked)
+		cfg.storeConfig, &clusterInfo, cfg.nodeName, kv, cfg.pvcBacked, migrateOSDIDs)
 
 	namespace := os.Getenv(k8sutil.PodNamespaceEnvVar)
 	err = osddaemon.Provision(context, agent, crushLocation, namespace)
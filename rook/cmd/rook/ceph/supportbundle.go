@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rook/rook/cmd/rook/rook"
+	"github.com/rook/rook/pkg/operator/ceph/supportbundle"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bundleNamespace         string
+	bundleOperatorNamespace string
+	bundleOutputDir         string
+	bundleLogTailLines      int64
+)
+
+var supportBundleCmd = &cobra.Command{
+	Use:   "support-bundle",
+	Short: "Collects the state of a cluster in a scrubbed archive to troubleshoot it offline",
+	Long: `Collects the ceph status, the Rook custom resources, the kubernetes resources and the pod logs
+of a cluster in a timestamped tar.gz archive. The cephx keys, the values of the secrets and the
+passwords and tokens found in the collected files are redacted. Nothing is sent outside of the
+cluster, the archive is written to the output directory.`,
+}
+
+func init() {
+	supportBundleCmd.Flags().StringVar(&bundleNamespace, "namespace", "rook-ceph", "the namespace of the cluster")
+	supportBundleCmd.Flags().StringVar(&bundleOperatorNamespace, "operator-namespace", os.Getenv(k8sutil.PodNamespaceEnvVar), "the namespace of the operator, its logs are not collected if empty")
+	supportBundleCmd.Flags().StringVar(&bundleOutputDir, "output-dir", ".", "the directory where the archive is written")
+	supportBundleCmd.Flags().Int64Var(&bundleLogTailLines, "log-tail-lines", 1000, "the number of lines collected from the end of the logs of each container, all the lines if 0")
+	supportBundleCmd.Flags().StringVar(&cfg.dataDir, "config-dir", k8sutil.DataDir, "directory of the ceph config of the cluster")
+	flags.SetFlagsFromEnv(supportBundleCmd.Flags(), rook.RookEnvVarPrefix)
+	supportBundleCmd.RunE = writeSupportBundle
+}
+
+func writeSupportBundle(cmd *cobra.Command, args []string) error {
+	rook.SetLogLevel()
+	rook.LogStartupInfo(supportBundleCmd.Flags())
+
+	collector := supportbundle.New(createContext(), bundleNamespace, bundleOperatorNamespace, bundleLogTailLines)
+	archivePath, err := collector.Write(bundleOutputDir, time.Now())
+	if err != nil {
+		rook.TerminateFatal(err)
+	}
+	fmt.Println(archivePath)
+	return nil
+}
@@ -21,6 +21,7 @@ import (
 
 	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -111,6 +112,23 @@ type ClusterSpec struct {
 	// Indicates user intent when deleting a cluster; blocks orchestration and should not be set if cluster
 	// deletion is not imminent.
 	CleanupPolicy CleanupPolicySpec `json:"cleanupPolicy,omitempty"`
+
+	// A spec for how the OSD deployments are updated
+	OSDUpdateStrategy OSDUpdateStrategySpec `json:"osdUpdateStrategy,omitempty"`
+
+	// A spec for how the flapping and crash looping OSDs are handled
+	OSDHealthCheck OSDHealthCheckSpec `json:"osdHealthCheck,omitempty"`
+
+	// A spec for the mgr balancer module
+	Balancer BalancerSpec `json:"balancer,omitempty"`
+
+	// OSDMemoryTargetRatio is the ratio of the memory limit of the OSD containers set as the osd_memory_target of
+	// the OSDs, such as "0.8". Defaults to 0.8.
+	OSDMemoryTargetRatio string `json:"osdMemoryTargetRatio,omitempty"`
+
+	// AllowMultipleFilesystems allows more than one CephFilesystem in the cluster, with their metadata servers
+	// bound to the filesystem they were created for
+	AllowMultipleFilesystems bool `json:"allowMultipleFilesystems,omitempty"`
 }
 
 // VersionSpec represents the settings for the Ceph version that Rook is orchestrating.
@@ -132,6 +150,35 @@ type DashboardSpec struct {
 	Port int `json:"port,omitempty"`
 	// Whether SSL should be used
 	SSL bool `json:"ssl,omitempty"`
+	// The name of a kubernetes.io/tls Secret with the certificate and key of the dashboard. If not set, a self-signed
+	// certificate is created when SSL is enabled.
+	CertificateSecret string `json:"certificateSecret,omitempty"`
+	// The users of the dashboard in addition to the admin user
+	Users []DashboardUser `json:"users,omitempty"`
+	// The SAML2 single sign-on configuration of the dashboard
+	SSO *DashboardSSOSpec `json:"sso,omitempty"`
+}
+
+// DashboardUser represents a user of the dashboard
+type DashboardUser struct {
+	// The name the user logs in with
+	Username string `json:"username"`
+	// The dashboard roles of the user, such as administrator, read-only or block-manager
+	Roles []string `json:"roles,omitempty"`
+	// The name of a Secret with the password of the user in the "password" key
+	PasswordSecret string `json:"passwordSecret"`
+}
+
+// DashboardSSOSpec represents the SAML2 single sign-on configuration of the dashboard
+type DashboardSSOSpec struct {
+	// The URL the dashboard is accessed with, the identity provider redirects to it
+	BaseURL string `json:"baseURL"`
+	// The URL or the XML content of the metadata of the identity provider
+	IdPMetadata string `json:"idpMetadata"`
+	// The attribute of the SAML assertion with the username, uid by default
+	UsernameAttribute string `json:"usernameAttribute,omitempty"`
+	// The entity ID of the identity provider, needed when the metadata has several entities
+	IdPEntityID string `json:"idpEntityID,omitempty"`
 }
 
 // MonitoringSpec represents the settings for Prometheus based Ceph monitoring
@@ -143,6 +190,33 @@ type MonitoringSpec struct {
 	// The namespace where the prometheus rules and alerts should be created.
 	// If empty, the same namespace as the cluster will be used.
 	RulesNamespace string `json:"rulesNamespace,omitempty"`
+
+	// The thresholds of the alerts that override the defaults of the rules
+	AlertThresholds AlertThresholdsSpec `json:"alertThresholds,omitempty"`
+
+	// The names of the alerts that are not deployed
+	DisabledAlerts []string `json:"disabledAlerts,omitempty"`
+
+	// Labels added to all the alerts, for example to route the alerts
+	AlertLabels map[string]string `json:"alertLabels,omitempty"`
+
+	// Annotations added to all the alerts
+	AlertAnnotations map[string]string `json:"alertAnnotations,omitempty"`
+}
+
+// AlertThresholdsSpec represents the thresholds of the prometheus alerts, the defaults of the rules are used when not set
+type AlertThresholdsSpec struct {
+	// The utilization percentage of an OSD from which CephOSDNearFull fires, 75 by default
+	OSDNearFullPercent int `json:"osdNearFullPercent,omitempty"`
+
+	// The utilization percentage of an OSD from which CephOSDCriticallyFull fires, 85 by default
+	OSDCriticallyFullPercent int `json:"osdCriticallyFullPercent,omitempty"`
+
+	// How long the mon quorum must be at risk before CephMonQuorumAtRisk fires, 15m by default
+	MonQuorumLossDuration string `json:"monQuorumLossDuration,omitempty"`
+
+	// The number of down OSDs from which CephOSDsDown fires. The alert is only deployed when it is set.
+	OSDDownCount int `json:"osdDownCount,omitempty"`
 }
 
 type ClusterStatus struct {
@@ -152,6 +226,50 @@ type ClusterStatus struct {
 	Conditions  []Condition     `json:"conditions,omitempty"`
 	CephStatus  *CephStatus     `json:"ceph,omitempty"`
 	CephVersion *ClusterVersion `json:"version,omitempty"`
+	Storage     *StorageStatus  `json:"storage,omitempty"`
+	Mgr         *MgrStatus      `json:"mgr,omitempty"`
+	Balancer    *BalancerStatus `json:"balancer,omitempty"`
+	Crashes     *CrashesStatus  `json:"crashes,omitempty"`
+}
+
+// MgrStatus reports the active and standby mgrs
+type MgrStatus struct {
+	// Active is the name of the active mgr
+	Active string `json:"active,omitempty"`
+	// Standbys are the names of the standby mgrs
+	Standbys []string `json:"standbys,omitempty"`
+	// LastFailover is the time a standby mgr last took over
+	LastFailover string `json:"lastFailover,omitempty"`
+}
+
+// BalancerStatus reports the state of the mgr balancer module
+type BalancerStatus struct {
+	// Active is whether the balancer is on
+	Active bool `json:"active"`
+	// Mode is the mode of the balancer
+	Mode string `json:"mode,omitempty"`
+	// Score is the score of the current data distribution, lower is better
+	Score string `json:"score,omitempty"`
+	// LastOptimizeStarted is the time the balancer last started an optimization
+	LastOptimizeStarted string `json:"lastOptimizeStarted,omitempty"`
+	// OptimizeResult is the result of the last optimization
+	OptimizeResult string `json:"optimizeResult,omitempty"`
+	// LastChecked is the time the balancer status was last checked
+	LastChecked string `json:"lastChecked,omitempty"`
+	// Settings are the settings of the balancer spec applied on the mgrs, removed when they are dropped from the spec
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+// CrashesStatus summarizes the crashes of the ceph daemons
+type CrashesStatus struct {
+	// New is the number of crashes that are not archived
+	New int `json:"new"`
+	// LastCrashID is the ID of the last crash reported
+	LastCrashID string `json:"lastCrashID,omitempty"`
+	// LastCrashEntity is the ceph daemon of the last crash reported, such as osd.0
+	LastCrashEntity string `json:"lastCrashEntity,omitempty"`
+	// LastCrashTime is the time of the last crash reported
+	LastCrashTime string `json:"lastCrashTime,omitempty"`
 }
 
 type CephStatus struct {
@@ -162,6 +280,77 @@ type CephStatus struct {
 	PreviousHealth string                       `json:"previousHealth,omitempty"`
 }
 
+// StorageStatus reports the state of the OSD orchestration
+type StorageStatus struct {
+	// DeviceSets reports the progress of the scale-down of the storageClassDeviceSets
+	DeviceSets []DeviceSetStatus `json:"deviceSets,omitempty"`
+	// OSDs reports the backend store of each OSD
+	OSDs []OSDStoreStatus `json:"osds,omitempty"`
+	// Migration reports the progress of the migration of the filestore OSDs to bluestore
+	Migration *StoreMigrationStatus `json:"migration,omitempty"`
+	// OSDConfig reports the Ceph config overrides of each OSD
+	OSDConfig []OSDConfigStatus `json:"osdConfig,omitempty"`
+	// RemediatedOSDs are the OSDs marked out or noup by the OSD health check that are not up and in again
+	RemediatedOSDs []int `json:"remediatedOSDs,omitempty"`
+}
+
+// OSDConfigStatus reports the Ceph config overrides of an OSD in the mon config store
+type OSDConfigStatus struct {
+	// ID of the OSD
+	ID int `json:"id"`
+	// Spec is the set of options applied from the storage spec
+	Spec map[string]string `json:"spec,omitempty"`
+	// Derived is the set of options derived from the resources of the OSD
+	Derived map[string]string `json:"derived,omitempty"`
+	// Effective is the set of options set on the OSD in the mon config store
+	Effective map[string]string `json:"effective,omitempty"`
+}
+
+// OSDStoreStatus reports the backend store of an OSD
+type OSDStoreStatus struct {
+	// ID of the OSD
+	ID int `json:"id"`
+	// StoreType is the object store of the OSD, either bluestore or filestore
+	StoreType string `json:"storeType,omitempty"`
+}
+
+// StoreMigrationStatus reports the progress of the migration of the filestore OSDs to bluestore
+type StoreMigrationStatus struct {
+	// Phase of the migration, one of Draining, Reprovisioning, Recovering, Completed, Incomplete or Failed
+	Phase string `json:"phase,omitempty"`
+	// FailureDomain is the CRUSH bucket whose OSDs are being migrated
+	FailureDomain string `json:"failureDomain,omitempty"`
+	// OSDs being migrated
+	OSDs []int `json:"osds,omitempty"`
+	// PendingOSDs are the filestore OSDs left to migrate by the next orchestrations
+	PendingOSDs []int `json:"pendingOSDs,omitempty"`
+	// UnsupportedOSDs are the filestore OSDs that cannot be migrated, the directory based OSDs and the OSDs created
+	// before ceph-volume. They must be replaced by new OSDs.
+	UnsupportedOSDs []int `json:"unsupportedOSDs,omitempty"`
+	// Message is a human readable description of the phase
+	Message string `json:"message,omitempty"`
+	// LastUpdated is the time of the last status update
+	LastUpdated string `json:"lastUpdated,omitempty"`
+}
+
+// DeviceSetStatus reports the progress of removing OSDs from a storageClassDeviceSet
+type DeviceSetStatus struct {
+	// Name of the storageClassDeviceSet
+	Name string `json:"name"`
+	// Count is the number of devices of the set that are still provisioned
+	Count int `json:"count"`
+	// DesiredCount is the number of devices requested in the spec
+	DesiredCount int `json:"desiredCount"`
+	// Phase of the scale-down, one of Draining, WaitingForClean, Purging, Completed or Failed
+	Phase string `json:"phase,omitempty"`
+	// Message is a human readable description of the phase
+	Message string `json:"message,omitempty"`
+	// OSDs being removed from the set
+	OSDs []int `json:"osds,omitempty"`
+	// LastUpdated is the time of the last status update
+	LastUpdated string `json:"lastUpdated,omitempty"`
+}
+
 type ClusterVersion struct {
 	Image   string `json:"image,omitempty"`
 	Version string `json:"version,omitempty"`
@@ -193,6 +382,10 @@ const (
 	ConditionFailure     ConditionType = "Failure"
 	ConditionUpgrading   ConditionType = "Upgrading"
 	ConditionDeleting    ConditionType = "Deleting"
+	// ConditionOSDUnhealthy reports the OSDs that flap or crash loop, it does not change the phase of the cluster
+	ConditionOSDUnhealthy ConditionType = "OSDUnhealthy"
+	// ConditionDataPoolsPending reports the changes of the data pools of a filesystem spec that cannot be applied
+	ConditionDataPoolsPending ConditionType = "DataPoolsPending"
 	// DefaultFailureDomain for PoolSpec
 	DefaultFailureDomain = "host"
 )
@@ -216,6 +409,8 @@ type MonSpec struct {
 
 // MgrSpec represents options to configure a ceph mgr
 type MgrSpec struct {
+	// Count is the number of mgrs to run, one is active and the others are standby. 1 by default, at most 2.
+	Count   int      `json:"count,omitempty"`
 	Modules []Module `json:"modules,omitempty"`
 }
 
@@ -223,6 +418,9 @@ type MgrSpec struct {
 type Module struct {
 	Name    string `json:"name,omitempty"`
 	Enabled bool   `json:"enabled"`
+	// Settings are the config options of the module, set as mgr/<module>/<key> on the mgrs. The options that are
+	// removed from the settings are removed from the mgrs.
+	Settings map[string]string `json:"settings,omitempty"`
 }
 
 // ExternalSpec represents the options supported by an external cluster
@@ -237,6 +435,11 @@ type RBDMirroringSpec struct {
 // CrashCollectorSpec represents options to configure the crash controller
 type CrashCollectorSpec struct {
 	Disable bool `json:"disable"`
+	// ArchiveAfter is how long a new crash is reported before it is archived, such as "24h". The crashes are not
+	// archived if empty.
+	ArchiveAfter string `json:"archiveAfter,omitempty"`
+	// DaysToRetain is the number of days the crashes are kept before they are pruned. The crashes are not pruned if 0.
+	DaysToRetain uint `json:"daysToRetain,omitempty"`
 }
 
 // +genclient
@@ -316,8 +519,8 @@ type ErasureCodedSpec struct {
 type CephFilesystem struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              FilesystemSpec `json:"spec"`
-	Status            *Status        `json:"status"`
+	Spec              FilesystemSpec        `json:"spec"`
+	Status            *CephFilesystemStatus `json:"status"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -341,6 +544,211 @@ type FilesystemSpec struct {
 
 	// The mds pod info
 	MetadataServer MetadataServerSpec `json:"metadataServer"`
+
+	// The quotas and the MDS pinning of directories of the filesystem
+	Directories []FilesystemDirectorySpec `json:"directories,omitempty"`
+
+	// The schedules of the snapshots of directories of the filesystem
+	SnapshotSchedules []SnapshotScheduleSpec `json:"snapshotSchedules,omitempty"`
+
+	// The retention of the scheduled snapshots of directories of the filesystem
+	SnapshotRetention []SnapshotRetentionSpec `json:"snapshotRetention,omitempty"`
+
+	// The eviction of the client sessions of the nodes that are not ready
+	ClientEviction *ClientEvictionSpec `json:"clientEviction,omitempty"`
+
+	// The mirroring of the snapshots of directories of the filesystem to peer clusters, requires Ceph Pacific
+	Mirroring *FilesystemMirroringSpec `json:"mirroring,omitempty"`
+}
+
+// FilesystemMirroringSpec represents the mirroring of the snapshots of directories of the filesystem to peer clusters
+type FilesystemMirroringSpec struct {
+	// The peer filesystems, added from the secrets containing their bootstrap token
+	Peers []FilesystemMirrorPeerSpec `json:"peers,omitempty"`
+
+	// The absolute paths of the directories whose snapshots are mirrored
+	Directories []string `json:"directories,omitempty"`
+
+	// The annotations-related configuration to add/set on the cephfs-mirror pod
+	Annotations rookv1.Annotations `json:"annotations,omitempty"`
+
+	// The affinity to place the cephfs-mirror pod (default is to place on any available node)
+	Placement rookv1.Placement `json:"placement,omitempty"`
+
+	// The resource requirements for the cephfs-mirror pod
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+
+	// PriorityClassName sets the priority class on the cephfs-mirror pod
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+}
+
+// FilesystemMirrorPeerSpec represents a peer filesystem the snapshots are mirrored to
+type FilesystemMirrorPeerSpec struct {
+	// The name of the secret containing the bootstrap token of the peer under the token key, as created with
+	// "ceph fs snapshot mirror peer_bootstrap create" on the peer cluster
+	SecretName string `json:"secretName"`
+}
+
+// ClientEvictionSpec represents the eviction of the client sessions of the filesystem on the nodes that are not ready
+type ClientEvictionSpec struct {
+	// The time a node must be not ready before its client sessions are blacklisted and evicted such as 10m, 5m if not set
+	NodeNotReadyGracePeriod string `json:"nodeNotReadyGracePeriod,omitempty"`
+}
+
+// SnapshotScheduleSpec represents a schedule of the snapshots of a directory of the filesystem
+type SnapshotScheduleSpec struct {
+	// The absolute path of the directory, the root of the filesystem if not set
+	Path string `json:"path,omitempty"`
+
+	// The interval between the snapshots, a number followed by m, h, d, w, M or y such as 1h
+	Interval string `json:"interval"`
+
+	// The time of the first snapshot in the ISO format such as 2020-06-01T00:00:00, the time the schedule is added if not set
+	StartTime string `json:"startTime,omitempty"`
+}
+
+// SnapshotRetentionSpec represents the retention of the scheduled snapshots of a directory of the filesystem
+type SnapshotRetentionSpec struct {
+	// The absolute path of the directory, the root of the filesystem if not set
+	Path string `json:"path,omitempty"`
+
+	// The number of snapshots to keep per period such as 24h7d for 24 hourly and 7 daily snapshots
+	Duration string `json:"duration"`
+}
+
+// CephFilesystemStatus represents the status of a Ceph filesystem
+type CephFilesystemStatus struct {
+	Phase string `json:"phase,omitempty"`
+	// The status of the snapshot schedules of the filesystem spec
+	SnapshotSchedules []SnapshotScheduleStatus `json:"snapshotSchedules,omitempty"`
+	// The state of the autoscaling of the active metadata servers
+	Autoscale *MDSAutoscaleStatus `json:"autoscale,omitempty"`
+	// The Ceph config options derived from the resources of the metadata servers and set on each of them
+	DerivedConfig map[string]string `json:"derivedConfig,omitempty"`
+	// The filesystem served by each metadata server of the filesystem
+	MetadataServers []MDSDaemonStatus `json:"metadataServers,omitempty"`
+	// The status of the mirroring of the filesystem to its peers
+	Mirroring *FilesystemMirroringStatus `json:"mirroring,omitempty"`
+	// The conditions of the filesystem, such as the changes of the data pools that cannot be applied
+	Conditions []Condition `json:"conditions,omitempty"`
+	// The ranks, standby metadata servers, pool usage and clients of the filesystem, refreshed periodically
+	Info *FilesystemInfoStatus `json:"info,omitempty"`
+}
+
+// FilesystemInfoStatus represents the state of the ranks, the standby metadata servers, the pools and the clients of
+// the filesystem
+type FilesystemInfoStatus struct {
+	// The ranks of the filesystem and the metadata servers serving them
+	Ranks []MDSRankStatus `json:"ranks,omitempty"`
+	// The standby metadata servers that may serve the filesystem
+	Standbys []string `json:"standbys,omitempty"`
+	// The usage of the metadata pool
+	MetadataPool *FilesystemPoolStatus `json:"metadataPool,omitempty"`
+	// The usage of the data pools
+	DataPools []FilesystemPoolStatus `json:"dataPools,omitempty"`
+	// The number of clients connected to the filesystem
+	Clients int `json:"clients"`
+}
+
+// MDSRankStatus represents a rank of the filesystem
+type MDSRankStatus struct {
+	Rank int `json:"rank"`
+	// The state of the rank, the state of the metadata server serving it such as up:active, or failed or damaged
+	State string `json:"state"`
+	// The metadata server serving the rank
+	Daemon string `json:"daemon,omitempty"`
+	// The standby-replay metadata server following the rank
+	StandbyReplay string `json:"standbyReplay,omitempty"`
+}
+
+// FilesystemPoolStatus represents the usage of a pool of the filesystem
+type FilesystemPoolStatus struct {
+	Name      string `json:"name"`
+	BytesUsed int64  `json:"bytesUsed"`
+	MaxAvail  int64  `json:"maxAvail"`
+	Objects   int64  `json:"objects"`
+}
+
+// FilesystemMirroringStatus represents the status of the mirroring of the filesystem
+type FilesystemMirroringStatus struct {
+	// The peers the snapshots are mirrored to
+	Peers []FilesystemMirrorPeerStatus `json:"peers,omitempty"`
+	// The status of the mirrored directories
+	Directories []FilesystemMirrorDirectoryStatus `json:"directories,omitempty"`
+	// The error preventing the mirroring of the filesystem
+	Error string `json:"error,omitempty"`
+}
+
+// FilesystemMirrorPeerStatus represents a peer filesystem the snapshots are mirrored to
+type FilesystemMirrorPeerStatus struct {
+	// The name of the secret of the peer in the spec, empty for the peers that were not added from the spec
+	SecretName string `json:"secretName,omitempty"`
+	UUID       string `json:"uuid,omitempty"`
+	SiteName   string `json:"siteName,omitempty"`
+	Filesystem string `json:"filesystem,omitempty"`
+	ClientName string `json:"clientName,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// FilesystemMirrorDirectoryStatus represents the synchronization of the snapshots of a directory
+type FilesystemMirrorDirectoryStatus struct {
+	Path string `json:"path"`
+	// The state of the directory such as mapped when a cephfs-mirror daemon synchronizes it, or stalled
+	State string `json:"state,omitempty"`
+	// The cephfs-mirror daemon instance synchronizing the directory
+	InstanceID string `json:"instanceID,omitempty"`
+	// The reason why the directory is not synchronized
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// MDSDaemonStatus represents the filesystem served by a metadata server
+type MDSDaemonStatus struct {
+	// The name of the metadata server
+	Name string `json:"name"`
+	// The filesystem the metadata server serves, empty while it is a standby
+	Filesystem string `json:"filesystem,omitempty"`
+	// The filesystem the metadata server is bound to as mds_join_fs
+	JoinFilesystem string `json:"joinFilesystem,omitempty"`
+	// The rank of the metadata server in the filesystem, -1 while it is a standby
+	Rank int `json:"rank"`
+	// The state of the metadata server such as up:active or up:standby
+	State string `json:"state"`
+}
+
+// MDSAutoscaleStatus represents the state of the autoscaling of the active metadata servers
+type MDSAutoscaleStatus struct {
+	// The number of active metadata servers chosen by the autoscaler
+	ActiveCount int32 `json:"activeCount"`
+	// The average rate of client requests per active metadata server at the last check
+	RequestRate int64 `json:"requestRate"`
+	// The time the number of active metadata servers was last changed
+	LastScaleTime string `json:"lastScaleTime,omitempty"`
+}
+
+// SnapshotScheduleStatus represents the status of a snapshot schedule
+type SnapshotScheduleStatus struct {
+	Path         string `json:"path"`
+	Interval     string `json:"interval"`
+	StartTime    string `json:"startTime,omitempty"`
+	Active       bool   `json:"active"`
+	LastSnapshot string `json:"lastSnapshot,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// FilesystemDirectorySpec represents the quotas and the export pin of a directory of the filesystem
+type FilesystemDirectorySpec struct {
+	// The absolute path of the directory in the filesystem, created if it does not exist
+	Path string `json:"path"`
+
+	// The maximum size of the directory, no limit if not set
+	MaxBytes *resource.Quantity `json:"maxBytes,omitempty"`
+
+	// The maximum number of files and directories under the directory, no limit if zero
+	MaxFiles int64 `json:"maxFiles,omitempty"`
+
+	// The rank of the active MDS serving the directory, not pinned if not set
+	ExportPin *int `json:"exportPin,omitempty"`
 }
 
 type MetadataServerSpec struct {
@@ -351,6 +759,14 @@ type MetadataServerSpec struct {
 	// If false, standbys will still be available, but will not have a warm metadata cache.
 	ActiveStandby bool `json:"activeStandby"`
 
+	// The ratio of the memory limit of the mds containers set as the mds_cache_memory_limit of the metadata servers,
+	// such as "0.5". Defaults to 0.5.
+	CacheMemoryLimitRatio string `json:"cacheMemoryLimitRatio,omitempty"`
+
+	// The policy scaling the number of active metadata servers with the load of the filesystem. If set, the
+	// active count is only the initial number of active metadata servers.
+	Autoscale *MDSAutoscaleSpec `json:"autoscale,omitempty"`
+
 	// The affinity to place the mds pods (default is to place on all available node) with a daemonset
 	Placement rookv1.Placement `json:"placement"`
 
@@ -364,6 +780,78 @@ type MetadataServerSpec struct {
 	PriorityClassName string `json:"priorityClassName,omitempty"`
 }
 
+// MDSAutoscaleSpec represents the policy scaling the number of active metadata servers with the rate of client requests
+type MDSAutoscaleSpec struct {
+	// The minimum number of active metadata servers, 1 if not set
+	MinActive int32 `json:"minActive,omitempty"`
+
+	// The maximum number of active metadata servers
+	MaxActive int32 `json:"maxActive"`
+
+	// The rate of client requests per active metadata server above which an active metadata server is added
+	ScaleUpRequestRate int64 `json:"scaleUpRequestRate"`
+
+	// The rate of client requests per active metadata server below which an active metadata server is removed
+	ScaleDownRequestRate int64 `json:"scaleDownRequestRate,omitempty"`
+
+	// The minimum time between two changes of the number of active metadata servers, such as "15m". Defaults to 10m.
+	Cooldown string `json:"cooldown,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephFilesystemSubVolumeGroup represents a subvolume group of a Ceph filesystem
+type CephFilesystemSubVolumeGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              SubVolumeGroupSpec    `json:"spec"`
+	Status            *SubVolumeGroupStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephFilesystemSubVolumeGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephFilesystemSubVolumeGroup `json:"items"`
+}
+
+// SubVolumeGroupSpec represents the spec of a subvolume group
+type SubVolumeGroupSpec struct {
+	// FilesystemName is the name of the CephFilesystem of the group, in the same namespace
+	FilesystemName string `json:"filesystemName"`
+
+	// Name of the group in the filesystem, the name of the resource by default
+	Name string `json:"name,omitempty"`
+
+	// DataPoolName is the data pool of the layout of the group, one of the data pools of the filesystem. The first data
+	// pool of the filesystem is used by default.
+	DataPoolName string `json:"dataPoolName,omitempty"`
+
+	// Mode is the octal permissions of the directory of the group, such as 755
+	Mode string `json:"mode,omitempty"`
+
+	// Quota is the maximum size of the group, set as the quota of bytes of the directory of the group
+	Quota *resource.Quantity `json:"quota,omitempty"`
+}
+
+// SubVolumeGroupStatus represents the status of a subvolume group
+type SubVolumeGroupStatus struct {
+	Phase string `json:"phase,omitempty"`
+	// Path is the path of the group in the filesystem
+	Path string `json:"path,omitempty"`
+	// Name, DataPoolName and Mode are the settings the group was created with, they cannot be changed afterwards
+	Name         string `json:"name,omitempty"`
+	DataPoolName string `json:"dataPoolName,omitempty"`
+	Mode         string `json:"mode,omitempty"`
+	// Quota is the quota last applied to the group
+	Quota *resource.Quantity `json:"quota,omitempty"`
+	// Message explains the phase, such as why the group cannot be deleted
+	Message string `json:"message,omitempty"`
+}
+
 // +genclient
 // +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -371,8 +859,15 @@ type MetadataServerSpec struct {
 type CephObjectStore struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              ObjectStoreSpec `json:"spec"`
-	Status            *Status         `json:"status"`
+	Spec              ObjectStoreSpec    `json:"spec"`
+	Status            *ObjectStoreStatus `json:"status"`
+}
+
+// ObjectStoreStatus represents the status of a Ceph object store
+type ObjectStoreStatus struct {
+	Phase string `json:"phase,omitempty"`
+	// The Ceph config options derived from the resources of the gateways and set on each of them
+	DerivedConfig map[string]string `json:"derivedConfig,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -452,6 +947,10 @@ type GatewaySpec struct {
 
 	// PriorityClassName sets priority classes on the rgw pods
 	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// The ratio of the memory limit of the rgw containers used by the client requests, such as "0.5". The
+	// rgw_max_concurrent_requests of the gateways is derived from it. Defaults to 0.5.
+	RequestMemoryRatio string `json:"requestMemoryRatio,omitempty"`
 }
 
 // +genclient
@@ -531,6 +1030,92 @@ type DisruptionManagementSpec struct {
 	MachineDisruptionBudgetNamespace string `json:"machineDisruptionBudgetNamespace,omitempty"`
 }
 
+// OSDUpdateStrategyType is the strategy used to update the OSD deployments
+type OSDUpdateStrategyType string
+
+const (
+	// OSDUpdateOneByOne updates the OSDs one at a time, each OSD being ok-to-stop before it is restarted
+	OSDUpdateOneByOne OSDUpdateStrategyType = "OneByOne"
+	// OSDUpdateFailureDomain updates all the OSDs of a CRUSH failure domain at the same time
+	OSDUpdateFailureDomain OSDUpdateStrategyType = "FailureDomain"
+)
+
+// OSDUpdateStrategySpec represents how the OSD deployments are updated
+type OSDUpdateStrategySpec struct {
+	// Type of the update strategy, either OneByOne (default) or FailureDomain
+	Type OSDUpdateStrategyType `json:"type,omitempty"`
+
+	// FailureDomain is the CRUSH bucket type whose OSDs are updated together: host (default), rack or zone
+	FailureDomain string `json:"failureDomain,omitempty"`
+
+	// MaxParallelDomains is the maximum number of failure domains updated at the same time, 1 by default
+	MaxParallelDomains int `json:"maxParallelDomains,omitempty"`
+}
+
+// OSDRemediationAction is the action taken on an unhealthy OSD
+type OSDRemediationAction string
+
+const (
+	// OSDRemediationNone only raises a condition and an event
+	OSDRemediationNone OSDRemediationAction = "none"
+	// OSDRemediationOut marks the OSD out so its data is moved to the other OSDs
+	OSDRemediationOut OSDRemediationAction = "out"
+	// OSDRemediationNoUp sets the noup flag on the OSD so it stays down without triggering a recovery
+	OSDRemediationNoUp OSDRemediationAction = "noup"
+)
+
+// OSDHealthCheckSpec represents how the OSDs that flap or crash loop are detected and remediated
+type OSDHealthCheckSpec struct {
+	// Action taken on an unhealthy OSD, one of none (default), out or noup
+	Action OSDRemediationAction `json:"action,omitempty"`
+
+	// FlapThreshold is the number of times an OSD must go down within the flap window to be flapping, 5 by default
+	FlapThreshold int `json:"flapThreshold,omitempty"`
+
+	// FlapWindow is the sliding window in which the OSD down transitions are counted, 30m by default
+	FlapWindow string `json:"flapWindow,omitempty"`
+
+	// CrashLoopTimeout is how long an OSD pod must be in CrashLoopBackOff to be unhealthy, 30m by default
+	CrashLoopTimeout string `json:"crashLoopTimeout,omitempty"`
+
+	// MaxRemediatedOSDs is the maximum number of OSDs remediated at the same time, 1 by default
+	MaxRemediatedOSDs int `json:"maxRemediatedOSDs,omitempty"`
+}
+
+// BalancerSpec represents the configuration of the mgr balancer module
+type BalancerSpec struct {
+	// Mode of the balancer, upmap (default) or crush-compat
+	Mode string `json:"mode,omitempty"`
+
+	// MaxMisplacedRatio is the ratio of the PGs that the balancer may misplace at a time, such as "0.05"
+	MaxMisplacedRatio string `json:"maxMisplacedRatio,omitempty"`
+
+	// ActiveWindow restricts the balancer to a time window, it is always active by default
+	ActiveWindow *BalancerWindowSpec `json:"activeWindow,omitempty"`
+
+	// Pools are the names of the only pools that are balanced
+	Pools []string `json:"pools,omitempty"`
+
+	// ExcludedPools are the names of the pools that are not balanced
+	ExcludedPools []string `json:"excludedPools,omitempty"`
+}
+
+// BalancerWindowSpec is the time window in which the balancer is active. The end is excluded and the window may wrap
+// around midnight or the end of the week.
+type BalancerWindowSpec struct {
+	// BeginHour is the hour of the day from which the balancer is active, from 0 to 23
+	BeginHour int `json:"beginHour,omitempty"`
+
+	// EndHour is the hour of the day until which the balancer is active, from 1 to 24. 24 by default.
+	EndHour int `json:"endHour,omitempty"`
+
+	// BeginWeekday is the day of the week from which the balancer is active, from 0 (Sunday) to 6
+	BeginWeekday int `json:"beginWeekday,omitempty"`
+
+	// EndWeekday is the day of the week until which the balancer is active, from 1 to 7 (Sunday). 7 by default.
+	EndWeekday int `json:"endWeekday,omitempty"`
+}
+
 // +genclient
 // +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -111,6 +111,7 @@ type StorageClassDeviceSet struct {
 	VolumeClaimTemplates []v1.PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty"` // List of PVC templates for the underlying storage devices
 	Portable             bool                       `json:"portable,omitempty"`             // OSD portability across the hosts
 	TuneSlowDeviceClass  bool                       `json:"tuneDeviceClass,omitempty"`      // TuneSlowDeviceClass Tune the OSD when running on a slow Device Class
+	OSDConfig            map[string]string          `json:"osdConfig,omitempty"`            // Ceph config options set on each OSD of the set
 }
 
 // VolumeSource is a volume source spec for Rook
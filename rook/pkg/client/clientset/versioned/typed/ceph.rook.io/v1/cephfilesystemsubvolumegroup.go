@@ -0,0 +1,174 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"time"
+
+	v1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	scheme "github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// CephFilesystemSubVolumeGroupsGetter has a method to return a CephFilesystemSubVolumeGroupInterface.
+// A group's client should implement this interface.
+type CephFilesystemSubVolumeGroupsGetter interface {
+	CephFilesystemSubVolumeGroups(namespace string) CephFilesystemSubVolumeGroupInterface
+}
+
+// CephFilesystemSubVolumeGroupInterface has methods to work with CephFilesystemSubVolumeGroup resources.
+type CephFilesystemSubVolumeGroupInterface interface {
+	Create(*v1.CephFilesystemSubVolumeGroup) (*v1.CephFilesystemSubVolumeGroup, error)
+	Update(*v1.CephFilesystemSubVolumeGroup) (*v1.CephFilesystemSubVolumeGroup, error)
+	Delete(name string, options *metav1.DeleteOptions) error
+	DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error
+	Get(name string, options metav1.GetOptions) (*v1.CephFilesystemSubVolumeGroup, error)
+	List(opts metav1.ListOptions) (*v1.CephFilesystemSubVolumeGroupList, error)
+	Watch(opts metav1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.CephFilesystemSubVolumeGroup, err error)
+	CephFilesystemSubVolumeGroupExpansion
+}
+
+// cephFilesystemSubVolumeGroups implements CephFilesystemSubVolumeGroupInterface
+type cephFilesystemSubVolumeGroups struct {
+	client rest.Interface
+	ns     string
+}
+
+// newCephFilesystemSubVolumeGroups returns a CephFilesystemSubVolumeGroups
+func newCephFilesystemSubVolumeGroups(c *CephV1Client, namespace string) *cephFilesystemSubVolumeGroups {
+	return &cephFilesystemSubVolumeGroups{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the cephFilesystemSubVolumeGroup, and returns the corresponding cephFilesystemSubVolumeGroup object, and an error if there is any.
+func (c *cephFilesystemSubVolumeGroups) Get(name string, options metav1.GetOptions) (result *v1.CephFilesystemSubVolumeGroup, err error) {
+	result = &v1.CephFilesystemSubVolumeGroup{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("cephfilesystemsubvolumegroups").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of CephFilesystemSubVolumeGroups that match those selectors.
+func (c *cephFilesystemSubVolumeGroups) List(opts metav1.ListOptions) (result *v1.CephFilesystemSubVolumeGroupList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.CephFilesystemSubVolumeGroupList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("cephfilesystemsubvolumegroups").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested cephFilesystemSubVolumeGroups.
+func (c *cephFilesystemSubVolumeGroups) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("cephfilesystemsubvolumegroups").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch()
+}
+
+// Create takes the representation of a cephFilesystemSubVolumeGroup and creates it.  Returns the server's representation of the cephFilesystemSubVolumeGroup, and an error, if there is any.
+func (c *cephFilesystemSubVolumeGroups) Create(cephFilesystemSubVolumeGroup *v1.CephFilesystemSubVolumeGroup) (result *v1.CephFilesystemSubVolumeGroup, err error) {
+	result = &v1.CephFilesystemSubVolumeGroup{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("cephfilesystemsubvolumegroups").
+		Body(cephFilesystemSubVolumeGroup).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a cephFilesystemSubVolumeGroup and updates it. Returns the server's representation of the cephFilesystemSubVolumeGroup, and an error, if there is any.
+func (c *cephFilesystemSubVolumeGroups) Update(cephFilesystemSubVolumeGroup *v1.CephFilesystemSubVolumeGroup) (result *v1.CephFilesystemSubVolumeGroup, err error) {
+	result = &v1.CephFilesystemSubVolumeGroup{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("cephfilesystemsubvolumegroups").
+		Name(cephFilesystemSubVolumeGroup.Name).
+		Body(cephFilesystemSubVolumeGroup).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the cephFilesystemSubVolumeGroup and deletes it. Returns an error if one occurs.
+func (c *cephFilesystemSubVolumeGroups) Delete(name string, options *metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("cephfilesystemsubvolumegroups").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *cephFilesystemSubVolumeGroups) DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOptions.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOptions.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("cephfilesystemsubvolumegroups").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched cephFilesystemSubVolumeGroup.
+func (c *cephFilesystemSubVolumeGroups) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.CephFilesystemSubVolumeGroup, err error) {
+	result = &v1.CephFilesystemSubVolumeGroup{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("cephfilesystemsubvolumegroups").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
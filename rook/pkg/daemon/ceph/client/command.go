@@ -19,6 +19,7 @@ package client
 import (
 	"fmt"
 	"path"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
@@ -29,6 +30,17 @@ import (
 // Everywhere else, the ceph tools are assumed to be in the container where we can shell out.
 var RunAllCephCommandsInToolbox = false
 
+// CommandObserver is called after each invocation of a ceph CLI tool started at the given time
+type CommandObserver func(tool string, args []string, start time.Time, err error)
+
+// commandObserver holds the CommandObserver, it is read by the commands running in the goroutines of the operator
+var commandObserver atomic.Value
+
+// SetCommandObserver sets the observer of the invocations of the ceph CLI tools, such as the operator metrics
+func SetCommandObserver(observer CommandObserver) {
+	commandObserver.Store(observer)
+}
+
 const (
 	// AdminUsername is the name of the admin user
 	AdminUsername = "client.admin"
@@ -135,6 +147,13 @@ func (c *CephToolCommand) run() ([]byte, error) {
 	var output string
 	var err error
 
+	if observer, _ := commandObserver.Load().(CommandObserver); observer != nil {
+		start := time.Now()
+		defer func() {
+			observer(c.tool, c.args, start, err)
+		}()
+	}
+
 	if c.OutputFile {
 		if command == Kubectl {
 			// Kubectl commands targeting the toolbox container generate a temp
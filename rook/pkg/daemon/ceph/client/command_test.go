@@ -17,8 +17,14 @@ limitations under the License.
 package client
 
 import (
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -110,3 +116,22 @@ func TestFinalizeCephCommandArgsClusterDefaultName(t *testing.T) {
 	assert.Exactly(t, expectedCommand, cmd)
 	assert.Exactly(t, expectedArgs, args)
 }
+
+func TestCommandObserver(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			return "", errors.New("failed")
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	var observed []string
+	SetCommandObserver(func(tool string, args []string, start time.Time, err error) {
+		observed = append(observed, fmt.Sprintf("%s %s %v", tool, strings.Join(args, " "), err))
+	})
+	defer SetCommandObserver(nil)
+
+	_, err := NewCephCommand(context, "ns", []string{"osd", "dump"}).Run()
+	assert.Error(t, err)
+	assert.Equal(t, []string{"ceph osd dump failed"}, observed)
+}
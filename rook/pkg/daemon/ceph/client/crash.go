@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+// CrashInfo is a crash report of a ceph daemon returned by "ceph crash ls-new" and "ceph crash info"
+type CrashInfo struct {
+	ID              string   `json:"crash_id"`
+	Timestamp       string   `json:"timestamp"`
+	Entity          string   `json:"entity_name"`
+	ProcessName     string   `json:"process_name"`
+	CephVersion     string   `json:"ceph_version"`
+	Hostname        string   `json:"utsname_hostname"`
+	AssertCondition string   `json:"assert_condition"`
+	AssertFunction  string   `json:"assert_func"`
+	AssertMessage   string   `json:"assert_msg"`
+	Backtrace       []string `json:"backtrace"`
+	Archived        string   `json:"archived"`
+}
+
+// Time returns the time of the crash. The timestamp is "2020-04-14 10:30:00.123456Z" in nautilus and
+// "2020-04-14T10:30:00.123456Z" in octopus.
+func (c CrashInfo) Time() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, strings.Replace(c.Timestamp, " ", "T", 1))
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to parse the timestamp of crash %q", c.ID)
+	}
+	return t, nil
+}
+
+// ListNewCrashes returns the crashes that are not archived yet. "crash ls-new" requires nautilus 14.2.5 or newer.
+func ListNewCrashes(context *clusterd.Context, clusterName string) ([]CrashInfo, error) {
+	return listCrashes(context, clusterName, "ls-new")
+}
+
+// ListCrashes returns all the crashes, for the releases where the crashes cannot be archived
+func ListCrashes(context *clusterd.Context, clusterName string) ([]CrashInfo, error) {
+	return listCrashes(context, clusterName, "ls")
+}
+
+func listCrashes(context *clusterd.Context, clusterName, command string) ([]CrashInfo, error) {
+	args := []string{"crash", command}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the crashes with crash %s", command)
+	}
+
+	var crashes []CrashInfo
+	if err := json.Unmarshal(buf, &crashes); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal crash %s response", command)
+	}
+	return crashes, nil
+}
+
+// GetCrashInfo returns the details of a crash
+func GetCrashInfo(context *clusterd.Context, clusterName, id string) (*CrashInfo, error) {
+	args := []string{"crash", "info", id}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the info of crash %q", id)
+	}
+
+	var crash CrashInfo
+	if err := json.Unmarshal(buf, &crash); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal crash info response")
+	}
+	return &crash, nil
+}
+
+// ArchiveCrash acknowledges a crash so it is not reported as new anymore. "crash archive" requires nautilus 14.2.5 or
+// newer.
+func ArchiveCrash(context *clusterd.Context, clusterName, id string) error {
+	args := []string{"crash", "archive", id}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to archive crash %q", id)
+	}
+	return nil
+}
+
+// PruneCrashes removes the crashes older than the number of days to keep
+func PruneCrashes(context *clusterd.Context, clusterName string, keepDays uint) error {
+	args := []string{"crash", "prune", strconv.FormatUint(uint64(keepDays), 10)}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to prune the crashes older than %d days", keepDays)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrashCommands(t *testing.T) {
+	var calls [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			calls = append(calls, args[:3])
+			switch args[1] {
+			case "ls-new":
+				return `[{"crash_id":"2020-04-14_10:30:00.123456Z_abc","timestamp":"2020-04-14 10:30:00.123456Z","entity_name":"osd.0"}]`, nil
+			case "info":
+				return `{"crash_id":"2020-04-14_10:30:00.123456Z_abc","entity_name":"osd.0","utsname_hostname":"node0","assert_msg":"failed assert"}`, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	crashes, err := ListNewCrashes(context, "ns")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(crashes))
+	assert.Equal(t, "osd.0", crashes[0].Entity)
+	crashTime, err := crashes[0].Time()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 4, 14, 10, 30, 0, 123456000, time.UTC), crashTime)
+
+	crash, err := GetCrashInfo(context, "ns", "2020-04-14_10:30:00.123456Z_abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "node0", crash.Hostname)
+	assert.Equal(t, "failed assert", crash.AssertMessage)
+
+	assert.NoError(t, ArchiveCrash(context, "ns", "2020-04-14_10:30:00.123456Z_abc"))
+	assert.NoError(t, PruneCrashes(context, "ns", 30))
+	assert.Equal(t, []string{"crash", "archive", "2020-04-14_10:30:00.123456Z_abc"}, calls[2])
+	assert.Equal(t, []string{"crash", "prune", "30"}, calls[3])
+}
+
+func TestCrashTime(t *testing.T) {
+	// octopus format
+	crashTime, err := CrashInfo{Timestamp: "2020-04-14T10:30:00.123456Z"}.Time()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 4, 14, 10, 30, 0, 123456000, time.UTC), crashTime)
+
+	_, err = CrashInfo{Timestamp: "yesterday"}.Time()
+	assert.Error(t, err)
+}
@@ -123,6 +123,16 @@ func GetCrushHostName(context *clusterd.Context, clusterName string, osdID int)
 	return result.Location["host"], nil
 }
 
+// CrushReweight sets the crush weight of the OSD
+func CrushReweight(context *clusterd.Context, clusterName string, osdID int, weight float64) error {
+	args := []string{"osd", "crush", "reweight", fmt.Sprintf("osd.%d", osdID), strconv.FormatFloat(weight, 'f', -1, 64)}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to reweight osd.%d to %.4f. %s", osdID, weight, string(buf))
+	}
+	return nil
+}
+
 // NormalizeCrushName replaces . with -
 func NormalizeCrushName(name string) string {
 	return strings.Replace(name, ".", "-", -1)
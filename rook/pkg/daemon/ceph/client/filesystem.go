@@ -19,12 +19,16 @@ package client
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
@@ -73,6 +77,137 @@ type MDSInfo struct {
 	Rank    int    `json:"rank"`
 	State   string `json:"state"`
 	Address string `json:"addr"`
+	// The id of the filesystem set as mds_join_fs, as of Octopus
+	JoinFSCID int `json:"join_fscid"`
+}
+
+// FilesystemDump is a representation of the json structure returned by 'ceph fs dump'
+type FilesystemDump struct {
+	Standbys    []MDSInfo               `json:"standbys"`
+	Filesystems []CephFilesystemDetails `json:"filesystems"`
+}
+
+// MDSSession is a representation of a client session returned by 'ceph tell mds.<name> session ls'
+type MDSSession struct {
+	ID             int64                `json:"id"`
+	State          string               `json:"state"`
+	Entity         MDSSessionEntity     `json:"entity"`
+	ClientMetadata MDSSessionClientInfo `json:"client_metadata"`
+}
+
+// MDSSessionEntity is the entity of the client of a session
+type MDSSessionEntity struct {
+	Addr MDSSessionAddr `json:"addr"`
+}
+
+// MDSSessionAddr is the address of the client of a session
+type MDSSessionAddr struct {
+	Addr  string `json:"addr"`
+	Nonce uint64 `json:"nonce"`
+}
+
+// MDSSessionClientInfo is the metadata reported by the client of a session
+type MDSSessionClientInfo struct {
+	Hostname string `json:"hostname"`
+	EntityID string `json:"entity_id"`
+}
+
+// IP returns the ip address of the client of the session
+func (s *MDSSession) IP() string {
+	host, _, err := net.SplitHostPort(s.Entity.Addr.Addr)
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// ClientAddr returns the address of the client instance of the session, as used by the osd blacklist
+func (s *MDSSession) ClientAddr() string {
+	return fmt.Sprintf("%s/%d", s.Entity.Addr.Addr, s.Entity.Addr.Nonce)
+}
+
+// FilesystemStatus is a representation of the json structure returned by 'ceph fs status'
+type FilesystemStatus struct {
+	Clients []FilesystemClients `json:"clients"`
+	MDSMap  []MDSStatus         `json:"mdsmap"`
+}
+
+// FilesystemClients is the number of clients of a filesystem returned by 'ceph fs status'
+type FilesystemClients struct {
+	Filesystem string `json:"fs"`
+	Clients    int    `json:"clients"`
+}
+
+// MDSStatus is the load of an mds daemon returned by 'ceph fs status'
+type MDSStatus struct {
+	Name     string  `json:"name"`
+	Rank     int     `json:"rank"`
+	State    string  `json:"state"`
+	Rate     float64 `json:"rate"`
+	Dentries int64   `json:"dns"`
+	Inodes   int64   `json:"inos"`
+}
+
+// GetFilesystemDump gets the mds maps of all the filesystems and the standby mds of the Ceph cluster.
+func GetFilesystemDump(context *clusterd.Context, clusterName string) (*FilesystemDump, error) {
+	args := []string{"fs", "dump"}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to dump filesystems")
+	}
+
+	var dump FilesystemDump
+	err = json.Unmarshal(buf, &dump)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal failed raw buffer response %s", string(buf))
+	}
+
+	return &dump, nil
+}
+
+// FilesystemName returns the name of the filesystem with the given id, or an empty string if there is none
+func (d *FilesystemDump) FilesystemName(id int) string {
+	for _, fs := range d.Filesystems {
+		if fs.ID == id {
+			return fs.MDSMap.FilesystemName
+		}
+	}
+	return ""
+}
+
+// ListMDSSessions lists the client sessions of an mds daemon.
+func ListMDSSessions(context *clusterd.Context, clusterName, mdsName string) ([]MDSSession, error) {
+	args := []string{"tell", fmt.Sprintf("mds.%s", mdsName), "session", "ls"}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the sessions of mds %s", mdsName)
+	}
+
+	var sessions []MDSSession
+	err = json.Unmarshal(buf, &sessions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal failed raw buffer response %s", string(buf))
+	}
+
+	return sessions, nil
+}
+
+// EvictMDSClient evicts the session of a client from an mds daemon.
+func EvictMDSClient(context *clusterd.Context, clusterName, mdsName string, clientID int64) error {
+	args := []string{"tell", fmt.Sprintf("mds.%s", mdsName), "client", "evict", fmt.Sprintf("id=%d", clientID)}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to evict client %d from mds %s", clientID, mdsName)
+	}
+	return nil
+}
+
+// BlacklistClient prevents a client instance from accessing the OSDs, until it expires
+func BlacklistClient(context *clusterd.Context, clusterName, addr string) error {
+	args := []string{"osd", "blacklist", "add", addr}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to blacklist client %s", addr)
+	}
+	return nil
 }
 
 // ListFilesystems lists all filesystems provided by the Ceph cluster.
@@ -109,6 +244,39 @@ func GetFilesystem(context *clusterd.Context, clusterName string, fsName string)
 	return &fs, nil
 }
 
+// GetFilesystemStatus gets the load of the mds daemons of a Ceph filesystem.
+func GetFilesystemStatus(context *clusterd.Context, clusterName string, fsName string) (*FilesystemStatus, error) {
+	args := []string{"fs", "status", fsName}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get status of file system %s", fsName)
+	}
+
+	var status FilesystemStatus
+	err = json.Unmarshal(buf, &status)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal failed raw buffer response %s", string(buf))
+	}
+
+	return &status, nil
+}
+
+// RequestRate returns the average rate of client requests per active mds
+func (s *FilesystemStatus) RequestRate() float64 {
+	active := 0
+	rate := 0.0
+	for _, mds := range s.MDSMap {
+		if mds.State == "active" {
+			active++
+			rate += mds.Rate
+		}
+	}
+	if active == 0 {
+		return 0
+	}
+	return rate / float64(active)
+}
+
 // AllowStandbyReplay gets detailed status information about a Ceph filesystem.
 func AllowStandbyReplay(context *clusterd.Context, clusterName string, fsName string, allowStandbyReplay bool) error {
 	logger.Infof("setting allow_standby_replay for filesystem %q", fsName)
@@ -128,27 +296,14 @@ func CreateFilesystem(context *clusterd.Context, clusterName, name, metadataPool
 	}
 
 	logger.Infof("creating filesystem %q with metadata pool %q and data pools %v", name, metadataPool, dataPools)
-	args := []string{}
-	var err error
-
-	if IsMultiFSEnabled() {
-		// enable multiple file systems in case this is not the first
-		args = []string{"fs", "flag", "set", "enable_multiple", "true", confirmFlag}
-		_, err = NewCephCommand(context, clusterName, args).Run()
-		if err != nil {
-			// continue if this fails
-			logger.Warning("failed enabling multiple file systems. %v", err)
-		}
-	}
-
 	// create the filesystem
-	args = []string{"fs", "new", name, metadataPool, dataPools[0]}
+	args := []string{"fs", "new", name, metadataPool, dataPools[0]}
 	// Force to use pre-existing pools
 	if force {
 		args = append(args, "--force")
 		logger.Infof("Filesystem %q will reuse pre-existing pools", name)
 	}
-	_, err = NewCephCommand(context, clusterName, args).Run()
+	_, err := NewCephCommand(context, clusterName, args).Run()
 	if err != nil {
 		return errors.Wrapf(err, "failed enabling ceph fs %q", name)
 	}
@@ -166,8 +321,39 @@ func CreateFilesystem(context *clusterd.Context, clusterName, name, metadataPool
 	return nil
 }
 
+// AddDataPoolToFilesystem adds a data pool to the filesystem. Adding a pool the filesystem already uses is not an error.
+func AddDataPoolToFilesystem(context *clusterd.Context, clusterName, fsName, poolName string) error {
+	args := []string{"fs", "add_data_pool", fsName, poolName}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to add data pool %q to filesystem %q", poolName, fsName)
+	}
+	logger.Infof("added data pool %q to filesystem %q", poolName, fsName)
+	return nil
+}
+
+// RemoveDataPoolFromFilesystem removes a data pool from the filesystem, the pool itself is not deleted. Ceph does not
+// check that the pool is still used by the files or the directory layouts of the filesystem.
+func RemoveDataPoolFromFilesystem(context *clusterd.Context, clusterName, fsName, poolName string) error {
+	args := []string{"fs", "rm_data_pool", fsName, poolName}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to remove data pool %q from filesystem %q", poolName, fsName)
+	}
+	logger.Infof("removed data pool %q from filesystem %q", poolName, fsName)
+	return nil
+}
+
+// EnableMultipleFilesystems allows the creation of more than one filesystem in the Ceph cluster
+func EnableMultipleFilesystems(context *clusterd.Context, clusterName string) error {
+	args := []string{"fs", "flag", "set", "enable_multiple", "true", confirmFlag}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to enable multiple filesystems")
+	}
+	return nil
+}
+
 // IsMultiFSEnabled returns true if ROOK_ALLOW_MULTIPLE_FILESYSTEMS is set to "true", allowing
 // Rook to create multiple Ceph filesystems. False if Rook is not allowed to do so.
+// Deprecated: the allowMultipleFilesystems setting of the CephCluster should be used instead.
 func IsMultiFSEnabled() bool {
 	t := os.Getenv(MultiFsEnv)
 	if t == "true" {
@@ -323,3 +509,66 @@ func deleteFSPool(context *clusterd.Context, clusterName string, poolNames map[i
 	}
 	return DeletePool(context, clusterName, name)
 }
+
+// SubVolume is a representation of the json structure returned by 'ceph fs subvolume ls'
+type SubVolume struct {
+	Name string `json:"name"`
+}
+
+// CreateSubVolumeGroup creates a subvolume group in a filesystem. The group is not changed if it already exists. The
+// data pool of the layout and the octal mode of the group are optional.
+func CreateSubVolumeGroup(context *clusterd.Context, clusterName, fsName, groupName, poolLayout, mode string) error {
+	args := []string{"fs", "subvolumegroup", "create", fsName, groupName}
+	if poolLayout != "" {
+		args = append(args, "--pool_layout", poolLayout)
+	}
+	if mode != "" {
+		args = append(args, "--mode", mode)
+	}
+	_, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to create subvolume group %q in filesystem %q", groupName, fsName)
+	}
+	return nil
+}
+
+// GetSubVolumeGroupPath returns the path of a subvolume group in a filesystem
+func GetSubVolumeGroupPath(context *clusterd.Context, clusterName, fsName, groupName string) (string, error) {
+	args := []string{"fs", "subvolumegroup", "getpath", fsName, groupName}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the path of subvolume group %q in filesystem %q", groupName, fsName)
+	}
+	// the path is returned as plain text whatever the format
+	return strings.TrimSpace(string(buf)), nil
+}
+
+// ListSubVolumes lists the subvolumes of a subvolume group
+func ListSubVolumes(context *clusterd.Context, clusterName, fsName, groupName string) ([]SubVolume, error) {
+	args := []string{"fs", "subvolume", "ls", fsName, "--group_name", groupName}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		if code, ok := exec.ExitStatus(err); ok && code == int(syscall.ENOENT) {
+			// the group does not exist
+			return []SubVolume{}, nil
+		}
+		return nil, errors.Wrapf(err, "failed to list the subvolumes of group %q in filesystem %q", groupName, fsName)
+	}
+
+	var subVolumes []SubVolume
+	if err := json.Unmarshal(buf, &subVolumes); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal subvolume ls response")
+	}
+	return subVolumes, nil
+}
+
+// DeleteSubVolumeGroup removes a subvolume group from a filesystem. Ceph refuses to remove a group that has
+// subvolumes. A group that does not exist is not an error.
+func DeleteSubVolumeGroup(context *clusterd.Context, clusterName, fsName, groupName string) error {
+	args := []string{"fs", "subvolumegroup", "rm", fsName, groupName, "--force"}
+	_, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete subvolume group %q in filesystem %q", groupName, fsName)
+	}
+	return nil
+}
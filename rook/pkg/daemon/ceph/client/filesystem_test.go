@@ -18,6 +18,8 @@ package client
 
 import (
 	"encoding/json"
+	"strings"
+	"syscall"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -89,6 +91,103 @@ func TestFilesystemGetMarshal(t *testing.T) {
 	assert.Equal(t, expectedFS, fs)
 }
 
+func TestFilesystemStatus(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			assert.Equal(t, []string{"fs", "status", "myfs"}, args[:3])
+			return `{"clients":[{"clients":4,"fs":"myfs"}],"mds_version":"ceph version 15.2.4",
+"mdsmap":[{"caps":10,"dirs":12,"dns":100,"inos":110,"name":"myfs-a","rank":0,"rate":120.5,"state":"active"},
+{"caps":2,"dirs":3,"dns":40,"inos":42,"name":"myfs-c","rank":1,"rate":39.5,"state":"active"},
+{"dns":100,"events":0,"inos":110,"name":"myfs-b","rank":0,"state":"standby-replay"}],
+"pools":[{"avail":1000,"id":1,"name":"myfs-metadata","type":"metadata","used":10}]}`, nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	status, err := GetFilesystemStatus(context, "ns", "myfs")
+	assert.NoError(t, err)
+	assert.Equal(t, []FilesystemClients{{Filesystem: "myfs", Clients: 4}}, status.Clients)
+	assert.Equal(t, 3, len(status.MDSMap))
+	assert.Equal(t, MDSStatus{Name: "myfs-c", Rank: 1, State: "active", Rate: 39.5, Dentries: 40, Inodes: 42}, status.MDSMap[1])
+
+	// the standby daemons don't count in the rate of requests
+	assert.Equal(t, 80.0, status.RequestRate())
+	assert.Equal(t, 0.0, (&FilesystemStatus{}).RequestRate())
+}
+
+func TestFilesystemDump(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			assert.Equal(t, []string{"fs", "dump"}, args[:2])
+			return `{"epoch":12,"default_fscid":1,
+"standbys":[{"gid":4120,"name":"otherfs-b","rank":-1,"state":"up:standby","addr":"10.0.0.3:6801/1","join_fscid":2}],
+"filesystems":[
+{"mdsmap":{"fs_name":"myfs","max_mds":1,"info":{"gid_4107":{"gid":4107,"name":"myfs-a","rank":0,"state":"up:active","addr":"10.0.0.1:6801/1","join_fscid":1}}},"id":1},
+{"mdsmap":{"fs_name":"otherfs","max_mds":1,"info":{"gid_4108":{"gid":4108,"name":"otherfs-a","rank":0,"state":"up:active","addr":"10.0.0.2:6801/1","join_fscid":2}}},"id":2}]}`, nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	dump, err := GetFilesystemDump(context, "ns")
+	assert.NoError(t, err)
+	assert.Equal(t, []MDSInfo{{GID: 4120, Name: "otherfs-b", Rank: -1, State: "up:standby", Address: "10.0.0.3:6801/1", JoinFSCID: 2}}, dump.Standbys)
+	assert.Equal(t, 2, len(dump.Filesystems))
+	assert.Equal(t, "myfs-a", dump.Filesystems[0].MDSMap.Info["gid_4107"].Name)
+	assert.Equal(t, "otherfs", dump.FilesystemName(2))
+	assert.Equal(t, "", dump.FilesystemName(-1))
+}
+
+func TestMDSSessions(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			if args[0] == "tell" && args[2] == "session" {
+				assert.Equal(t, []string{"tell", "mds.myfs-a", "session", "ls"}, args[:4])
+				return `[{"id":4305,"state":"open","num_caps":2,"inst":"client.4305 v1:10.0.0.2:0/123",
+"entity":{"name":{"type":"client","num":4305},"addr":{"type":"v1","addr":"10.0.0.2:0","nonce":123}},
+"client_metadata":{"features":"0x00000000000000ff","entity_id":"csi-cephfs-node","hostname":"node1","kernel_version":"5.4.0"}}]`, nil
+			}
+			assert.Equal(t, []string{"tell", "mds.myfs-a", "client", "evict", "id=4305"}, args[:5])
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	sessions, err := ListMDSSessions(context, "ns", "myfs-a")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(sessions))
+	assert.Equal(t, int64(4305), sessions[0].ID)
+	assert.Equal(t, "node1", sessions[0].ClientMetadata.Hostname)
+	assert.Equal(t, "10.0.0.2", sessions[0].IP())
+	assert.Equal(t, "10.0.0.2:0/123", sessions[0].ClientAddr())
+	assert.NoError(t, EvictMDSClient(context, "ns", "myfs-a", 4305))
+
+	// the address of an ipv6 client
+	session := MDSSession{Entity: MDSSessionEntity{Addr: MDSSessionAddr{Addr: "[fd00::2]:0", Nonce: 5}}}
+	assert.Equal(t, "fd00::2", session.IP())
+	assert.Equal(t, "[fd00::2]:0/5", session.ClientAddr())
+}
+
+func TestDataPoolCommands(t *testing.T) {
+	var calls [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			calls = append(calls, args[:4])
+			if args[1] == "rm_data_pool" {
+				return "", errors.New("EINVAL")
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.NoError(t, AddDataPoolToFilesystem(context, "ns", "myfs", "myfs-data1"))
+	assert.Error(t, RemoveDataPoolFromFilesystem(context, "ns", "myfs", "myfs-data0"))
+	assert.Equal(t, [][]string{
+		{"fs", "add_data_pool", "myfs", "myfs-data1"},
+		{"fs", "rm_data_pool", "myfs", "myfs-data0"},
+	}, calls)
+}
+
 func TestFilesystemRemove(t *testing.T) {
 	dataDeleted := false
 	metadataDeleted := false
@@ -166,3 +265,51 @@ func TestFilesystemRemove(t *testing.T) {
 	assert.True(t, dataDeleted)
 	assert.True(t, crushDeleted)
 }
+
+func TestSubVolumeGroup(t *testing.T) {
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfileArg string, args ...string) (string, error) {
+			// remove the common args added by the ceph command
+			for i, arg := range args {
+				if strings.HasPrefix(arg, "--connect-timeout") {
+					commands = append(commands, args[:i])
+					break
+				}
+			}
+			switch args[2] {
+			case "getpath":
+				return "/volumes/team-a\n", nil
+			case "ls":
+				if args[5] == "team-c" {
+					return "", exitError(syscall.ENOENT)
+				}
+				return `[{"name":"csi-vol-1"},{"name":"csi-vol-2"}]`, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.NoError(t, CreateSubVolumeGroup(context, "ns", "myfs", "team-a", "myfs-data1", "755"))
+	assert.Equal(t, []string{"fs", "subvolumegroup", "create", "myfs", "team-a", "--pool_layout", "myfs-data1", "--mode", "755"}, commands[0])
+	assert.NoError(t, CreateSubVolumeGroup(context, "ns", "myfs", "team-b", "", ""))
+	assert.Equal(t, []string{"fs", "subvolumegroup", "create", "myfs", "team-b"}, commands[1])
+
+	path, err := GetSubVolumeGroupPath(context, "ns", "myfs", "team-a")
+	assert.NoError(t, err)
+	assert.Equal(t, "/volumes/team-a", path)
+
+	subVolumes, err := ListSubVolumes(context, "ns", "myfs", "team-a")
+	assert.NoError(t, err)
+	assert.Equal(t, []SubVolume{{Name: "csi-vol-1"}, {Name: "csi-vol-2"}}, subVolumes)
+	assert.Equal(t, []string{"fs", "subvolume", "ls", "myfs", "--group_name", "team-a"}, commands[3])
+
+	// a group that does not exist has no subvolumes
+	subVolumes, err = ListSubVolumes(context, "ns", "myfs", "team-c")
+	assert.NoError(t, err)
+	assert.Empty(t, subVolumes)
+
+	assert.NoError(t, DeleteSubVolumeGroup(context, "ns", "myfs", "team-a"))
+	assert.Equal(t, []string{"fs", "subvolumegroup", "rm", "myfs", "team-a", "--force"}, commands[5])
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
+)
+
+// FilesystemMirroringModule is the mgr module managing the mirroring of the filesystems
+const FilesystemMirroringModule = "mirroring"
+
+// FilesystemMirrorPeer is a peer of a mirrored filesystem returned by "ceph fs snapshot mirror peer_list"
+type FilesystemMirrorPeer struct {
+	ClientName string `json:"client_name"`
+	SiteName   string `json:"site_name"`
+	Filesystem string `json:"fs_name"`
+}
+
+// FilesystemMirrorBootstrapToken is the content of the token created with
+// "ceph fs snapshot mirror peer_bootstrap create" to add a peer
+type FilesystemMirrorBootstrapToken struct {
+	FSID       string `json:"fsid"`
+	Filesystem string `json:"filesystem"`
+	User       string `json:"user"`
+	SiteName   string `json:"site_name"`
+}
+
+// FilesystemMirrorDirMap is the state of a mirrored directory returned by "ceph fs snapshot mirror dirmap"
+type FilesystemMirrorDirMap struct {
+	InstanceID string `json:"instance_id"`
+	State      string `json:"state"`
+	Reason     string `json:"reason"`
+}
+
+// ParseFilesystemMirrorBootstrapToken decodes the peer of a bootstrap token
+func ParseFilesystemMirrorBootstrapToken(token string) (*FilesystemMirrorBootstrapToken, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(token))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode the bootstrap token")
+	}
+	var t FilesystemMirrorBootstrapToken
+	if err := json.Unmarshal(decoded, &t); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the bootstrap token")
+	}
+	if t.Filesystem == "" || t.User == "" || t.SiteName == "" {
+		return nil, errors.New("the bootstrap token has no filesystem, user or site name")
+	}
+	return &t, nil
+}
+
+// Matches returns whether the peer was added from the token
+func (p FilesystemMirrorPeer) Matches(token *FilesystemMirrorBootstrapToken) bool {
+	return p.ClientName == token.User && p.SiteName == token.SiteName && p.Filesystem == token.Filesystem
+}
+
+// EnableFilesystemMirroring enables the mirroring of the snapshots of a filesystem, it succeeds if the mirroring
+// is already enabled
+func EnableFilesystemMirroring(context *clusterd.Context, clusterName, fsName string) error {
+	args := []string{"fs", "snapshot", "mirror", "enable", fsName}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		if code, ok := exec.ExitStatus(err); ok && code == int(syscall.EEXIST) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to enable the mirroring of filesystem %q", fsName)
+	}
+	return nil
+}
+
+// DisableFilesystemMirroring disables the mirroring of the snapshots of a filesystem
+func DisableFilesystemMirroring(context *clusterd.Context, clusterName, fsName string) error {
+	args := []string{"fs", "snapshot", "mirror", "disable", fsName}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to disable the mirroring of filesystem %q", fsName)
+	}
+	return nil
+}
+
+// ListFilesystemMirrorPeers returns the peers of a mirrored filesystem by uuid
+func ListFilesystemMirrorPeers(context *clusterd.Context, clusterName, fsName string) (map[string]FilesystemMirrorPeer, error) {
+	args := []string{"fs", "snapshot", "mirror", "peer_list", fsName}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the mirror peers of filesystem %q", fsName)
+	}
+
+	peers := map[string]FilesystemMirrorPeer{}
+	if strings.TrimSpace(string(buf)) == "" {
+		return peers, nil
+	}
+	if err := json.Unmarshal(buf, &peers); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal failed raw buffer response %s", string(buf))
+	}
+	return peers, nil
+}
+
+// ImportFilesystemMirrorPeer adds the peer of a bootstrap token to a mirrored filesystem
+func ImportFilesystemMirrorPeer(context *clusterd.Context, clusterName, fsName, token string) error {
+	args := []string{"fs", "snapshot", "mirror", "peer_bootstrap", "import", fsName, strings.TrimSpace(token)}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to import the mirror peer of filesystem %q", fsName)
+	}
+	logger.Infof("imported mirror peer of filesystem %q", fsName)
+	return nil
+}
+
+// RemoveFilesystemMirrorPeer removes a peer from a mirrored filesystem
+func RemoveFilesystemMirrorPeer(context *clusterd.Context, clusterName, fsName, uuid string) error {
+	args := []string{"fs", "snapshot", "mirror", "peer_remove", fsName, uuid}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to remove mirror peer %q of filesystem %q", uuid, fsName)
+	}
+	logger.Infof("removed mirror peer %q of filesystem %q", uuid, fsName)
+	return nil
+}
+
+// AddFilesystemMirrorDirectory mirrors the snapshots of a directory of the filesystem, it succeeds if the
+// directory is already mirrored
+func AddFilesystemMirrorDirectory(context *clusterd.Context, clusterName, fsName, path string) error {
+	args := []string{"fs", "snapshot", "mirror", "add", fsName, path}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		if code, ok := exec.ExitStatus(err); ok && code == int(syscall.EEXIST) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to mirror directory %q of filesystem %q", path, fsName)
+	}
+	return nil
+}
+
+// RemoveFilesystemMirrorDirectory stops the mirroring of the snapshots of a directory of the filesystem, it succeeds
+// if the directory is not mirrored
+func RemoveFilesystemMirrorDirectory(context *clusterd.Context, clusterName, fsName, path string) error {
+	args := []string{"fs", "snapshot", "mirror", "remove", fsName, path}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		if code, ok := exec.ExitStatus(err); ok && code == int(syscall.ENOENT) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to stop mirroring directory %q of filesystem %q", path, fsName)
+	}
+	logger.Infof("stopped mirroring directory %q of filesystem %q", path, fsName)
+	return nil
+}
+
+// GetFilesystemMirrorDirMap returns the state of the synchronization of a mirrored directory
+func GetFilesystemMirrorDirMap(context *clusterd.Context, clusterName, fsName, path string) (*FilesystemMirrorDirMap, error) {
+	args := []string{"fs", "snapshot", "mirror", "dirmap", fsName, path}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the state of mirrored directory %q of filesystem %q", path, fsName)
+	}
+
+	var dirMap FilesystemMirrorDirMap
+	if err := json.Unmarshal(buf, &dirMap); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal failed raw buffer response %s", string(buf))
+	}
+	return &dirMap, nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// exitError returns the error of a command failing with the errno
+func exitError(errno syscall.Errno) error {
+	return exec.Command("sh", "-c", fmt.Sprintf("exit %d", int(errno))).Run()
+}
+
+func TestParseFilesystemMirrorBootstrapToken(t *testing.T) {
+	encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+	token, err := ParseFilesystemMirrorBootstrapToken(encode(`{"fsid":"abc","filesystem":"backupfs","user":"client.mirror_remote","site_name":"site-b","key":"secret","mon_host":"[v2:10.0.0.1:3300]"}`) + "\n")
+	assert.NoError(t, err)
+	assert.Equal(t, &FilesystemMirrorBootstrapToken{FSID: "abc", Filesystem: "backupfs", User: "client.mirror_remote", SiteName: "site-b"}, token)
+
+	assert.True(t, FilesystemMirrorPeer{ClientName: "client.mirror_remote", SiteName: "site-b", Filesystem: "backupfs"}.Matches(token))
+	assert.False(t, FilesystemMirrorPeer{ClientName: "client.mirror_remote", SiteName: "site-c", Filesystem: "backupfs"}.Matches(token))
+
+	_, err = ParseFilesystemMirrorBootstrapToken("not base64!")
+	assert.Error(t, err)
+	_, err = ParseFilesystemMirrorBootstrapToken(encode("not json"))
+	assert.Error(t, err)
+	_, err = ParseFilesystemMirrorBootstrapToken(encode(`{"fsid":"abc","filesystem":"backupfs"}`))
+	assert.Error(t, err)
+}
+
+func TestFilesystemMirrorCommands(t *testing.T) {
+	var calls []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			for i, arg := range args {
+				if strings.HasPrefix(arg, "--connect-timeout") {
+					args = args[:i]
+					break
+				}
+			}
+			calls = append(calls, strings.Join(args, " "))
+			switch args[3] {
+			case "enable", "add":
+				return "", exitError(syscall.EEXIST)
+			case "remove":
+				return "", exitError(syscall.ENOENT)
+			case "disable":
+				return "", exitError(syscall.EINVAL)
+			case "peer_list":
+				return `{"3a1b":{"client_name":"client.mirror_remote","site_name":"site-b","fs_name":"backupfs"}}`, nil
+			case "dirmap":
+				return `{"instance_id":"4242","last_shuffled":1600000000.0,"state":"mapped"}`, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	// already enabled, mirrored or removed is not an error
+	assert.NoError(t, EnableFilesystemMirroring(context, "ns", "myfs"))
+	assert.NoError(t, AddFilesystemMirrorDirectory(context, "ns", "myfs", "/a"))
+	assert.NoError(t, RemoveFilesystemMirrorDirectory(context, "ns", "myfs", "/b"))
+	assert.Error(t, DisableFilesystemMirroring(context, "ns", "myfs"))
+
+	peers, err := ListFilesystemMirrorPeers(context, "ns", "myfs")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]FilesystemMirrorPeer{
+		"3a1b": {ClientName: "client.mirror_remote", SiteName: "site-b", Filesystem: "backupfs"},
+	}, peers)
+	assert.NoError(t, ImportFilesystemMirrorPeer(context, "ns", "myfs", "dG9rZW4=\n"))
+	assert.NoError(t, RemoveFilesystemMirrorPeer(context, "ns", "myfs", "3a1b"))
+
+	dirMap, err := GetFilesystemMirrorDirMap(context, "ns", "myfs", "/a")
+	assert.NoError(t, err)
+	assert.Equal(t, &FilesystemMirrorDirMap{InstanceID: "4242", State: "mapped"}, dirMap)
+
+	assert.Equal(t, []string{
+		"fs snapshot mirror enable myfs",
+		"fs snapshot mirror add myfs /a",
+		"fs snapshot mirror remove myfs /b",
+		"fs snapshot mirror disable myfs",
+		"fs snapshot mirror peer_list myfs",
+		"fs snapshot mirror peer_bootstrap import myfs dG9rZW4=",
+		"fs snapshot mirror peer_remove myfs 3a1b",
+		"fs snapshot mirror dirmap myfs /a",
+	}, calls)
+}
@@ -17,7 +17,9 @@ limitations under the License.
 package client
 
 import (
+	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
@@ -27,8 +29,33 @@ import (
 
 var (
 	moduleEnableWaitTime = 5 * time.Second
+	// the score in the output of "ceph balancer eval"
+	balancerScore = regexp.MustCompile(`score\s+([0-9.]+)`)
 )
 
+// BalancerStatus is the status of the balancer returned by "ceph balancer status"
+type BalancerStatus struct {
+	Active              bool   `json:"active"`
+	Mode                string `json:"mode"`
+	LastOptimizeStarted string `json:"last_optimize_started"`
+	OptimizeResult      string `json:"optimize_result"`
+}
+
+// GetMgrMap returns the mgr map with the active and standby mgrs
+func GetMgrMap(context *clusterd.Context, clusterName string) (*MgrMap, error) {
+	args := []string{"mgr", "dump"}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get mgr dump")
+	}
+
+	var mgrMap MgrMap
+	if err := json.Unmarshal(buf, &mgrMap); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal mgr dump response")
+	}
+	return &mgrMap, nil
+}
+
 // MgrEnableModule enables a mgr module
 func MgrEnableModule(context *clusterd.Context, clusterName, name string, force bool) error {
 	retryCount := 5
@@ -172,3 +199,33 @@ func ConfigureBalancerModule(context *clusterd.Context, clusterName, balancerMod
 
 	return nil
 }
+
+// GetBalancerStatus returns the status of the balancer module
+func GetBalancerStatus(context *clusterd.Context, clusterName string) (*BalancerStatus, error) {
+	args := []string{"balancer", "status"}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get balancer status")
+	}
+
+	var status BalancerStatus
+	if err := json.Unmarshal(buf, &status); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal balancer status response")
+	}
+	return &status, nil
+}
+
+// GetBalancerScore returns the score of the current data distribution of the cluster, lower is better
+func GetBalancerScore(context *clusterd.Context, clusterName string) (string, error) {
+	args := []string{"balancer", "eval"}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to evaluate the balancer score")
+	}
+
+	match := balancerScore.FindStringSubmatch(string(buf))
+	if match == nil {
+		return "", errors.Errorf("failed to parse balancer score from %q", strings.TrimSpace(string(buf)))
+	}
+	return match[1], nil
+}
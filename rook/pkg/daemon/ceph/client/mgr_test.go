@@ -133,3 +133,29 @@ func TestSetBalancerMode(t *testing.T) {
 	err := setBalancerMode(&clusterd.Context{Executor: executor}, "clusterName", "upmap")
 	assert.NoError(t, err)
 }
+
+func TestGetBalancerStatus(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[0] == "balancer" && args[1] == "status" {
+			return `{"active": true, "last_optimize_duration": "0:00:00.001", "last_optimize_started": "Tue Apr 14 09:30:01 2020", "mode": "upmap", "optimize_result": "Unable to find further optimization", "plans": []}`, nil
+		}
+		if args[0] == "balancer" && args[1] == "eval" {
+			return "current cluster score 0.012345 (lower is better)\n", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	status, err := GetBalancerStatus(context, "clusterName")
+	assert.NoError(t, err)
+	assert.True(t, status.Active)
+	assert.Equal(t, "upmap", status.Mode)
+	assert.Equal(t, "Tue Apr 14 09:30:01 2020", status.LastOptimizeStarted)
+	assert.Equal(t, "Unable to find further optimization", status.OptimizeResult)
+
+	score, err := GetBalancerScore(context, "clusterName")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.012345", score)
+}
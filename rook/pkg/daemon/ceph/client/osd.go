@@ -61,14 +61,28 @@ type OSDPerfStats struct {
 
 type OSDDump struct {
 	OSDs []struct {
-		OSD json.Number `json:"osd"`
-		Up  json.Number `json:"up"`
-		In  json.Number `json:"in"`
+		OSD   json.Number `json:"osd"`
+		Up    json.Number `json:"up"`
+		In    json.Number `json:"in"`
+		State []string    `json:"state"`
+		// UpFrom is the epoch since which the OSD is up, it changes each time the OSD goes down and up again
+		UpFrom json.Number `json:"up_from"`
 	} `json:"osds"`
 	Flags          string              `json:"flags"`
 	CrushNodeFlags map[string][]string `json:"crush_node_flags"`
 }
 
+// OSDMetadata is the go representation of the metadata reported by an OSD
+type OSDMetadata struct {
+	ID          int    `json:"id"`
+	Hostname    string `json:"hostname"`
+	ObjectStore string `json:"osd_objectstore"`
+	// OSDData is the data directory of the OSD, e.g. "/var/lib/ceph/osd/ceph-0"
+	OSDData string `json:"osd_data"`
+	// Devices is the comma separated list of the devices of the OSD, e.g. "sdb"
+	Devices string `json:"devices"`
+}
+
 // IsFlagSet checks if an OSD flag is set
 func (dump *OSDDump) IsFlagSet(checkFlag string) bool {
 	flags := strings.Split(dump.Flags, ",")
@@ -172,6 +186,24 @@ type OsdTree struct {
 	} `json:"stray"`
 }
 
+// DestroyedOSDs returns the IDs of the OSDs that were destroyed and whose ID can be reused
+func (dump *OSDDump) DestroyedOSDs() ([]int, error) {
+	destroyed := []int{}
+	for _, d := range dump.OSDs {
+		id, err := d.OSD.Int64()
+		if err != nil {
+			return nil, err
+		}
+		for _, state := range d.State {
+			if state == "destroyed" {
+				destroyed = append(destroyed, int(id))
+				break
+			}
+		}
+	}
+	return destroyed, nil
+}
+
 // OsdList returns the list of OSD by their IDs
 type OsdList []int
 
@@ -253,6 +285,66 @@ func OSDOut(context *clusterd.Context, clusterName string, osdID int) (string, e
 	return string(buf), err
 }
 
+// SetOSDNoUp sets the noup flag on an OSD so it is not marked up when it boots
+func SetOSDNoUp(context *clusterd.Context, clusterName string, osdID int) error {
+	args := []string{"osd", "add-noup", "osd." + strconv.Itoa(osdID)}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to set noup on osd.%d. %s", osdID, string(buf))
+	}
+	return nil
+}
+
+// GetOSDMetadata returns the metadata reported by all the OSDs
+func GetOSDMetadata(context *clusterd.Context, clusterName string) ([]OSDMetadata, error) {
+	args := []string{"osd", "metadata"}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get osd metadata")
+	}
+
+	var metadata []OSDMetadata
+	if err := json.Unmarshal(buf, &metadata); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal osd metadata response")
+	}
+	return metadata, nil
+}
+
+// OSDIn marks the OSD as in so data is mapped to it again
+func OSDIn(context *clusterd.Context, clusterName string, osdID int) (string, error) {
+	args := []string{"osd", "in", strconv.Itoa(osdID)}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	return string(buf), err
+}
+
+// OSDDown marks the OSD as down in the osd map
+func OSDDown(context *clusterd.Context, clusterName string, osdID int) (string, error) {
+	args := []string{"osd", "down", strconv.Itoa(osdID)}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	return string(buf), err
+}
+
+// DestroyOSD removes the auth key of the OSD and marks it as destroyed, keeping its ID and CRUSH position so a new
+// OSD can be prepared with the same ID
+func DestroyOSD(context *clusterd.Context, clusterName string, osdID int) error {
+	args := []string{"osd", "destroy", strconv.Itoa(osdID), "--yes-i-really-mean-it"}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to destroy osd.%d. %s", osdID, string(buf))
+	}
+	return nil
+}
+
+// PurgeOSD removes the OSD from the crush map, deletes its auth key and removes it from the osd map
+func PurgeOSD(context *clusterd.Context, clusterName string, osdID int) error {
+	args := []string{"osd", "purge", strconv.Itoa(osdID), "--yes-i-really-mean-it"}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to purge osd.%d. %s", osdID, string(buf))
+	}
+	return nil
+}
+
 func OsdSafeToDestroy(context *clusterd.Context, clusterName string, osdID int, cephVersion cephver.CephVersion) (bool, error) {
 	args := []string{"osd", "safe-to-destroy", strconv.Itoa(osdID)}
 	cmd := NewCephCommand(context, clusterName, args)
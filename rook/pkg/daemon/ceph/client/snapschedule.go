@@ -0,0 +1,172 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
+)
+
+const (
+	// SnapScheduleModule is the mgr module taking the scheduled snapshots of the filesystems
+	SnapScheduleModule = "snap_schedule"
+
+	// the periods of the snapshot retention, in the order they are reported
+	snapRetentionPeriods = "nmhdwMy"
+)
+
+var snapRetentionPattern = regexp.MustCompile(`^([0-9]+)([` + snapRetentionPeriods + `])`)
+
+// SnapSchedule is a snapshot schedule of a directory of a filesystem returned by "ceph fs snap-schedule list"
+type SnapSchedule struct {
+	Path      string         `json:"path"`
+	Schedule  string         `json:"schedule"`
+	Start     string         `json:"start"`
+	Retention map[string]int `json:"retention"`
+	Last      string         `json:"last"`
+	Active    bool           `json:"active"`
+}
+
+// ListSnapSchedules returns the snapshot schedules of all the directories of a filesystem
+func ListSnapSchedules(context *clusterd.Context, clusterName, fsName string) ([]SnapSchedule, error) {
+	args := []string{"fs", "snap-schedule", "list", "/", "--recursive=true", "--fs", fsName}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		if code, ok := exec.ExitStatus(err); ok && code == int(syscall.ENOENT) {
+			// no schedule exists in the filesystem
+			return []SnapSchedule{}, nil
+		}
+		return nil, errors.Wrapf(err, "failed to list the snapshot schedules of filesystem %q", fsName)
+	}
+
+	schedules := []SnapSchedule{}
+	if strings.TrimSpace(string(buf)) == "" {
+		return schedules, nil
+	}
+	if err := json.Unmarshal(buf, &schedules); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal snap-schedule list response")
+	}
+	return schedules, nil
+}
+
+// AddSnapSchedule adds a schedule taking a snapshot of a directory of the filesystem at every interval,
+// from the start time if it is not empty
+func AddSnapSchedule(context *clusterd.Context, clusterName, fsName, path, interval, start string) error {
+	args := []string{"fs", "snap-schedule", "add", path, interval}
+	if start != "" {
+		args = append(args, start)
+	}
+	args = append(args, "--fs", fsName)
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to add snapshot schedule %q of %q in filesystem %q", interval, path, fsName)
+	}
+	logger.Infof("added snapshot schedule %q of %q in filesystem %q", interval, path, fsName)
+	return nil
+}
+
+// RemoveSnapSchedule removes a snapshot schedule of a directory of the filesystem
+func RemoveSnapSchedule(context *clusterd.Context, clusterName, fsName, path, interval, start string) error {
+	args := []string{"fs", "snap-schedule", "remove", path, interval}
+	if start != "" {
+		args = append(args, start)
+	}
+	args = append(args, "--fs", fsName)
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to remove snapshot schedule %q of %q in filesystem %q", interval, path, fsName)
+	}
+	logger.Infof("removed snapshot schedule %q of %q in filesystem %q", interval, path, fsName)
+	return nil
+}
+
+// AddSnapRetention adds the counts of snapshots to keep per period to the retention of a directory of the filesystem
+func AddSnapRetention(context *clusterd.Context, clusterName, fsName, path string, retention map[string]int) error {
+	return setSnapRetention(context, clusterName, fsName, path, retention, "add")
+}
+
+// RemoveSnapRetention removes the counts of snapshots to keep per period from the retention of a directory of
+// the filesystem
+func RemoveSnapRetention(context *clusterd.Context, clusterName, fsName, path string, retention map[string]int) error {
+	return setSnapRetention(context, clusterName, fsName, path, retention, "remove")
+}
+
+func setSnapRetention(context *clusterd.Context, clusterName, fsName, path string, retention map[string]int, action string) error {
+	if len(retention) == 0 {
+		return nil
+	}
+	spec := FormatSnapRetention(retention)
+	args := []string{"fs", "snap-schedule", "retention", action, path, spec, "--fs", fsName}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to %s snapshot retention %q of %q in filesystem %q", action, spec, path, fsName)
+	}
+	logger.Infof("snapshot retention %q of %q in filesystem %q: %s", spec, path, fsName, action)
+	return nil
+}
+
+// ParseSnapRetention parses a snapshot retention, the counts of snapshots to keep per period. The retention
+// is either in the compact form "24h7d" or the "h 24" form of a single period.
+func ParseSnapRetention(spec string) (map[string]int, error) {
+	retention := map[string]int{}
+	fields := strings.Fields(spec)
+	if len(fields) == 2 && len(fields[0]) == 1 {
+		spec = fields[1] + fields[0]
+	} else if len(fields) != 1 {
+		return nil, errors.Errorf("invalid snapshot retention %q", spec)
+	}
+
+	for spec != "" {
+		match := snapRetentionPattern.FindStringSubmatch(spec)
+		if match == nil {
+			return nil, errors.Errorf("invalid snapshot retention %q, expected counts of the periods %q such as 24h7d", spec, snapRetentionPeriods)
+		}
+		count, err := strconv.Atoi(match[1])
+		if err != nil || count == 0 {
+			return nil, errors.Errorf("invalid count %q of snapshot retention", match[1])
+		}
+		if _, ok := retention[match[2]]; ok {
+			return nil, errors.Errorf("period %q of snapshot retention is specified more than once", match[2])
+		}
+		retention[match[2]] = count
+		spec = spec[len(match[0]):]
+	}
+	return retention, nil
+}
+
+// FormatSnapRetention formats a snapshot retention in the compact form "24h7d"
+func FormatSnapRetention(retention map[string]int) string {
+	periods := []string{}
+	for period := range retention {
+		periods = append(periods, period)
+	}
+	sort.Slice(periods, func(i, j int) bool {
+		return strings.Index(snapRetentionPeriods, periods[i]) < strings.Index(snapRetentionPeriods, periods[j])
+	})
+
+	spec := ""
+	for _, period := range periods {
+		spec += fmt.Sprintf("%d%s", retention[period], period)
+	}
+	return spec
+}
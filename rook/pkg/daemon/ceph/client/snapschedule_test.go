@@ -0,0 +1,88 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapScheduleCommands(t *testing.T) {
+	var calls []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			for i, arg := range args {
+				if strings.HasPrefix(arg, "--connect-timeout") {
+					args = args[:i]
+					break
+				}
+			}
+			calls = append(calls, strings.Join(args, " "))
+			if args[2] == "list" {
+				return `[{"fs":"myfs","path":"/","schedule":"1h","retention":{"h":24},"start":"2020-06-01T00:00:00","last":"2020-06-02T10:00:00","active":true}]`, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	schedules, err := ListSnapSchedules(context, "ns", "myfs")
+	assert.NoError(t, err)
+	assert.Equal(t, []SnapSchedule{
+		{Path: "/", Schedule: "1h", Start: "2020-06-01T00:00:00", Retention: map[string]int{"h": 24}, Last: "2020-06-02T10:00:00", Active: true},
+	}, schedules)
+
+	assert.NoError(t, AddSnapSchedule(context, "ns", "myfs", "/a", "1d", ""))
+	assert.NoError(t, AddSnapSchedule(context, "ns", "myfs", "/a", "1h", "2020-06-01T00:00:00"))
+	assert.NoError(t, RemoveSnapSchedule(context, "ns", "myfs", "/", "1h", "2020-06-01T00:00:00"))
+	assert.NoError(t, AddSnapRetention(context, "ns", "myfs", "/", map[string]int{"d": 7, "h": 24}))
+	assert.NoError(t, RemoveSnapRetention(context, "ns", "myfs", "/", map[string]int{"w": 4}))
+	// an empty retention is not applied
+	assert.NoError(t, AddSnapRetention(context, "ns", "myfs", "/", map[string]int{}))
+
+	assert.Equal(t, []string{
+		"fs snap-schedule list / --recursive=true --fs myfs",
+		"fs snap-schedule add /a 1d --fs myfs",
+		"fs snap-schedule add /a 1h 2020-06-01T00:00:00 --fs myfs",
+		"fs snap-schedule remove / 1h 2020-06-01T00:00:00 --fs myfs",
+		"fs snap-schedule retention add / 24h7d --fs myfs",
+		"fs snap-schedule retention remove / 4w --fs myfs",
+	}, calls)
+}
+
+func TestParseSnapRetention(t *testing.T) {
+	retention, err := ParseSnapRetention("24h7d4w")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"h": 24, "d": 7, "w": 4}, retention)
+	assert.Equal(t, "24h7d4w", FormatSnapRetention(retention))
+
+	retention, err = ParseSnapRetention("h 24")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"h": 24}, retention)
+
+	// the periods are formatted from the shortest
+	assert.Equal(t, "10n6M1y", FormatSnapRetention(map[string]int{"y": 1, "M": 6, "n": 10}))
+
+	for _, spec := range []string{"", "24", "h", "24x", "0h", "24h 7d", "24h24h", "hours 24"} {
+		_, err = ParseSnapRetention(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -18,6 +18,7 @@ package client
 
 import (
 	"encoding/json"
+	"strconv"
 	"strings"
 	"time"
 
@@ -214,6 +215,24 @@ func okToStopDaemon(context *clusterd.Context, deployment, clusterName, daemonTy
 	return nil
 }
 
+// OkToStopOSDs determines whether the given OSDs can be stopped at the same time without making PGs unavailable
+func OkToStopOSDs(context *clusterd.Context, clusterName string, osdIDs []int) error {
+	if osdDoNothing(context, clusterName) {
+		return nil
+	}
+
+	args := []string{"osd", "ok-to-stop"}
+	for _, osdID := range osdIDs {
+		args = append(args, strconv.Itoa(osdID))
+	}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "osds %v cannot be stopped. %s", osdIDs, string(buf))
+	}
+	logger.Debugf("osds %v are ok to be updated. %s", osdIDs, string(buf))
+	return nil
+}
+
 // okToContinueOSDDaemon determines whether it's fine to go to the next osd during an upgrade
 // This basically makes sure all the PGs have settled
 func okToContinueOSDDaemon(context *clusterd.Context, namespace string) error {
@@ -0,0 +1,164 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package filesystem manages the directories of a CephFS filesystem from a client mount.
+package filesystem
+
+import (
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+)
+
+const (
+	quotaMaxBytesAttr = "ceph.quota.max_bytes"
+	quotaMaxFilesAttr = "ceph.quota.max_files"
+	exportPinAttr     = "ceph.dir.pin"
+
+	// NoExportPin is the export pin of a directory that is not pinned to an MDS rank
+	NoExportPin = -1
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "cephfs-directories")
+
+// DirectoryState is the quotas and the export pin of a directory of the filesystem
+type DirectoryState struct {
+	Path      string `json:"path"`
+	MaxBytes  int64  `json:"maxBytes"`
+	MaxFiles  int64  `json:"maxFiles"`
+	ExportPin int    `json:"exportPin"`
+	// Missing is true if the directory did not exist in the filesystem
+	Missing bool `json:"missing,omitempty"`
+}
+
+// ApplyDirectories mounts the filesystem and sets the quotas and the export pins of the directories,
+// creating the directories that do not exist. The state of the directories found before they were
+// changed is returned.
+func ApplyDirectories(context *clusterd.Context, configFile, fsName, mountPoint string, dirs []DirectoryState) ([]DirectoryState, error) {
+	unmount, err := mountFilesystem(context, configFile, fsName, mountPoint)
+	if err != nil {
+		return nil, err
+	}
+	defer unmount()
+
+	observed := []DirectoryState{}
+	for _, dir := range dirs {
+		found, err := applyDirectory(context, mountPoint, dir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to apply the settings of directory %q", dir.Path)
+		}
+		observed = append(observed, found)
+	}
+	return observed, nil
+}
+
+// mountFilesystem mounts the filesystem with the admin credentials and returns the function unmounting it
+func mountFilesystem(context *clusterd.Context, configFile, fsName, mountPoint string) (func(), error) {
+	if err := os.MkdirAll(mountPoint, 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create mount point %q", mountPoint)
+	}
+
+	logger.Infof("mounting filesystem %q on %q", fsName, mountPoint)
+	err := context.Executor.ExecuteCommand("ceph-fuse", mountPoint,
+		"--conf", configFile,
+		"--name", client.AdminUsername,
+		"--client_mds_namespace", fsName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to mount filesystem %q", fsName)
+	}
+	return func() {
+		if err := context.Executor.ExecuteCommand("fusermount", "-u", mountPoint); err != nil {
+			logger.Errorf("failed to unmount filesystem %q from %q. %v", fsName, mountPoint, err)
+		}
+	}, nil
+}
+
+func applyDirectory(context *clusterd.Context, mountPoint string, dir DirectoryState) (DirectoryState, error) {
+	dirPath := path.Join(mountPoint, dir.Path)
+	found := DirectoryState{Path: dir.Path, ExportPin: NoExportPin}
+
+	if _, err := os.Stat(dirPath); err != nil {
+		if !os.IsNotExist(err) {
+			return found, errors.Wrapf(err, "failed to stat directory")
+		}
+		logger.Infof("creating directory %q", dir.Path)
+		if err := os.MkdirAll(dirPath, 0755); err != nil {
+			return found, errors.Wrapf(err, "failed to create directory")
+		}
+		found.Missing = true
+	} else {
+		found.MaxBytes = getIntAttr(context, dirPath, quotaMaxBytesAttr, 0)
+		found.MaxFiles = getIntAttr(context, dirPath, quotaMaxFilesAttr, 0)
+		found.ExportPin = int(getIntAttr(context, dirPath, exportPinAttr, NoExportPin))
+	}
+
+	if found.MaxBytes != dir.MaxBytes {
+		if err := setIntAttr(context, dirPath, quotaMaxBytesAttr, dir.MaxBytes); err != nil {
+			return found, err
+		}
+	}
+	if found.MaxFiles != dir.MaxFiles {
+		if err := setIntAttr(context, dirPath, quotaMaxFilesAttr, dir.MaxFiles); err != nil {
+			return found, err
+		}
+	}
+	if found.ExportPin != dir.ExportPin {
+		if err := setIntAttr(context, dirPath, exportPinAttr, int64(dir.ExportPin)); err != nil {
+			return found, err
+		}
+	}
+	return found, nil
+}
+
+// getIntAttr reads an attribute of a directory. The attributes that are not set on the directory
+// cannot be read, in which case the default value is returned.
+func getIntAttr(context *clusterd.Context, dirPath, name string, defaultValue int64) int64 {
+	output, ok := getAttr(context, dirPath, name)
+	if !ok {
+		return defaultValue
+	}
+	value, err := strconv.ParseInt(output, 10, 64)
+	if err != nil {
+		logger.Warningf("failed to parse attribute %q of %q with value %q. %v", name, dirPath, output, err)
+		return defaultValue
+	}
+	return value
+}
+
+// getAttr reads an attribute of a directory, it returns false if the attribute is not set on the directory
+func getAttr(context *clusterd.Context, dirPath, name string) (string, bool) {
+	output, err := context.Executor.ExecuteCommandWithOutput("getfattr", "--only-values", "--absolute-names", "-n", name, dirPath)
+	if err != nil {
+		logger.Debugf("attribute %q of %q is not set. %v", name, dirPath, err)
+		return "", false
+	}
+	return strings.TrimSpace(output), true
+}
+
+func setIntAttr(context *clusterd.Context, dirPath, name string, value int64) error {
+	logger.Infof("setting attribute %q of %q to %d", name, dirPath, value)
+	err := context.Executor.ExecuteCommand("setfattr", "-n", name, "-v", strconv.FormatInt(value, 10), dirPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set attribute %q to %d", name, value)
+	}
+	return nil
+}
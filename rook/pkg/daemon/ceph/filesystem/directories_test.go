@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyDirectories(t *testing.T) {
+	mountPoint, err := ioutil.TempDir("", "TestApplyDirectories")
+	assert.NoError(t, err)
+	defer os.RemoveAll(mountPoint)
+	assert.NoError(t, os.MkdirAll(path.Join(mountPoint, "projects/a"), 0755))
+
+	// the existing directory has a quota of bytes and is pinned to rank 1
+	attrs := map[string]string{
+		path.Join(mountPoint, "projects/a") + " " + quotaMaxBytesAttr: "1024",
+		path.Join(mountPoint, "projects/a") + " " + exportPinAttr:     "1",
+	}
+	mounted := false
+	set := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(command string, args ...string) error {
+			switch command {
+			case "ceph-fuse":
+				assert.Equal(t, mountPoint, args[0])
+				assert.Equal(t, "myfs", args[len(args)-1])
+				mounted = true
+			case "fusermount":
+				mounted = false
+			case "setfattr":
+				assert.True(t, mounted)
+				set = append(set, strings.TrimPrefix(strings.Join(args, " "), "-n "))
+			}
+			return nil
+		},
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			assert.Equal(t, "getfattr", command)
+			if value, ok := attrs[args[len(args)-1]+" "+args[len(args)-2]]; ok {
+				return value, nil
+			}
+			return "", errors.New("No such attribute")
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	dirs := []DirectoryState{
+		{Path: "/projects/a", MaxBytes: 1024, MaxFiles: 100, ExportPin: 0},
+		{Path: "/projects/b", MaxBytes: 2048, ExportPin: NoExportPin},
+	}
+	observed, err := ApplyDirectories(context, "/etc/ceph/ceph.conf", "myfs", mountPoint, dirs)
+	assert.NoError(t, err)
+	assert.False(t, mounted)
+	assert.Equal(t, []DirectoryState{
+		{Path: "/projects/a", MaxBytes: 1024, MaxFiles: 0, ExportPin: 1},
+		{Path: "/projects/b", ExportPin: NoExportPin, Missing: true},
+	}, observed)
+
+	// the missing directory was created and only the settings that differ were set
+	_, err = os.Stat(path.Join(mountPoint, "projects/b"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		quotaMaxFilesAttr + " -v 100 " + path.Join(mountPoint, "projects/a"),
+		exportPinAttr + " -v 0 " + path.Join(mountPoint, "projects/a"),
+		quotaMaxBytesAttr + " -v 2048 " + path.Join(mountPoint, "projects/b"),
+	}, set)
+
+	// a failure to mount is returned
+	executor.MockExecuteCommand = func(command string, args ...string) error {
+		return errors.New("mount failed")
+	}
+	_, err = ApplyDirectories(context, "/etc/ceph/ceph.conf", "myfs", mountPoint, dirs)
+	assert.Error(t, err)
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+// the data pool of the layout of a directory, only set on the directories with their own layout
+const dirLayoutPoolAttr = "ceph.dir.layout.pool"
+
+// FindPoolLayouts mounts the filesystem and returns by pool the directories whose layout places the data of
+// their new files in one of the pools
+func FindPoolLayouts(context *clusterd.Context, configFile, fsName, mountPoint string, pools []string) (map[string][]string, error) {
+	unmount, err := mountFilesystem(context, configFile, fsName, mountPoint)
+	if err != nil {
+		return nil, err
+	}
+	defer unmount()
+
+	wanted := map[string]bool{}
+	for _, pool := range pools {
+		wanted[pool] = true
+	}
+
+	layouts := map[string][]string{}
+	err = filepath.Walk(mountPoint, func(dirPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		pool, ok := getAttr(context, dirPath, dirLayoutPoolAttr)
+		if !ok || !wanted[pool] {
+			return nil
+		}
+		rel, err := filepath.Rel(mountPoint, dirPath)
+		if err != nil {
+			return err
+		}
+		layouts[pool] = append(layouts[pool], path.Join("/", rel))
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the layouts of the directories of filesystem %q", fsName)
+	}
+	return layouts, nil
+}
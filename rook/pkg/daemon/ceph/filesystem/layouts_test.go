@@ -0,0 +1,77 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindPoolLayouts(t *testing.T) {
+	mountPoint, err := ioutil.TempDir("", "TestFindPoolLayouts")
+	assert.NoError(t, err)
+	defer os.RemoveAll(mountPoint)
+	assert.NoError(t, os.MkdirAll(path.Join(mountPoint, "projects/a"), 0755))
+	assert.NoError(t, os.MkdirAll(path.Join(mountPoint, "archive"), 0755))
+	assert.NoError(t, ioutil.WriteFile(path.Join(mountPoint, "projects/file"), []byte("data"), 0644))
+
+	layouts := map[string]string{
+		mountPoint:                          "myfs-data0",
+		path.Join(mountPoint, "projects/a"): "myfs-data1",
+		path.Join(mountPoint, "archive"):    "myfs-data2",
+	}
+	mounted := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(command string, args ...string) error {
+			switch command {
+			case "ceph-fuse":
+				mounted = true
+			case "fusermount":
+				mounted = false
+			}
+			return nil
+		},
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			assert.True(t, mounted)
+			assert.Equal(t, dirLayoutPoolAttr, args[len(args)-2])
+			dir := args[len(args)-1]
+			info, err := os.Stat(dir)
+			assert.NoError(t, err)
+			assert.True(t, info.IsDir())
+			if pool, ok := layouts[dir]; ok {
+				return pool, nil
+			}
+			return "", errors.New("No such attribute")
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	found, err := FindPoolLayouts(context, "/etc/ceph/ceph.conf", "myfs", mountPoint, []string{"myfs-data1", "myfs-data2", "myfs-data3"})
+	assert.NoError(t, err)
+	assert.False(t, mounted)
+	assert.Equal(t, map[string][]string{
+		"myfs-data1": {"/projects/a"},
+		"myfs-data2": {"/archive"},
+	}, found)
+}
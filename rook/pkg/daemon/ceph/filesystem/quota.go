@@ -0,0 +1,49 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem
+
+import (
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+// ApplyQuota mounts the filesystem and sets the quota of bytes of an existing directory, such as the directory of a
+// subvolume group. Only the quota of bytes is changed, a max bytes of 0 removes the quota. The quota found before it
+// was changed is returned.
+func ApplyQuota(context *clusterd.Context, configFile, fsName, mountPoint, dirPath string, maxBytes int64) (int64, error) {
+	unmount, err := mountFilesystem(context, configFile, fsName, mountPoint)
+	if err != nil {
+		return 0, err
+	}
+	defer unmount()
+
+	fullPath := path.Join(mountPoint, dirPath)
+	if _, err := os.Stat(fullPath); err != nil {
+		return 0, errors.Wrapf(err, "failed to find directory %q", dirPath)
+	}
+
+	found := getIntAttr(context, fullPath, quotaMaxBytesAttr, 0)
+	if found != maxBytes {
+		if err := setIntAttr(context, fullPath, quotaMaxBytesAttr, maxBytes); err != nil {
+			return found, errors.Wrapf(err, "failed to set the quota of directory %q", dirPath)
+		}
+	}
+	return found, nil
+}
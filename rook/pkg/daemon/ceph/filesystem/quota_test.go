@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyQuota(t *testing.T) {
+	mountPoint, err := ioutil.TempDir("", "TestApplyQuota")
+	assert.NoError(t, err)
+	defer os.RemoveAll(mountPoint)
+	groupPath := path.Join(mountPoint, "volumes/group-a")
+	assert.NoError(t, os.MkdirAll(groupPath, 0755))
+
+	maxBytes := ""
+	mounted := false
+	set := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(command string, args ...string) error {
+			switch command {
+			case "ceph-fuse":
+				mounted = true
+			case "fusermount":
+				mounted = false
+			case "setfattr":
+				assert.True(t, mounted)
+				set = append(set, strings.TrimPrefix(strings.Join(args, " "), "-n "))
+			}
+			return nil
+		},
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			assert.Equal(t, quotaMaxBytesAttr, args[len(args)-2])
+			if maxBytes == "" {
+				return "", errors.New("No such attribute")
+			}
+			return maxBytes, nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	// the quota is set on the directory without a quota
+	found, err := ApplyQuota(context, "/etc/ceph/ceph.conf", "myfs", mountPoint, "/volumes/group-a", 1024)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), found)
+	assert.False(t, mounted)
+	assert.Equal(t, []string{quotaMaxBytesAttr + " -v 1024 " + groupPath}, set)
+
+	// the quota is not set again
+	maxBytes = "1024"
+	set = []string{}
+	found, err = ApplyQuota(context, "/etc/ceph/ceph.conf", "myfs", mountPoint, "/volumes/group-a", 1024)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1024), found)
+	assert.Empty(t, set)
+
+	// the quota is removed
+	found, err = ApplyQuota(context, "/etc/ceph/ceph.conf", "myfs", mountPoint, "/volumes/group-a", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1024), found)
+	assert.Equal(t, []string{quotaMaxBytesAttr + " -v 0 " + groupPath}, set)
+
+	// the directory is not created
+	_, err = ApplyQuota(context, "/etc/ceph/ceph.conf", "myfs", mountPoint, "/volumes/group-b", 1024)
+	assert.Error(t, err)
+	_, err = os.Stat(path.Join(mountPoint, "volumes/group-b"))
+	assert.True(t, os.IsNotExist(err))
+}
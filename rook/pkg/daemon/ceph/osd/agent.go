@@ -37,6 +37,7 @@ type OsdAgent struct {
 	storeConfig    config.StoreConfig
 	kv             *k8sutil.ConfigMapKVStore
 	pvcBacked      bool
+	migrateOSDIDs  []int
 	configCounter  int32
 	osdsCompleted  chan struct{}
 }
@@ -48,7 +49,8 @@ type device struct {
 
 // NewAgent is the instantiation of the OSD agent
 func NewAgent(context *clusterd.Context, devices []DesiredDevice, metadataDevice string, forceFormat bool,
-	storeConfig config.StoreConfig, cluster *cephconfig.ClusterInfo, nodeName string, kv *k8sutil.ConfigMapKVStore, pvcBacked bool) *OsdAgent {
+	storeConfig config.StoreConfig, cluster *cephconfig.ClusterInfo, nodeName string, kv *k8sutil.ConfigMapKVStore, pvcBacked bool,
+	migrateOSDIDs []int) *OsdAgent {
 
 	return &OsdAgent{
 		devices:        devices,
@@ -59,6 +61,7 @@ func NewAgent(context *clusterd.Context, devices []DesiredDevice, metadataDevice
 		nodeName:       nodeName,
 		kv:             kv,
 		pvcBacked:      pvcBacked,
+		migrateOSDIDs:  migrateOSDIDs,
 	}
 }
 
@@ -175,6 +175,14 @@ func Provision(context *clusterd.Context, agent *OsdAgent, crushLocation, namesp
 		logger.Warningf("wrote and copied config file but failed to read it back from %s for logging. %v", cephconfig.DefaultConfigFilePath(), err)
 	}
 
+	if !agent.pvcBacked {
+		// the filestore osds destroyed by the operator are prepared again with bluestore before the devices are
+		// discovered, the osds are then reported as any existing ceph-volume osd
+		if err := agent.prepareMigratedOSDs(context); err != nil {
+			return errors.Wrap(err, "failed to migrate osds to bluestore")
+		}
+	}
+
 	logger.Infof("discovering hardware")
 
 	var rawDevices []*sys.LocalDisk
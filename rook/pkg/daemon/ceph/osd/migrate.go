@@ -0,0 +1,78 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+// prepareMigratedOSDs prepares again with bluestore the filestore OSDs that were destroyed by the operator to be
+// migrated. The OSDs keep their ID, their devices are zapped and used as the bluestore data device.
+func (a *OsdAgent) prepareMigratedOSDs(context *clusterd.Context) error {
+	if len(a.migrateOSDIDs) == 0 {
+		return nil
+	}
+
+	if err := createOSDBootstrapKeyring(context, a.cluster.Name, cephConfigDir); err != nil {
+		return errors.Wrapf(err, "failed to generate osd keyring")
+	}
+
+	result, err := context.Executor.ExecuteCommandWithOutput(cephVolumeCmd, "lvm", "list", "--format", "json")
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve ceph-volume lvm list results")
+	}
+	var cephVolumeResult map[string][]osdInfo
+	if err := json.Unmarshal([]byte(result), &cephVolumeResult); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal ceph-volume lvm list results")
+	}
+
+	for _, osdID := range a.migrateOSDIDs {
+		id := strconv.Itoa(osdID)
+		device := ""
+		migrated := false
+		for _, info := range cephVolumeResult[id] {
+			if info.Type == "block" {
+				migrated = true
+			}
+			if info.Type == "data" && len(info.Devices) > 0 {
+				device = info.Devices[0]
+			}
+		}
+		if migrated {
+			logger.Infof("osd.%d is already prepared with bluestore", osdID)
+			continue
+		}
+		if device == "" {
+			// directory based osds were not created by ceph-volume, they cannot be migrated
+			logger.Warningf("no ceph-volume device found for osd.%d, it cannot be prepared again with bluestore", osdID)
+			continue
+		}
+
+		logger.Infof("preparing osd.%d again with bluestore on device %q", osdID, device)
+		if err := context.Executor.ExecuteCommand(cephVolumeCmd, "lvm", "zap", "--osd-id", id, "--destroy"); err != nil {
+			return errors.Wrapf(err, "failed to zap the devices of osd.%d", osdID)
+		}
+		if err := context.Executor.ExecuteCommand("stdbuf", "-oL", cephVolumeCmd, "lvm", "prepare", "--bluestore", "--osd-id", id, "--data", device); err != nil {
+			return errors.Wrapf(err, "failed to prepare osd.%d with bluestore", osdID)
+		}
+	}
+	return nil
+}
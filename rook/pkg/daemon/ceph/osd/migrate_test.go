@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrepareMigratedOSDs(t *testing.T) {
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	defer func(dir string) { cephConfigDir = dir }(cephConfigDir)
+	cephConfigDir = configDir
+
+	// osd.0 is a filestore osd on sdb, osd.1 is already prepared with bluestore, osd.2 is not a ceph-volume osd
+	lvmList := `{
+	"0": [{"name":"osd-data-0","type":"data","devices":["/dev/sdb"],"tags":{}},{"name":"osd-journal-0","type":"journal","devices":["/dev/sdb"],"tags":{}}],
+	"1": [{"name":"osd-block-1","type":"block","devices":["/dev/sdc"],"tags":{}}]
+}`
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			return `{"key":"mysecurekey"}`, nil
+		},
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			return lvmList, nil
+		},
+		MockExecuteCommand: func(command string, args ...string) error {
+			commands = append(commands, command+" "+strings.Join(args, " "))
+			return nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, ConfigDir: configDir}
+
+	// nothing to migrate
+	agent := &OsdAgent{cluster: &cephconfig.ClusterInfo{Name: "mycluster"}}
+	assert.NoError(t, agent.prepareMigratedOSDs(context))
+	assert.Equal(t, 0, len(commands))
+
+	agent.migrateOSDIDs = []int{0, 1, 2}
+	assert.NoError(t, agent.prepareMigratedOSDs(context))
+	assert.Equal(t, []string{
+		"ceph-volume lvm zap --osd-id 0 --destroy",
+		"stdbuf -oL ceph-volume lvm prepare --bluestore --osd-id 0 --data /dev/sdb",
+	}, commands)
+}
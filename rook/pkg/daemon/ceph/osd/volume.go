@@ -63,9 +63,10 @@ type osdInfoBlock struct {
 }
 
 type osdInfo struct {
-	Name string  `json:"name"`
-	Path string  `json:"path"`
-	Tags osdTags `json:"tags"`
+	Name    string   `json:"name"`
+	Path    string   `json:"path"`
+	Devices []string `json:"devices"`
+	Tags    osdTags  `json:"tags"`
 	// "data" or "journal" for filestore and "block" for bluestore
 	Type string `json:"type"`
 }
@@ -34,6 +34,9 @@ import (
 const (
 	// defaultStatusCheckInterval is the interval to check the status of the ceph cluster
 	defaultStatusCheckInterval = 60 * time.Second
+	// balancerScoreInterval is the interval to evaluate the score of the data distribution, which is costly on large
+	// clusters
+	balancerScoreInterval = 15 * time.Minute
 )
 
 // cephStatusChecker aggregates the mon/cluster info needed to check the health of the monitors
@@ -44,6 +47,9 @@ type cephStatusChecker struct {
 	interval     time.Duration
 	externalCred config.ExternalCred
 	isExternal   bool
+	// the last balancer score and when it was evaluated
+	balancerScore        string
+	balancerScoreChecked time.Time
 }
 
 // newCephStatusChecker creates a new HealthChecker object
@@ -108,7 +114,7 @@ func (c *cephStatusChecker) checkStatus() {
 	if err != nil {
 		logger.Errorf("failed to get ceph status. %v", err)
 		condition, reason, message := c.conditionMessageReason(cephv1.ConditionFailure)
-		if err := c.updateCephStatus(cephStatusOnError(err.Error()), condition, reason, message); err != nil {
+		if err := c.updateCephStatus(cephStatusOnError(err.Error()), nil, condition, reason, message); err != nil {
 			logger.Errorf("failed to query cluster status in namespace %q. %v", c.namespace, err)
 		}
 		return
@@ -116,13 +122,51 @@ func (c *cephStatusChecker) checkStatus() {
 
 	logger.Debugf("Cluster status: %+v", status)
 	condition, reason, message := c.conditionMessageReason(cephv1.ConditionReady)
-	if err := c.updateCephStatus(&status, condition, reason, message); err != nil {
+	if err := c.updateCephStatus(&status, c.getBalancerStatus(), condition, reason, message); err != nil {
 		logger.Errorf("failed to query cluster status in namespace %q. %v", c.namespace, err)
 	}
 }
 
-// updateCephStatus detects the latest health status from ceph and updates the CR status
-func (c *cephStatusChecker) updateCephStatus(status *client.CephStatus, condition cephv1.ConditionType, reason, message string) error {
+// getBalancerStatus returns the status and score of the balancer, or nil if they cannot be checked
+func (c *cephStatusChecker) getBalancerStatus() *cephv1.BalancerStatus {
+	if c.isExternal {
+		return nil
+	}
+	status, err := client.GetBalancerStatus(c.context, c.namespace)
+	if err != nil {
+		logger.Debugf("failed to get balancer status. %v", err)
+		return nil
+	}
+	balancer := &cephv1.BalancerStatus{
+		Active:              status.Active,
+		Mode:                status.Mode,
+		LastOptimizeStarted: status.LastOptimizeStarted,
+		OptimizeResult:      status.OptimizeResult,
+		LastChecked:         formatTime(time.Now().UTC()),
+	}
+	balancer.Score = c.getBalancerScore(time.Now())
+	return balancer
+}
+
+// getBalancerScore returns the score of the data distribution, evaluated again once the previous score is older than
+// the score interval
+func (c *cephStatusChecker) getBalancerScore(now time.Time) string {
+	if c.balancerScore != "" && now.Sub(c.balancerScoreChecked) < balancerScoreInterval {
+		return c.balancerScore
+	}
+	score, err := client.GetBalancerScore(c.context, c.namespace)
+	if err != nil {
+		logger.Debugf("failed to get balancer score. %v", err)
+		return c.balancerScore
+	}
+	c.balancerScore = score
+	c.balancerScoreChecked = now
+	return score
+}
+
+// updateCephStatus detects the latest health status from ceph and updates the CR status. The balancer status is kept
+// if it is nil.
+func (c *cephStatusChecker) updateCephStatus(status *client.CephStatus, balancer *cephv1.BalancerStatus, condition cephv1.ConditionType, reason, message string) error {
 
 	// get the most recent cluster CRD object
 	cluster, err := c.context.RookClientset.CephV1().CephClusters(c.namespace).Get(c.resourceName, metav1.GetOptions{})
@@ -133,6 +177,13 @@ func (c *cephStatusChecker) updateCephStatus(status *client.CephStatus, conditio
 	// translate the ceph status struct to the crd status
 	cluster.Status.CephStatus = toCustomResourceStatus(cluster.Status, status)
 	cluster.Status.Phase = condition
+	if balancer != nil {
+		// the settings are recorded by the mgr when they are applied
+		if cluster.Status.Balancer != nil {
+			balancer.Settings = cluster.Status.Balancer.Settings
+		}
+		cluster.Status.Balancer = balancer
+	}
 	if _, err := c.context.RookClientset.CephV1().CephClusters(c.namespace).Update(cluster); err != nil {
 		return errors.Wrapf(err, "failed to update cluster %s status", c.namespace)
 	}
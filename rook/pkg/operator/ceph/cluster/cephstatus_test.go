@@ -26,6 +26,7 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/daemon/ceph/config"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -142,3 +143,40 @@ func Test_cephStatusChecker_conditionMessageReason(t *testing.T) {
 		})
 	}
 }
+
+func TestGetBalancerStatus(t *testing.T) {
+	evals := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "balancer" && args[1] == "status" {
+				return `{"active": true, "last_optimize_started": "Tue Apr 14 09:30:01 2020", "mode": "upmap", "optimize_result": "Optimization plan created successfully", "plans": []}`, nil
+			}
+			if args[0] == "balancer" && args[1] == "eval" {
+				evals++
+				return "current cluster score 0.054321 (lower is better)", nil
+			}
+			return "", nil
+		},
+	}
+	c := &cephStatusChecker{context: &clusterd.Context{Executor: executor}, namespace: "rook-ceph"}
+
+	balancer := c.getBalancerStatus()
+	assert.NotNil(t, balancer)
+	assert.True(t, balancer.Active)
+	assert.Equal(t, "upmap", balancer.Mode)
+	assert.Equal(t, "0.054321", balancer.Score)
+	assert.Equal(t, "Tue Apr 14 09:30:01 2020", balancer.LastOptimizeStarted)
+	assert.Equal(t, "Optimization plan created successfully", balancer.OptimizeResult)
+	assert.NotEqual(t, "", balancer.LastChecked)
+
+	// the score is only evaluated again after the score interval
+	balancer = c.getBalancerStatus()
+	assert.Equal(t, "0.054321", balancer.Score)
+	assert.Equal(t, 1, evals)
+	assert.Equal(t, "0.054321", c.getBalancerScore(time.Now().Add(balancerScoreInterval)))
+	assert.Equal(t, 2, evals)
+
+	// the balancer of an external cluster is not reported
+	c.isExternal = true
+	assert.Nil(t, c.getBalancerStatus())
+}
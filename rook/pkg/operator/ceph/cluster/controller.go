@@ -579,8 +579,21 @@ func (c *ClusterController) startClusterMonitoring(cluster *cluster) {
 
 	if !cluster.Spec.External.Enable {
 		// Start the osd health checker only if running OSDs in the local ceph cluster
-		c.osdChecker = osd.NewOSDHealthMonitor(c.context, cluster.Namespace, cluster.Spec.RemoveOSDsIfOutAndSafeToRemove, cluster.Info.CephVersion)
+		c.osdChecker = osd.NewOSDHealthMonitor(c.context, cluster.Namespace, cluster.crdName, cluster.Spec.RemoveOSDsIfOutAndSafeToRemove,
+			cluster.Info.CephVersion, cluster.Spec.OSDHealthCheck)
 		go c.osdChecker.Start(cluster.stopCh)
+
+		// Start the monitoring of the active mgr so the mgr services follow the failovers
+		mgrMonitor := mgr.NewActiveMgrMonitor(c.context, cluster.Namespace, cluster.crdName)
+		go mgrMonitor.Start(cluster.stopCh)
+
+		// Start publishing the inventory of the rook orchestrator module and applying its requests
+		inventory := mgr.NewOrchestratorInventory(c.context, cluster.Namespace, cluster.crdName)
+		go inventory.Start(cluster.stopCh)
+
+		// Start reporting the crashes of the ceph daemons
+		crashMonitor := crash.NewCrashMonitor(c.context, cluster.Namespace, cluster.crdName)
+		go crashMonitor.Start(cluster.stopCh)
 	}
 
 	// Start the ceph status checker
@@ -725,9 +738,11 @@ func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
 	config.ConditionExport(c.context, newClust.Namespace, newClust.Name,
 		cephv1.ConditionUpdating, v1.ConditionTrue, "ClusterUpdating", "Cluster is updating")
 
-	if oldClust.Spec.RemoveOSDsIfOutAndSafeToRemove != newClust.Spec.RemoveOSDsIfOutAndSafeToRemove {
-		logger.Infof("removeOSDsIfOutAndSafeToRemove is set to %t", newClust.Spec.RemoveOSDsIfOutAndSafeToRemove)
-		c.osdChecker.Update(newClust.Spec.RemoveOSDsIfOutAndSafeToRemove)
+	if oldClust.Spec.RemoveOSDsIfOutAndSafeToRemove != newClust.Spec.RemoveOSDsIfOutAndSafeToRemove ||
+		oldClust.Spec.OSDHealthCheck != newClust.Spec.OSDHealthCheck {
+		logger.Infof("removeOSDsIfOutAndSafeToRemove is set to %t, osd health check is set to %+v",
+			newClust.Spec.RemoveOSDsIfOutAndSafeToRemove, newClust.Spec.OSDHealthCheck)
+		c.osdChecker.Update(newClust.Spec.RemoveOSDsIfOutAndSafeToRemove, newClust.Spec.OSDHealthCheck)
 	}
 
 	logger.Debugf("old cluster: %+v", oldClust.Spec)
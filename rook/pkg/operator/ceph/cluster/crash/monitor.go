@@ -0,0 +1,220 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crash
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	daemonCrashedReason = "DaemonCrashed"
+)
+
+var (
+	crashCheckInterval = time.Minute
+	crashPruneInterval = time.Hour
+	// the crashes can be archived and listed by archive state since nautilus 14.2.5
+	crashArchiveVersion = cephver.CephVersion{Major: 14, Minor: 2, Extra: 5}
+)
+
+// CrashMonitor publishes the new crashes of the ceph daemons as events of the CephCluster, summarizes them in the
+// CephCluster status and archives and prunes them as set in the crash collector spec
+type CrashMonitor struct {
+	context     *clusterd.Context
+	namespace   string
+	clusterName string
+	lastPrune   time.Time
+	// the crashes whose time cannot be parsed, they are only reported the first time they are listed
+	unparsedCrashes map[string]bool
+	archiveWarned   bool
+}
+
+// NewCrashMonitor instantiates the monitoring of the crashes
+func NewCrashMonitor(context *clusterd.Context, namespace, clusterName string) *CrashMonitor {
+	return &CrashMonitor{context: context, namespace: namespace, clusterName: clusterName, unparsedCrashes: map[string]bool{}}
+}
+
+// Start checks the crashes at set intervals
+func (m *CrashMonitor) Start(stopCh chan struct{}) {
+	for {
+		select {
+		case <-time.After(crashCheckInterval):
+			logger.Debug("checking the crashes")
+			if err := m.checkCrashes(time.Now()); err != nil {
+				logger.Warningf("failed to check the crashes. %v", err)
+			}
+
+		case <-stopCh:
+			logger.Infof("stopping monitoring of the crashes in namespace %s", m.namespace)
+			return
+		}
+	}
+}
+
+// checkCrashes reports the crashes that are newer than the last crash of the CephCluster status, archives the
+// crashes that were reported for longer than the archive period and prunes the crashes older than the retention
+func (m *CrashMonitor) checkCrashes(now time.Time) error {
+	cluster, err := m.context.RookClientset.CephV1().CephClusters(m.namespace).Get(m.clusterName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get cluster %q", m.clusterName)
+	}
+	spec := cluster.Spec.CrashCollector
+	if spec.Disable {
+		return nil
+	}
+	var archiveAfter time.Duration
+	if spec.ArchiveAfter != "" {
+		archiveAfter, err = time.ParseDuration(spec.ArchiveAfter)
+		if err != nil || archiveAfter <= 0 {
+			return errors.Errorf("invalid crash archive period %q", spec.ArchiveAfter)
+		}
+	}
+
+	cephVersion, err := opcontroller.GetImageVersion(*cluster)
+	if err != nil {
+		logger.Debugf("the crashes are not checked until the ceph version is detected. %v", err)
+		return nil
+	}
+	var crashes []cephclient.CrashInfo
+	if cephVersion.IsAtLeast(crashArchiveVersion) {
+		crashes, err = cephclient.ListNewCrashes(m.context, m.namespace)
+	} else {
+		if archiveAfter > 0 && !m.archiveWarned {
+			logger.Warningf("the crashes cannot be archived with ceph version %q, %q or newer is required", cephVersion.String(), crashArchiveVersion.String())
+			m.archiveWarned = true
+		}
+		archiveAfter = 0
+		crashes, err = cephclient.ListCrashes(m.context, m.namespace)
+	}
+	if err != nil {
+		return err
+	}
+	sort.Slice(crashes, func(i, j int) bool {
+		return crashes[i].Timestamp < crashes[j].Timestamp
+	})
+
+	status := cephv1.CrashesStatus{}
+	lastReported := time.Time{}
+	if cluster.Status.Crashes != nil {
+		status = *cluster.Status.Crashes
+		if status.LastCrashTime != "" {
+			lastReported, err = time.Parse(time.RFC3339Nano, status.LastCrashTime)
+			if err != nil {
+				logger.Warningf("failed to parse the time of the last crash reported. %v", err)
+			}
+		}
+	}
+
+	status.New = 0
+	unparsedCrashes := map[string]bool{}
+	for _, crash := range crashes {
+		crashTime, err := crash.Time()
+		if err != nil {
+			// the crash cannot be compared with the last crash reported, it is reported the first time it is listed
+			if !m.unparsedCrashes[crash.ID] {
+				logger.Warningf("%v", err)
+				m.reportCrash(cluster, crash)
+			}
+			unparsedCrashes[crash.ID] = true
+			status.New++
+			continue
+		}
+		if crashTime.After(lastReported) {
+			m.reportCrash(cluster, crash)
+			lastReported = crashTime
+			status.LastCrashID = crash.ID
+			status.LastCrashEntity = crash.Entity
+			status.LastCrashTime = crashTime.UTC().Format(time.RFC3339Nano)
+		}
+		if archiveAfter > 0 && now.Sub(crashTime) >= archiveAfter {
+			logger.Infof("archiving crash %q of %s", crash.ID, crash.Entity)
+			if err := cephclient.ArchiveCrash(m.context, m.namespace, crash.ID); err != nil {
+				logger.Warningf("%v", err)
+			} else {
+				continue
+			}
+		}
+		status.New++
+	}
+	m.unparsedCrashes = unparsedCrashes
+
+	if spec.DaysToRetain > 0 && now.Sub(m.lastPrune) >= crashPruneInterval {
+		logger.Debugf("pruning the crashes older than %d days", spec.DaysToRetain)
+		if err := cephclient.PruneCrashes(m.context, m.namespace, spec.DaysToRetain); err != nil {
+			logger.Warningf("%v", err)
+		} else {
+			m.lastPrune = now
+		}
+	}
+
+	if cluster.Status.Crashes != nil && reflect.DeepEqual(*cluster.Status.Crashes, status) {
+		return nil
+	}
+	cluster.Status.Crashes = &status
+	if _, err := m.context.RookClientset.CephV1().CephClusters(m.namespace).Update(cluster); err != nil {
+		return errors.Wrapf(err, "failed to update the crashes status of cluster %q", m.clusterName)
+	}
+	return nil
+}
+
+// reportCrash publishes a warning event for a crash with its details
+func (m *CrashMonitor) reportCrash(cluster *cephv1.CephCluster, crash cephclient.CrashInfo) {
+	if info, err := cephclient.GetCrashInfo(m.context, m.namespace, crash.ID); err != nil {
+		logger.Warningf("failed to get the details of crash %q. %v", crash.ID, err)
+	} else {
+		crash = *info
+	}
+
+	message := fmt.Sprintf("%s crashed", crash.Entity)
+	if crash.Hostname != "" {
+		message += fmt.Sprintf(" on host %q", crash.Hostname)
+	}
+	message += fmt.Sprintf(" at %s (crash %s", crash.Timestamp, crash.ID)
+	if crash.CephVersion != "" {
+		message += fmt.Sprintf(", ceph version %s", crash.CephVersion)
+	}
+	message += ")"
+	if crash.AssertMessage != "" {
+		message += ": " + strings.TrimSpace(strings.SplitN(crash.AssertMessage, "\n", 2)[0])
+	}
+	logger.Warningf("%s", message)
+
+	object := corev1.ObjectReference{
+		APIVersion: cephv1.SchemeGroupVersion.String(),
+		Kind:       "CephCluster",
+		Name:       cluster.Name,
+		Namespace:  cluster.Namespace,
+		UID:        cluster.UID,
+	}
+	if err := k8sutil.CreateEvent(m.context.Clientset, object, corev1.EventTypeWarning, daemonCrashedReason, message); err != nil {
+		logger.Warningf("%v", err)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crash
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCheckCrashes(t *testing.T) {
+	cluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "ns"},
+		Spec: cephv1.ClusterSpec{
+			CephVersion:    cephv1.CephVersionSpec{Image: "ceph/ceph:v15.2.1"},
+			CrashCollector: cephv1.CrashCollectorSpec{ArchiveAfter: "24h", DaysToRetain: 30},
+		},
+		Status: cephv1.ClusterStatus{CephVersion: &cephv1.ClusterVersion{Image: "ceph/ceph:v15.2.1", Version: "15.2.1-0"}},
+	}
+	clientset := fake.NewSimpleClientset()
+	newCrashes := `[
+		{"crash_id":"2020-04-14T10:30:00.000000Z_b","timestamp":"2020-04-14T10:30:00.000000Z","entity_name":"mds.a"},
+		{"crash_id":"2020-04-13T08:00:00.000000Z_a","timestamp":"2020-04-13T08:00:00.000000Z","entity_name":"osd.0"}]`
+	allCrashes := ""
+	archived, pruned := []string{}, 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			switch args[1] {
+			case "ls-new":
+				return newCrashes, nil
+			case "ls":
+				return allCrashes, nil
+			case "info":
+				if args[2] == "2020-04-13T08:00:00.000000Z_a" {
+					return `{"crash_id":"2020-04-13T08:00:00.000000Z_a","timestamp":"2020-04-13T08:00:00.000000Z","entity_name":"osd.0","utsname_hostname":"node0","ceph_version":"15.2.1","assert_msg":"bluestore failed\nsecond line"}`, nil
+				}
+				return `{"crash_id":"2020-04-14T10:30:00.000000Z_b","timestamp":"2020-04-14T10:30:00.000000Z","entity_name":"mds.a"}`, nil
+			case "archive":
+				archived = append(archived, args[2])
+			case "prune":
+				assert.Equal(t, "30", args[2])
+				pruned++
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookclient.NewSimpleClientset(cluster), Executor: executor}
+	monitor := NewCrashMonitor(context, "ns", "cluster")
+
+	// the crashes are reported and the crash older than a day is archived
+	now := time.Date(2020, 4, 14, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, monitor.checkCrashes(now))
+	assert.Equal(t, []string{"2020-04-13T08:00:00.000000Z_a"}, archived)
+	assert.Equal(t, 1, pruned)
+
+	events, err := clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(events.Items))
+	messages := []string{}
+	for _, event := range events.Items {
+		assert.Equal(t, corev1.EventTypeWarning, event.Type)
+		assert.Equal(t, "DaemonCrashed", event.Reason)
+		assert.Equal(t, "cluster", event.InvolvedObject.Name)
+		messages = append(messages, event.Message)
+	}
+	assert.Contains(t, strings.Join(messages, "\n"), `osd.0 crashed on host "node0" at 2020-04-13T08:00:00.000000Z (crash 2020-04-13T08:00:00.000000Z_a, ceph version 15.2.1): bluestore failed`)
+
+	updated, err := context.RookClientset.CephV1().CephClusters("ns").Get("cluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, &cephv1.CrashesStatus{
+		New:             1,
+		LastCrashID:     "2020-04-14T10:30:00.000000Z_b",
+		LastCrashEntity: "mds.a",
+		LastCrashTime:   "2020-04-14T10:30:00Z",
+	}, updated.Status.Crashes)
+
+	// the crashes already reported are not reported again and the crashes are pruned once an hour
+	newCrashes = `[{"crash_id":"2020-04-14T10:30:00.000000Z_b","timestamp":"2020-04-14T10:30:00.000000Z","entity_name":"mds.a"}]`
+	assert.NoError(t, monitor.checkCrashes(now.Add(time.Minute)))
+	events, _ = clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.Equal(t, 2, len(events.Items))
+	assert.Equal(t, 1, pruned)
+
+	// a new crash is reported
+	newCrashes = `[
+		{"crash_id":"2020-04-14T10:30:00.000000Z_b","timestamp":"2020-04-14T10:30:00.000000Z","entity_name":"mds.a"},
+		{"crash_id":"2020-04-14T13:00:00.000000Z_c","timestamp":"2020-04-14T13:00:00.000000Z","entity_name":"osd.1"}]`
+	assert.NoError(t, monitor.checkCrashes(now.Add(2*time.Hour)))
+	events, _ = clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.Equal(t, 3, len(events.Items))
+	assert.Equal(t, 2, pruned)
+	updated, _ = context.RookClientset.CephV1().CephClusters("ns").Get("cluster", metav1.GetOptions{})
+	assert.Equal(t, 2, updated.Status.Crashes.New)
+	assert.Equal(t, "osd.1", updated.Status.Crashes.LastCrashEntity)
+
+	// a crash whose time cannot be parsed is reported once
+	newCrashes = `[{"crash_id":"unknown_d","timestamp":"yesterday","entity_name":"mgr.a"}]`
+	assert.NoError(t, monitor.checkCrashes(now.Add(2*time.Hour)))
+	assert.NoError(t, monitor.checkCrashes(now.Add(2*time.Hour)))
+	events, _ = clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.Equal(t, 4, len(events.Items))
+	updated, _ = context.RookClientset.CephV1().CephClusters("ns").Get("cluster", metav1.GetOptions{})
+	assert.Equal(t, 1, updated.Status.Crashes.New)
+	assert.Equal(t, "osd.1", updated.Status.Crashes.LastCrashEntity)
+
+	// the crashes are listed but not archived before nautilus 14.2.5
+	updated.Spec.CephVersion.Image = "ceph/ceph:v14.2.4"
+	updated.Status.CephVersion = &cephv1.ClusterVersion{Image: "ceph/ceph:v14.2.4", Version: "14.2.4-0"}
+	_, err = context.RookClientset.CephV1().CephClusters("ns").Update(updated)
+	assert.NoError(t, err)
+	newCrashes = "invalid"
+	allCrashes = `[
+		{"crash_id":"2020-04-13T08:00:00.000000Z_a","timestamp":"2020-04-13T08:00:00.000000Z","entity_name":"osd.0"},
+		{"crash_id":"2020-04-14T14:00:00.000000Z_e","timestamp":"2020-04-14T14:00:00.000000Z","entity_name":"osd.2"}]`
+	archived = []string{}
+	assert.NoError(t, monitor.checkCrashes(now.Add(3*time.Hour)))
+	assert.Empty(t, archived)
+	events, _ = clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.Equal(t, 5, len(events.Items))
+	updated, _ = context.RookClientset.CephV1().CephClusters("ns").Get("cluster", metav1.GetOptions{})
+	assert.Equal(t, 2, updated.Status.Crashes.New)
+	assert.Equal(t, "osd.2", updated.Status.Crashes.LastCrashEntity)
+
+	// nothing is done when the crash collector is disabled
+	updated.Spec.CrashCollector.Disable = true
+	_, err = context.RookClientset.CephV1().CephClusters("ns").Update(updated)
+	assert.NoError(t, err)
+	newCrashes = "invalid"
+	assert.NoError(t, monitor.checkCrashes(now.Add(3*time.Hour)))
+
+	// the archive period is validated
+	updated.Spec.CrashCollector = cephv1.CrashCollectorSpec{ArchiveAfter: "1 day"}
+	_, err = context.RookClientset.CephV1().CephClusters("ns").Update(updated)
+	assert.NoError(t, err)
+	assert.Error(t, monitor.checkCrashes(now))
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// the label of the mgr pods set to the role of the mgr, the services select the active mgr
+	mgrRoleLabelKey   = "mgr_role"
+	activeMgrRole     = "active"
+	standbyMgrRole    = "standby"
+	mgrFailoverReason = "MgrFailover"
+)
+
+var (
+	activeMgrCheckInterval = 15 * time.Second
+)
+
+// ActiveMgrMonitor follows the failovers of the mgrs so the mgr services always select the active mgr
+type ActiveMgrMonitor struct {
+	context     *clusterd.Context
+	namespace   string
+	clusterName string
+}
+
+// NewActiveMgrMonitor instantiates the monitoring of the active mgr
+func NewActiveMgrMonitor(context *clusterd.Context, namespace, clusterName string) *ActiveMgrMonitor {
+	return &ActiveMgrMonitor{context: context, namespace: namespace, clusterName: clusterName}
+}
+
+// Start reconciles the role of the mgr pods at set intervals
+func (m *ActiveMgrMonitor) Start(stopCh chan struct{}) {
+	for {
+		select {
+		case <-time.After(activeMgrCheckInterval):
+			logger.Debug("checking the active mgr")
+			if err := reconcileActiveMgr(m.context, m.namespace, m.clusterName); err != nil {
+				logger.Warningf("failed to check the active mgr. %v", err)
+			}
+
+		case <-stopCh:
+			logger.Infof("stopping monitoring of the active mgr in namespace %s", m.namespace)
+			return
+		}
+	}
+}
+
+// reconcileActiveMgr labels the mgr pods with their role as reported by the mgr map and reports the active and
+// standby mgrs in the CephCluster status
+func reconcileActiveMgr(context *clusterd.Context, namespace, clusterName string) error {
+	mgrMap, err := client.GetMgrMap(context, namespace)
+	if err != nil {
+		return err
+	}
+	if mgrMap.ActiveName == "" {
+		logger.Debugf("no active mgr yet")
+		return nil
+	}
+
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.AppAttr, AppName)}
+	pods, err := context.Clientset.CoreV1().Pods(namespace).List(listOpts)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list mgr pods")
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		role := standbyMgrRole
+		if pod.Labels[config.MgrType] == mgrMap.ActiveName {
+			role = activeMgrRole
+		}
+		if pod.Labels[mgrRoleLabelKey] == role {
+			continue
+		}
+		logger.Infof("labeling mgr pod %q as %s", pod.Name, role)
+		pod.Labels[mgrRoleLabelKey] = role
+		if _, err := context.Clientset.CoreV1().Pods(namespace).Update(pod); err != nil {
+			return errors.Wrapf(err, "failed to label mgr pod %q", pod.Name)
+		}
+	}
+
+	var standbys []string
+	for _, standby := range mgrMap.Standbys {
+		standbys = append(standbys, standby.Name)
+	}
+	sort.Strings(standbys)
+	updateMgrStatus(context, namespace, clusterName, mgrMap.ActiveName, standbys)
+	return nil
+}
+
+// updateMgrStatus reports the active and standby mgrs in the CephCluster status, with the time of the failover when
+// the active mgr changed
+func updateMgrStatus(context *clusterd.Context, namespace, clusterName, active string, standbys []string) {
+	if context.RookClientset == nil || clusterName == "" {
+		return
+	}
+	cluster, err := context.RookClientset.CephV1().CephClusters(namespace).Get(clusterName, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get cluster %q to update the mgr status. %v", clusterName, err)
+		return
+	}
+
+	status := cephv1.MgrStatus{Active: active, Standbys: standbys}
+	if cluster.Status.Mgr != nil {
+		status.LastFailover = cluster.Status.Mgr.LastFailover
+		if reflect.DeepEqual(*cluster.Status.Mgr, status) {
+			return
+		}
+		if previous := cluster.Status.Mgr.Active; previous != "" && previous != active {
+			message := fmt.Sprintf("mgr %q took over from mgr %q", active, previous)
+			logger.Infof("%s", message)
+			status.LastFailover = time.Now().UTC().Format(time.RFC3339)
+			object := v1.ObjectReference{
+				APIVersion: cephv1.SchemeGroupVersion.String(),
+				Kind:       "CephCluster",
+				Name:       cluster.Name,
+				Namespace:  cluster.Namespace,
+				UID:        cluster.UID,
+			}
+			if err := k8sutil.CreateEvent(context.Clientset, object, v1.EventTypeNormal, mgrFailoverReason, message); err != nil {
+				logger.Warningf("%v", err)
+			}
+		}
+	}
+
+	cluster.Status.Mgr = &status
+	if _, err := context.RookClientset.CephV1().CephClusters(namespace).Update(cluster); err != nil {
+		logger.Warningf("failed to update the mgr status of cluster %q. %v", clusterName, err)
+	}
+}
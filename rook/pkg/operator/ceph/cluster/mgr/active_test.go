@@ -0,0 +1,125 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"fmt"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReconcileActiveMgr(t *testing.T) {
+	clientset := testop.New(t, 1)
+	rookClientset := rookclient.NewSimpleClientset(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "ns"}})
+	for _, id := range []string{"a", "b"} {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("rook-ceph-mgr-%s-123", id),
+			Namespace: "ns",
+			Labels:    map[string]string{"app": AppName, "mgr": id},
+		}}
+		_, err := clientset.CoreV1().Pods("ns").Create(pod)
+		assert.NoError(t, err)
+	}
+
+	active := ""
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "mgr" && args[1] == "dump" {
+				standby := "a"
+				if active == "a" {
+					standby = "b"
+				}
+				return fmt.Sprintf(`{"epoch":5,"active_name":"%s","available":true,"standbys":[{"gid":1,"name":"%s"}]}`, active, standby), nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: clientset, RookClientset: rookClientset}
+	role := func(id string) string {
+		pod, err := clientset.CoreV1().Pods("ns").Get(fmt.Sprintf("rook-ceph-mgr-%s-123", id), metav1.GetOptions{})
+		assert.NoError(t, err)
+		return pod.Labels[mgrRoleLabelKey]
+	}
+
+	// no mgr is active yet
+	assert.NoError(t, reconcileActiveMgr(context, "ns", "mycluster"))
+	assert.Equal(t, "", role("a"))
+
+	active = "a"
+	assert.NoError(t, reconcileActiveMgr(context, "ns", "mycluster"))
+	assert.Equal(t, activeMgrRole, role("a"))
+	assert.Equal(t, standbyMgrRole, role("b"))
+	cluster, err := rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.MgrStatus{Active: "a", Standbys: []string{"b"}}, *cluster.Status.Mgr)
+
+	// the standby takes over, the pods are relabeled and the failover is reported
+	active = "b"
+	assert.NoError(t, reconcileActiveMgr(context, "ns", "mycluster"))
+	assert.Equal(t, standbyMgrRole, role("a"))
+	assert.Equal(t, activeMgrRole, role("b"))
+	cluster, err = rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "b", cluster.Status.Mgr.Active)
+	assert.Equal(t, []string{"a"}, cluster.Status.Mgr.Standbys)
+	assert.NotEqual(t, "", cluster.Status.Mgr.LastFailover)
+	events, err := clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events.Items))
+	assert.Equal(t, mgrFailoverReason, events.Items[0].Reason)
+}
+
+func TestMgrCount(t *testing.T) {
+	clientset := testop.New(t, 1)
+	context := &clusterd.Context{Clientset: clientset}
+
+	// a single mgr is selected by the services without its role
+	c := &Cluster{context: context, Namespace: "ns", Replicas: 1}
+	assert.Equal(t, "", c.makeMetricsService(AppName).Spec.Selector[mgrRoleLabelKey])
+
+	// with a standby mgr only the active mgr is selected
+	c.Replicas = 2
+	assert.Equal(t, []string{"a", "b"}, c.getDaemonIDs())
+	assert.Equal(t, activeMgrRole, c.makeMetricsService(AppName).Spec.Selector[mgrRoleLabelKey])
+	assert.Equal(t, activeMgrRole, c.makeDashboardService(AppName).Spec.Selector[mgrRoleLabelKey])
+
+	// the mgrs beyond the count are removed
+	for _, id := range []string{"a", "b"} {
+		d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("rook-ceph-mgr-%s", id),
+			Namespace: "ns",
+			Labels:    map[string]string{"app": AppName, "mgr": id},
+		}}
+		_, err := clientset.AppsV1().Deployments("ns").Create(d)
+		assert.NoError(t, err)
+	}
+	c.Replicas = 1
+	assert.NoError(t, c.removeExtraMgrs(c.getDaemonIDs()))
+	deployments, err := clientset.AppsV1().Deployments("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(deployments.Items))
+	assert.Equal(t, "rook-ceph-mgr-a", deployments.Items[0].Name)
+}
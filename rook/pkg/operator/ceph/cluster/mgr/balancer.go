@@ -0,0 +1,243 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	crushCompatBalancerMode = "crush-compat"
+	maxMisplacedRatioKey    = "target_max_misplaced_ratio"
+	balancerBeginTimeKey    = "mgr/balancer/begin_time"
+	balancerEndTimeKey      = "mgr/balancer/end_time"
+	balancerBeginWeekdayKey = "mgr/balancer/begin_weekday"
+	balancerEndWeekdayKey   = "mgr/balancer/end_weekday"
+	balancerPoolIDsKey      = "mgr/balancer/pool_ids"
+)
+
+// balancerSpecified returns whether any setting of the balancer is in the spec
+func (c *Cluster) balancerSpecified() bool {
+	b := c.balancer
+	return b.Mode != "" || b.MaxMisplacedRatio != "" || b.ActiveWindow != nil || len(b.Pools) > 0 || len(b.ExcludedPools) > 0
+}
+
+// balancerMode returns the mode of the balancer spec, upmap by default
+func (c *Cluster) balancerMode() string {
+	if c.balancer.Mode == "" {
+		return defaultBalancerMode
+	}
+	return c.balancer.Mode
+}
+
+// configureBalancer sets the mode of the balancer and applies the settings of the balancer spec on each mgr
+func (c *Cluster) configureBalancer() error {
+	settings, err := c.balancerSettings()
+	if err != nil {
+		return errors.Wrapf(err, "invalid balancer settings")
+	}
+
+	if err := client.ConfigureBalancerModule(c.context, c.Namespace, c.balancerMode()); err != nil {
+		return err
+	}
+	return c.applyBalancerSettings(settings)
+}
+
+// clearBalancerSettings removes the settings of the balancer from each mgr when the balancer spec was removed, so the
+// defaults of ceph apply again
+func (c *Cluster) clearBalancerSettings() error {
+	return c.applyBalancerSettings(map[string]string{})
+}
+
+// applyBalancerSettings sets the balancer settings on each mgr and removes the settings previously applied that are no
+// longer in the spec. The applied settings are recorded in the balancer status of the CephCluster, so the settings that
+// were never set by the operator are left alone.
+func (c *Cluster) applyBalancerSettings(settings map[string]string) error {
+	previous := c.getAppliedBalancerSettings()
+	keys := []string{}
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	for key := range previous {
+		if _, ok := settings[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, daemonID := range c.getDaemonIDs() {
+		for _, key := range keys {
+			// an empty value removes the setting so the default of ceph applies
+			changed, err := client.MgrSetConfig(c.context, c.Namespace, daemonID, key, settings[key], false)
+			if err != nil {
+				return errors.Wrapf(err, "failed to set balancer setting %q on mgr.%s", key, daemonID)
+			}
+			if changed {
+				logger.Infof("balancer setting %q set to %q on mgr.%s", key, settings[key], daemonID)
+			}
+		}
+	}
+	c.updateAppliedBalancerSettings(settings)
+	return nil
+}
+
+// getAppliedBalancerSettings returns the balancer settings recorded in the CephCluster status
+func (c *Cluster) getAppliedBalancerSettings() map[string]string {
+	if c.context.RookClientset == nil || c.ownerRef.Name == "" {
+		return nil
+	}
+	cluster, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Get(c.ownerRef.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get cluster %q to read the applied balancer settings. %v", c.ownerRef.Name, err)
+		return nil
+	}
+	if cluster.Status.Balancer == nil {
+		return nil
+	}
+	return cluster.Status.Balancer.Settings
+}
+
+// updateAppliedBalancerSettings records the balancer settings applied on the mgrs in the CephCluster status
+func (c *Cluster) updateAppliedBalancerSettings(settings map[string]string) {
+	if c.context.RookClientset == nil || c.ownerRef.Name == "" {
+		return
+	}
+	cluster, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Get(c.ownerRef.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get cluster %q to record the applied balancer settings. %v", c.ownerRef.Name, err)
+		return
+	}
+	if len(settings) == 0 {
+		settings = nil
+	}
+	if cluster.Status.Balancer == nil {
+		if settings == nil {
+			return
+		}
+		cluster.Status.Balancer = &cephv1.BalancerStatus{}
+	}
+	if reflect.DeepEqual(cluster.Status.Balancer.Settings, settings) {
+		return
+	}
+	cluster.Status.Balancer.Settings = settings
+	if _, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Update(cluster); err != nil {
+		logger.Warningf("failed to record the applied balancer settings of cluster %q. %v", c.ownerRef.Name, err)
+	}
+}
+
+// balancerSettings validates the balancer spec and returns the mgr settings it translates to. The settings that are
+// not in the spec are not returned.
+func (c *Cluster) balancerSettings() (map[string]string, error) {
+	b := c.balancer
+	settings := map[string]string{}
+
+	if mode := c.balancerMode(); mode != defaultBalancerMode && mode != crushCompatBalancerMode {
+		return nil, errors.Errorf("unknown balancer mode %q", mode)
+	}
+
+	if b.MaxMisplacedRatio != "" {
+		ratio, err := strconv.ParseFloat(b.MaxMisplacedRatio, 64)
+		if err != nil || ratio <= 0 || ratio > 1 {
+			return nil, errors.Errorf("max misplaced ratio %q must be a number greater than 0 and up to 1", b.MaxMisplacedRatio)
+		}
+		settings[maxMisplacedRatioKey] = b.MaxMisplacedRatio
+	}
+
+	if w := b.ActiveWindow; w != nil {
+		endHour := w.EndHour
+		if endHour == 0 {
+			endHour = 24
+		}
+		if w.BeginHour < 0 || w.BeginHour > 23 || endHour < 1 || endHour > 24 {
+			return nil, errors.Errorf("invalid balancer hours %d to %d", w.BeginHour, endHour)
+		}
+		endWeekday := w.EndWeekday
+		if endWeekday == 0 {
+			endWeekday = 7
+		}
+		if w.BeginWeekday < 0 || w.BeginWeekday > 6 || endWeekday < 1 || endWeekday > 7 {
+			return nil, errors.Errorf("invalid balancer weekdays %d to %d", w.BeginWeekday, endWeekday)
+		}
+		// the balancer compares the times in the HHMM format
+		settings[balancerBeginTimeKey] = fmt.Sprintf("%02d00", w.BeginHour)
+		settings[balancerEndTimeKey] = fmt.Sprintf("%02d00", endHour)
+		settings[balancerBeginWeekdayKey] = strconv.Itoa(w.BeginWeekday)
+		settings[balancerEndWeekdayKey] = strconv.Itoa(endWeekday)
+	}
+
+	if len(b.Pools) > 0 || len(b.ExcludedPools) > 0 {
+		poolIDs, err := c.balancedPoolIDs()
+		if err != nil {
+			return nil, err
+		}
+		settings[balancerPoolIDsKey] = poolIDs
+	}
+	return settings, nil
+}
+
+// balancedPoolIDs returns the comma separated IDs of the pools to balance. The pools that do not exist yet are skipped,
+// they are balanced after they are created and the cluster is orchestrated again.
+func (c *Cluster) balancedPoolIDs() (string, error) {
+	pools, err := client.ListPoolSummaries(c.context, c.Namespace)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list the pools to balance")
+	}
+	existing := map[string]int{}
+	for _, pool := range pools {
+		existing[pool.Name] = pool.Number
+	}
+
+	included := map[string]bool{}
+	if len(c.balancer.Pools) > 0 {
+		for _, name := range c.balancer.Pools {
+			if _, ok := existing[name]; !ok {
+				logger.Warningf("balancer pool %q does not exist yet", name)
+				continue
+			}
+			included[name] = true
+		}
+	} else {
+		for name := range existing {
+			included[name] = true
+		}
+	}
+	for _, name := range c.balancer.ExcludedPools {
+		delete(included, name)
+	}
+	if len(included) == 0 {
+		return "", errors.New("no existing pool to balance")
+	}
+
+	ids := []int{}
+	for name := range included {
+		ids = append(ids, existing[name])
+	}
+	sort.Ints(ids)
+	idStrings := []string{}
+	for _, id := range ids {
+		idStrings = append(idStrings, strconv.Itoa(id))
+	}
+	return strings.Join(idStrings, ","), nil
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConfigureBalancer(t *testing.T) {
+	mode := ""
+	store := map[string]string{"mgr/balancer/begin_time": "0100"}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			switch {
+			case args[0] == "osd" && args[1] == "lspools":
+				return `[{"poolnum":1,"poolname":"replicapool"},{"poolnum":2,"poolname":"ecpool"},{"poolnum":3,"poolname":"device_health_metrics"}]`, nil
+			case args[0] == "balancer" && args[1] == "mode":
+				mode = args[2]
+			case args[0] == "config" && args[2] == "mgr.a":
+				switch args[1] {
+				case "get":
+					return store[args[3]], nil
+				case "set":
+					store[args[3]] = args[4]
+				case "rm":
+					delete(store, args[3])
+				}
+			}
+			return "", nil
+		},
+	}
+	rookClientset := rookclient.NewSimpleClientset(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "ns"}})
+	c := &Cluster{Namespace: "ns", Replicas: 1, context: &clusterd.Context{Executor: executor, RookClientset: rookClientset},
+		ownerRef: metav1.OwnerReference{Name: "mycluster"}}
+	appliedSettings := func() map[string]string {
+		cluster, err := rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+		assert.NoError(t, err)
+		if cluster.Status.Balancer == nil {
+			return nil
+		}
+		return cluster.Status.Balancer.Settings
+	}
+
+	// the defaults of ceph apply without a balancer spec, the settings not set by the operator are left alone
+	assert.False(t, c.balancerSpecified())
+	assert.NoError(t, c.configureBalancer())
+	assert.Equal(t, "upmap", mode)
+	assert.Equal(t, map[string]string{"mgr/balancer/begin_time": "0100"}, store)
+	assert.Nil(t, appliedSettings())
+
+	// all the settings
+	c.balancer = cephv1.BalancerSpec{
+		Mode:              "crush-compat",
+		MaxMisplacedRatio: "0.02",
+		ActiveWindow:      &cephv1.BalancerWindowSpec{BeginHour: 22, EndHour: 6, BeginWeekday: 1, EndWeekday: 6},
+		ExcludedPools:     []string{"device_health_metrics"},
+	}
+	assert.True(t, c.balancerSpecified())
+	assert.NoError(t, c.configureBalancer())
+	assert.Equal(t, "crush-compat", mode)
+	assert.Equal(t, map[string]string{
+		"target_max_misplaced_ratio": "0.02",
+		"mgr/balancer/begin_time":    "2200",
+		"mgr/balancer/end_time":      "0600",
+		"mgr/balancer/begin_weekday": "1",
+		"mgr/balancer/end_weekday":   "6",
+		"mgr/balancer/pool_ids":      "1,2",
+	}, store)
+	assert.Equal(t, store, appliedSettings())
+
+	// the end of the window defaults to the end of the day and week, the pools that don't exist are skipped
+	c.balancer = cephv1.BalancerSpec{
+		ActiveWindow: &cephv1.BalancerWindowSpec{BeginHour: 8},
+		Pools:        []string{"ecpool", "missing"},
+	}
+	assert.NoError(t, c.configureBalancer())
+	assert.Equal(t, "upmap", mode)
+	assert.Equal(t, map[string]string{
+		"mgr/balancer/begin_time":    "0800",
+		"mgr/balancer/end_time":      "2400",
+		"mgr/balancer/begin_weekday": "0",
+		"mgr/balancer/end_weekday":   "7",
+		"mgr/balancer/pool_ids":      "2",
+	}, store)
+	assert.Equal(t, store, appliedSettings())
+
+	// invalid settings are not applied
+	invalid := []cephv1.BalancerSpec{
+		{Mode: "none"},
+		{MaxMisplacedRatio: "5%"},
+		{MaxMisplacedRatio: "1.5"},
+		{ActiveWindow: &cephv1.BalancerWindowSpec{BeginHour: 24}},
+		{ActiveWindow: &cephv1.BalancerWindowSpec{BeginWeekday: 7}},
+		{Pools: []string{"missing"}},
+		{ExcludedPools: []string{"replicapool", "ecpool", "device_health_metrics"}},
+	}
+	for _, spec := range invalid {
+		mode = ""
+		c.balancer = spec
+		assert.Error(t, c.configureBalancer())
+		assert.Equal(t, "", mode)
+	}
+
+	// the settings are removed when the balancer spec is removed, the mode is left untouched
+	c.balancer = cephv1.BalancerSpec{}
+	assert.NoError(t, c.clearBalancerSettings())
+	assert.Equal(t, "", mode)
+	assert.Empty(t, store)
+	assert.Nil(t, appliedSettings())
+
+	// nothing is left to remove
+	store["mgr/balancer/begin_time"] = "0100"
+	assert.NoError(t, c.clearBalancerSettings())
+	assert.Equal(t, map[string]string{"mgr/balancer/begin_time": "0100"}, store)
+}
@@ -58,6 +58,9 @@ func (c *Cluster) configureDashboardService() error {
 				return errors.Wrapf(err, "failed to create dashboard mgr service")
 			}
 			logger.Infof("dashboard service already exists")
+			if err := c.updateServiceSelector(dashboardService); err != nil {
+				return err
+			}
 			original, err := c.context.Clientset.CoreV1().Services(c.Namespace).Get(dashboardService.Name, metav1.GetOptions{})
 			if err != nil {
 				return errors.Wrapf(err, "failed to get dashboard service")
@@ -110,6 +113,14 @@ func (c *Cluster) configureDashboardModules() error {
 			hasChanged = true
 		}
 	}
+
+	if err := c.configureDashboardUsers(); err != nil {
+		return errors.Wrapf(err, "failed to configure dashboard users")
+	}
+	if err := c.configureDashboardSSO(); err != nil {
+		return errors.Wrapf(err, "failed to configure dashboard sso")
+	}
+
 	if hasChanged {
 		logger.Infof("dashboard config has changed. restarting the dashboard module.")
 		return c.restartDashboard()
@@ -161,14 +172,28 @@ func (c *Cluster) initializeSecureDashboard() (bool, error) {
 		return false, errors.Wrapf(err, "failed to generate a password for the ceph dashboard")
 	}
 
-	if c.dashboard.SSL {
-		alreadyCreated, err := c.createSelfSignedCert()
+	if c.dashboard.SSL && c.dashboard.CertificateSecret != "" {
+		certChanged, err := c.configureDashboardCert()
 		if err != nil {
-			return false, errors.Wrapf(err, "failed to create a self signed cert for the ceph dashboard")
+			return false, errors.Wrapf(err, "failed to configure the certificate of the ceph dashboard")
 		}
-		if alreadyCreated {
+		if !certChanged {
 			return false, nil
 		}
+	} else {
+		// the certificate of a secret removed from the spec is replaced by a self signed certificate
+		if _, err := c.removeDashboardCert(); err != nil {
+			return false, errors.Wrapf(err, "failed to remove the certificate of the ceph dashboard")
+		}
+		if c.dashboard.SSL {
+			alreadyCreated, err := c.createSelfSignedCert()
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to create a self signed cert for the ceph dashboard")
+			}
+			if alreadyCreated {
+				return false, nil
+			}
+		}
 	}
 
 	if err := c.setLoginCredentials(password); err != nil {
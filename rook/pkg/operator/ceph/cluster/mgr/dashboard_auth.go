@@ -0,0 +1,308 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	dashboardCertKey        = "mgr/dashboard/crt"
+	dashboardCertPrivateKey = "mgr/dashboard/key"
+	defaultSSOUsernameAttr  = "uid"
+	// the sso settings stored by the dashboard, the protocol is empty when sso is disabled
+	dashboardSSODBKey = "mgr/dashboard/ssodb_v1"
+	// the version of the password secret last applied to a dashboard user, the password hash cannot be compared
+	dashboardUserPasswordVersionKeyFmt = "rook/dashboard/users/%s/password-version"
+	// the secret whose certificate is set in the mgr, to remove the certificate when the secret is removed from the spec
+	dashboardCertSecretKey = "rook/dashboard/certificate-secret"
+)
+
+// dashboardUser is the description of a user returned by "ceph dashboard ac-user-show <username>"
+type dashboardUser struct {
+	Username string   `json:"username"`
+	Roles    []string `json:"roles"`
+}
+
+// ssoDB is the part of the sso settings stored by the dashboard that tells whether sso is enabled
+type ssoDB struct {
+	Protocol string `json:"protocol"`
+}
+
+// saml2Config is the part of the configuration returned by "ceph dashboard sso show saml2" that the operator sets
+type saml2Config struct {
+	OneLoginSettings struct {
+		SP struct {
+			EntityID                  string `json:"entityId"`
+			AttributeConsumingService struct {
+				RequestedAttributes []struct {
+					Name string `json:"name"`
+				} `json:"requestedAttributes"`
+			} `json:"attributeConsumingService"`
+		} `json:"sp"`
+		IdP struct {
+			EntityID string `json:"entityId"`
+		} `json:"idp"`
+	} `json:"onelogin_settings"`
+}
+
+// configureDashboardCert sets the certificate and key of the TLS Secret of the dashboard spec. Returns whether the
+// certificate changed and the dashboard must be restarted.
+func (c *Cluster) configureDashboardCert() (bool, error) {
+	secret, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(c.dashboard.CertificateSecret, metav1.GetOptions{})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get dashboard certificate secret %q", c.dashboard.CertificateSecret)
+	}
+	cert, key := secret.Data[v1.TLSCertKey], secret.Data[v1.TLSPrivateKeyKey]
+	if len(cert) == 0 || len(key) == 0 {
+		return false, errors.Errorf("dashboard certificate secret %q must have the %q and %q keys", secret.Name, v1.TLSCertKey, v1.TLSPrivateKeyKey)
+	}
+
+	hasChanged := false
+	for configKey, value := range map[string]string{dashboardCertKey: string(cert), dashboardCertPrivateKey: string(key)} {
+		current, err := client.NewCephCommand(c.context, c.Namespace, []string{"config-key", "get", configKey}).Run()
+		if err == nil && strings.TrimSpace(string(current)) == strings.TrimSpace(value) {
+			continue
+		}
+		if _, err := c.runDashboardCommand("set dashboard certificate", "config-key", "set", configKey, value); err != nil {
+			return false, errors.Wrapf(err, "failed to set %q from secret %q", configKey, secret.Name)
+		}
+		hasChanged = true
+	}
+	if hasChanged {
+		logger.Infof("dashboard certificate set from secret %q", secret.Name)
+	}
+	if current, err := client.NewCephCommand(c.context, c.Namespace, []string{"config-key", "get", dashboardCertSecretKey}).Run(); err != nil || strings.TrimSpace(string(current)) != secret.Name {
+		if _, err := c.runDashboardCommand("record dashboard certificate secret", "config-key", "set", dashboardCertSecretKey, secret.Name); err != nil {
+			return false, errors.Wrapf(err, "failed to record the dashboard certificate secret %q", secret.Name)
+		}
+	}
+	return hasChanged, nil
+}
+
+// removeDashboardCert removes the certificate and key set from the secret of the dashboard spec once the secret is
+// removed from the spec, so the dashboard gets a self signed certificate again. Returns whether the certificate was
+// removed and the dashboard must be restarted.
+func (c *Cluster) removeDashboardCert() (bool, error) {
+	secretName, err := client.NewCephCommand(c.context, c.Namespace, []string{"config-key", "get", dashboardCertSecretKey}).Run()
+	if err != nil || strings.TrimSpace(string(secretName)) == "" {
+		// no certificate was set from a secret
+		return false, nil
+	}
+	for _, configKey := range []string{dashboardCertKey, dashboardCertPrivateKey, dashboardCertSecretKey} {
+		if _, err := c.runDashboardCommand("remove dashboard certificate", "config-key", "rm", configKey); err != nil {
+			return false, errors.Wrapf(err, "failed to remove %q", configKey)
+		}
+	}
+	logger.Infof("dashboard certificate of secret %q removed", strings.TrimSpace(string(secretName)))
+	return true, nil
+}
+
+// configureDashboardUsers creates the users of the dashboard spec and updates their roles and password. The users
+// that are not in the spec are left untouched since they may have been created from the dashboard.
+func (c *Cluster) configureDashboardUsers() error {
+	if len(c.dashboard.Users) == 0 {
+		return nil
+	}
+	output, err := c.runDashboardCommand("list dashboard users", "dashboard", "ac-user-show")
+	if err != nil {
+		return errors.Wrapf(err, "failed to list dashboard users")
+	}
+	var usernames []string
+	if err := json.Unmarshal(output, &usernames); err != nil {
+		return errors.Wrapf(err, "failed to parse dashboard users. %s", string(output))
+	}
+	existing := map[string]bool{}
+	for _, username := range usernames {
+		existing[username] = true
+	}
+
+	for _, user := range c.dashboard.Users {
+		if user.Username == "" || user.Username == dashboardUsername {
+			return errors.Errorf("invalid dashboard username %q", user.Username)
+		}
+		password, version, err := c.getDashboardUserPassword(user)
+		if err != nil {
+			return err
+		}
+
+		versionKey := fmt.Sprintf(dashboardUserPasswordVersionKeyFmt, user.Username)
+		if !existing[user.Username] {
+			logger.Infof("creating dashboard user %q", user.Username)
+			if _, err := c.runDashboardCommand("create dashboard user", "dashboard", "ac-user-create", user.Username, password); err != nil {
+				return errors.Wrapf(err, "failed to create dashboard user %q", user.Username)
+			}
+		} else if current, err := client.NewCephCommand(c.context, c.Namespace, []string{"config-key", "get", versionKey}).Run(); err != nil || strings.TrimSpace(string(current)) != version {
+			logger.Infof("setting the password of dashboard user %q from secret %q", user.Username, user.PasswordSecret)
+			if _, err := c.runDashboardCommand("set dashboard user password", "dashboard", "ac-user-set-password", user.Username, password); err != nil {
+				return errors.Wrapf(err, "failed to set the password of dashboard user %q", user.Username)
+			}
+		}
+		if _, err := c.runDashboardCommand("set dashboard user password version", "config-key", "set", versionKey, version); err != nil {
+			return errors.Wrapf(err, "failed to record the password version of dashboard user %q", user.Username)
+		}
+
+		output, err := c.runDashboardCommand("show dashboard user", "dashboard", "ac-user-show", user.Username)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get dashboard user %q", user.Username)
+		}
+		var current dashboardUser
+		if err := json.Unmarshal(output, &current); err != nil {
+			return errors.Wrapf(err, "failed to parse dashboard user %q. %s", user.Username, string(output))
+		}
+		if sameRoles(current.Roles, user.Roles) {
+			continue
+		}
+		logger.Infof("setting roles %v of dashboard user %q", user.Roles, user.Username)
+		args := append([]string{"dashboard", "ac-user-set-roles", user.Username}, user.Roles...)
+		if _, err := c.runDashboardCommand("set dashboard user roles", args...); err != nil {
+			return errors.Wrapf(err, "failed to set the roles of dashboard user %q", user.Username)
+		}
+	}
+	return nil
+}
+
+// getDashboardUserPassword returns the password of a dashboard user from its Secret, and the version of the Secret
+func (c *Cluster) getDashboardUserPassword(user cephv1.DashboardUser) (string, string, error) {
+	if user.PasswordSecret == "" {
+		return "", "", errors.Errorf("password secret not specified for dashboard user %q", user.Username)
+	}
+	secret, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(user.PasswordSecret, metav1.GetOptions{})
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to get the password secret of dashboard user %q", user.Username)
+	}
+	password, err := decodeSecret(secret)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to get the password of dashboard user %q from secret %q", user.Username, secret.Name)
+	}
+	return password, fmt.Sprintf("%s/%s/%s", secret.Name, secret.UID, secret.ResourceVersion), nil
+}
+
+// configureDashboardSSO sets up and enables the SAML2 single sign-on of the dashboard spec, or disables single
+// sign-on when it was removed from the spec
+func (c *Cluster) configureDashboardSSO() error {
+	enabled, err := c.dashboardSSOEnabled()
+	if err != nil {
+		return err
+	}
+
+	sso := c.dashboard.SSO
+	if sso == nil {
+		if enabled {
+			logger.Infof("disabling dashboard sso")
+			if _, err := c.runDashboardCommand("disable dashboard sso", "dashboard", "sso", "disable"); err != nil {
+				return errors.Wrapf(err, "failed to disable dashboard sso")
+			}
+		}
+		return nil
+	}
+	if sso.BaseURL == "" || sso.IdPMetadata == "" {
+		return errors.New("the base url and the idp metadata are required for the dashboard sso")
+	}
+	baseURL := strings.TrimSuffix(sso.BaseURL, "/")
+	usernameAttr := sso.UsernameAttribute
+	if usernameAttr == "" {
+		usernameAttr = defaultSSOUsernameAttr
+	}
+
+	if !enabled || !c.dashboardSSOConfigured(baseURL, usernameAttr, sso.IdPEntityID) {
+		logger.Infof("setting up dashboard sso with saml2")
+		args := []string{"dashboard", "sso", "setup", "saml2", baseURL, sso.IdPMetadata, usernameAttr}
+		if sso.IdPEntityID != "" {
+			args = append(args, sso.IdPEntityID)
+		}
+		if _, err := c.runDashboardCommand("set up dashboard sso", args...); err != nil {
+			return errors.Wrapf(err, "failed to set up dashboard sso")
+		}
+	}
+	if !enabled {
+		if _, err := c.runDashboardCommand("enable dashboard sso", "dashboard", "sso", "enable", "saml2"); err != nil {
+			return errors.Wrapf(err, "failed to enable dashboard sso")
+		}
+		logger.Infof("dashboard sso enabled")
+	}
+	return nil
+}
+
+// dashboardSSOEnabled returns whether sso is enabled from the sso settings stored by the dashboard. The settings do
+// not exist until sso is set up.
+func (c *Cluster) dashboardSSOEnabled() (bool, error) {
+	output, err := client.NewCephCommand(c.context, c.Namespace, []string{"config-key", "get", dashboardSSODBKey}).Run()
+	if err != nil || len(strings.TrimSpace(string(output))) == 0 {
+		logger.Debugf("dashboard sso is not set up. %v", err)
+		return false, nil
+	}
+	var db ssoDB
+	if err := json.Unmarshal(output, &db); err != nil {
+		return false, errors.Wrapf(err, "failed to parse dashboard sso settings. %s", string(output))
+	}
+	return db.Protocol != "", nil
+}
+
+// dashboardSSOConfigured returns whether the current SAML2 configuration of the dashboard matches the spec
+func (c *Cluster) dashboardSSOConfigured(baseURL, usernameAttr, idpEntityID string) bool {
+	output, err := c.runDashboardCommand("show dashboard sso", "dashboard", "sso", "show", "saml2")
+	if err != nil {
+		logger.Infof("dashboard sso is not configured. %v", err)
+		return false
+	}
+	var config saml2Config
+	if err := json.Unmarshal(output, &config); err != nil {
+		logger.Warningf("failed to parse dashboard sso config. %v", err)
+		return false
+	}
+	sp := config.OneLoginSettings.SP
+	if sp.EntityID != fmt.Sprintf("%s/auth/saml2/metadata", baseURL) {
+		return false
+	}
+	if len(sp.AttributeConsumingService.RequestedAttributes) == 0 || sp.AttributeConsumingService.RequestedAttributes[0].Name != usernameAttr {
+		return false
+	}
+	return idpEntityID == "" || config.OneLoginSettings.IdP.EntityID == idpEntityID
+}
+
+// runDashboardCommand runs a ceph command, retrying while the dashboard module is not ready to accept commands.
+// The arguments are not logged since they may contain passwords or keys.
+func (c *Cluster) runDashboardCommand(action string, args ...string) ([]byte, error) {
+	return client.ExecuteCephCommandWithRetry(func() (string, []byte, error) {
+		cmd := client.NewCephCommand(c.context, c.Namespace, args)
+		output, err := cmd.RunWithTimeout(client.CmdExecuteTimeout)
+		return action, output, err
+	}, c.exitCode, 5, invalidArgErrorCode, dashboardInitWaitTime)
+}
+
+// sameRoles returns whether two lists of roles have the same roles in any order
+func sameRoles(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	x := append([]string{}, a...)
+	y := append([]string{}, b...)
+	sort.Strings(x)
+	sort.Strings(y)
+	return reflect.DeepEqual(x, y)
+}
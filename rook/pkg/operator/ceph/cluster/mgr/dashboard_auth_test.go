@@ -0,0 +1,246 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeDashboard simulates the dashboard commands of the mgr
+type fakeDashboard struct {
+	configKeys map[string]string
+	users      map[string]*dashboardUser
+	passwords  map[string]string
+	sso        string
+	commands   []string
+}
+
+// ssoEnabled returns whether sso is enabled in the sso settings stored by the dashboard
+func (d *fakeDashboard) ssoEnabled() bool {
+	return strings.Contains(d.configKeys[dashboardSSODBKey], `"protocol": "saml2"`)
+}
+
+func (d *fakeDashboard) run(args ...string) (string, error) {
+	// strip the connection flags
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "--") {
+			args = args[:i]
+			break
+		}
+	}
+	if len(args) > 2 && args[1] == "sso" {
+		d.commands = append(d.commands, strings.Join(args[:3], " "))
+	} else {
+		d.commands = append(d.commands, strings.Join(args[:2], " "))
+	}
+	switch {
+	case args[0] == "config-key" && args[1] == "get":
+		if value, ok := d.configKeys[args[2]]; ok {
+			return value, nil
+		}
+		return "", errors.New("not found")
+	case args[0] == "config-key" && args[1] == "set":
+		d.configKeys[args[2]] = args[3]
+	case args[0] == "config-key" && args[1] == "rm":
+		delete(d.configKeys, args[2])
+	case args[1] == "ac-user-show" && len(args) == 2:
+		usernames := []string{}
+		for username := range d.users {
+			usernames = append(usernames, username)
+		}
+		b, _ := json.Marshal(usernames)
+		return string(b), nil
+	case args[1] == "ac-user-show":
+		b, _ := json.Marshal(d.users[args[2]])
+		return string(b), nil
+	case args[1] == "ac-user-create":
+		d.users[args[2]] = &dashboardUser{Username: args[2], Roles: []string{}}
+		d.passwords[args[2]] = args[3]
+	case args[1] == "ac-user-set-password":
+		d.passwords[args[2]] = args[3]
+	case args[1] == "ac-user-set-roles":
+		d.users[args[2]].Roles = args[3:]
+	case args[1] == "sso" && args[2] == "setup":
+		d.configKeys[dashboardSSODBKey] = `{"protocol": "", "saml2": {}}`
+		d.sso = `{"onelogin_settings": {"sp": {"entityId": "` + args[4] + `/auth/saml2/metadata",
+			"attributeConsumingService": {"requestedAttributes": [{"name": "` + args[6] + `"}]}}, "idp": {"entityId": "idp"}}}`
+	case args[1] == "sso" && args[2] == "show":
+		if d.sso == "" {
+			return "", errors.New("not configured")
+		}
+		return d.sso, nil
+	case args[1] == "sso" && args[2] == "enable":
+		d.configKeys[dashboardSSODBKey] = `{"protocol": "saml2", "saml2": {}}`
+	case args[1] == "sso" && args[2] == "disable":
+		d.configKeys[dashboardSSODBKey] = `{"protocol": "", "saml2": {}}`
+	}
+	return "", nil
+}
+
+func newDashboardAuthTest(t *testing.T) (*Cluster, *fakeDashboard) {
+	d := &fakeDashboard{configKeys: map[string]string{}, users: map[string]*dashboardUser{}, passwords: map[string]string{}}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfileArg string, args ...string) (string, error) {
+			return d.run(args...)
+		},
+		MockExecuteCommandWithOutputFileTimeout: func(timeout time.Duration, command, outfileArg string, args ...string) (string, error) {
+			return d.run(args...)
+		},
+	}
+	c := &Cluster{context: &clusterd.Context{Clientset: test.New(t, 1), Executor: executor}, Namespace: "ns"}
+	c.exitCode = func(err error) (int, bool) { return 0, false }
+	dashboardInitWaitTime = 0
+	return c, d
+}
+
+func TestConfigureDashboardCert(t *testing.T) {
+	c, d := newDashboardAuthTest(t)
+	c.dashboard = cephv1.DashboardSpec{Enabled: true, SSL: true, CertificateSecret: "dashboard-tls"}
+
+	// the secret does not exist
+	_, err := c.configureDashboardCert()
+	assert.Error(t, err)
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "dashboard-tls", Namespace: "ns"},
+		Data:       map[string][]byte{v1.TLSCertKey: []byte("cert"), v1.TLSPrivateKeyKey: []byte("key")},
+		Type:       v1.SecretTypeTLS,
+	}
+	_, err = c.context.Clientset.CoreV1().Secrets("ns").Create(secret)
+	assert.NoError(t, err)
+	changed, err := c.configureDashboardCert()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "cert", d.configKeys[dashboardCertKey])
+	assert.Equal(t, "key", d.configKeys[dashboardCertPrivateKey])
+
+	// the cert did not change
+	changed, err = c.configureDashboardCert()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	// the cert is renewed
+	secret.Data[v1.TLSCertKey] = []byte("renewed")
+	_, err = c.context.Clientset.CoreV1().Secrets("ns").Update(secret)
+	assert.NoError(t, err)
+	changed, err = c.configureDashboardCert()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "renewed", d.configKeys[dashboardCertKey])
+	assert.Equal(t, "dashboard-tls", d.configKeys[dashboardCertSecretKey])
+
+	// the secret is removed from the spec
+	removed, err := c.removeDashboardCert()
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	assert.Empty(t, d.configKeys)
+	removed, err = c.removeDashboardCert()
+	assert.NoError(t, err)
+	assert.False(t, removed)
+
+	// the self signed certificate is not removed
+	d.configKeys[dashboardCertKey] = "self-signed"
+	removed, err = c.removeDashboardCert()
+	assert.NoError(t, err)
+	assert.False(t, removed)
+	assert.Equal(t, "self-signed", d.configKeys[dashboardCertKey])
+}
+
+func TestConfigureDashboardUsers(t *testing.T) {
+	c, d := newDashboardAuthTest(t)
+	d.users["admin"] = &dashboardUser{Username: "admin", Roles: []string{"administrator"}}
+	c.dashboard.Users = []cephv1.DashboardUser{
+		{Username: "alice", Roles: []string{"read-only", "block-manager"}, PasswordSecret: "alice-password"},
+	}
+
+	// the password secret does not exist
+	assert.Error(t, c.configureDashboardUsers())
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "alice-password", Namespace: "ns"},
+		Data:       map[string][]byte{passwordKeyName: []byte("secret")},
+	}
+	_, err := c.context.Clientset.CoreV1().Secrets("ns").Create(secret)
+	assert.NoError(t, err)
+	assert.NoError(t, c.configureDashboardUsers())
+	assert.Equal(t, []string{"read-only", "block-manager"}, d.users["alice"].Roles)
+	assert.Equal(t, "secret", d.passwords["alice"])
+	assert.Equal(t, []string{"administrator"}, d.users["admin"].Roles)
+
+	// the roles and the password are only set when they changed
+	d.commands = nil
+	c.dashboard.Users[0].Roles = []string{"block-manager", "read-only"}
+	assert.NoError(t, c.configureDashboardUsers())
+	assert.NotContains(t, d.commands, "dashboard ac-user-set-roles")
+	assert.NotContains(t, d.commands, "dashboard ac-user-create")
+	assert.NotContains(t, d.commands, "dashboard ac-user-set-password")
+
+	secret.Data[passwordKeyName] = []byte("changed")
+	secret.ResourceVersion = "2"
+	_, err = c.context.Clientset.CoreV1().Secrets("ns").Update(secret)
+	assert.NoError(t, err)
+	assert.NoError(t, c.configureDashboardUsers())
+	assert.Equal(t, "changed", d.passwords["alice"])
+
+	c.dashboard.Users[0].Roles = []string{"administrator"}
+	assert.NoError(t, c.configureDashboardUsers())
+	assert.Equal(t, []string{"administrator"}, d.users["alice"].Roles)
+
+	// the admin user is managed by the operator
+	c.dashboard.Users[0].Username = "admin"
+	assert.Error(t, c.configureDashboardUsers())
+}
+
+func TestConfigureDashboardSSO(t *testing.T) {
+	c, d := newDashboardAuthTest(t)
+
+	// sso is not enabled
+	assert.NoError(t, c.configureDashboardSSO())
+	assert.False(t, d.ssoEnabled())
+
+	c.dashboard.SSO = &cephv1.DashboardSSOSpec{BaseURL: "https://dashboard.example.com/", IdPMetadata: "https://idp.example.com/metadata"}
+	assert.NoError(t, c.configureDashboardSSO())
+	assert.True(t, d.ssoEnabled())
+	assert.Contains(t, d.sso, `"https://dashboard.example.com/auth/saml2/metadata"`)
+	assert.Contains(t, d.sso, `"uid"`)
+
+	// sso is not set up again when the config did not change
+	d.commands = nil
+	assert.NoError(t, c.configureDashboardSSO())
+	assert.Equal(t, []string{"config-key get", "dashboard sso show"}, d.commands)
+
+	c.dashboard.SSO.UsernameAttribute = "email"
+	assert.NoError(t, c.configureDashboardSSO())
+	assert.Contains(t, d.sso, `"email"`)
+
+	// sso is disabled when removed from the spec
+	c.dashboard.SSO = nil
+	assert.NoError(t, c.configureDashboardSSO())
+	assert.False(t, d.ssoEnabled())
+}
@@ -0,0 +1,349 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	discoverDaemon "github.com/rook/rook/pkg/daemon/discover"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/discover"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/sys"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// OrchestratorInventoryName is the name of the ConfigMap with the inventory read by the rook orchestrator module
+	OrchestratorInventoryName = "rook-ceph-orchestrator-inventory"
+	orchestratorAppName       = "rook-ceph-orchestrator"
+	inventoryDevicesKey       = "devices"
+	inventoryDaemonsKey       = "daemons"
+	inventoryLastRefreshKey   = "lastRefresh"
+	crashLoopBackOffReason    = "CrashLoopBackOff"
+)
+
+var (
+	orchestratorInventoryInterval = 30 * time.Second
+	// the daemon types reported to the orchestrator, keyed by the app label of their pods
+	orchestratorDaemonTypes = map[string]string{
+		"rook-ceph-mon":        "mon",
+		"rook-ceph-mgr":        "mgr",
+		"rook-ceph-osd":        "osd",
+		"rook-ceph-mds":        "mds",
+		"rook-ceph-rgw":        "rgw",
+		"rook-ceph-nfs":        "nfs",
+		"rook-ceph-rbd-mirror": "rbd-mirror",
+	}
+)
+
+// InventoryDevice is a device of a node in the orchestrator inventory
+type InventoryDevice struct {
+	Path            string   `json:"path"`
+	Size            uint64   `json:"size"`
+	Rotational      bool     `json:"rotational"`
+	Type            string   `json:"type"`
+	Model           string   `json:"model,omitempty"`
+	Vendor          string   `json:"vendor,omitempty"`
+	Serial          string   `json:"serial,omitempty"`
+	DevLinks        []string `json:"devLinks,omitempty"`
+	Available       bool     `json:"available"`
+	RejectedReasons []string `json:"rejectedReasons,omitempty"`
+}
+
+// InventoryDaemon is a daemon managed by the operator in the orchestrator inventory. The status follows the
+// orchestrator convention: 1 running, 0 stopped and -1 error.
+type InventoryDaemon struct {
+	DaemonType     string `json:"daemonType"`
+	DaemonID       string `json:"daemonID"`
+	Hostname       string `json:"hostname"`
+	PodName        string `json:"podName"`
+	ContainerImage string `json:"containerImage,omitempty"`
+	Version        string `json:"version,omitempty"`
+	Status         int    `json:"status"`
+	StatusDesc     string `json:"statusDesc"`
+	Started        string `json:"started,omitempty"`
+}
+
+// OrchestratorInventory publishes the devices discovered on the nodes and the daemons managed by the operator for the
+// rook orchestrator module of the mgr, and applies the OSD creation requests of the orchestrator
+type OrchestratorInventory struct {
+	context           *clusterd.Context
+	namespace         string
+	clusterName       string
+	operatorNamespace string
+}
+
+// NewOrchestratorInventory instantiates the publication of the orchestrator inventory
+func NewOrchestratorInventory(context *clusterd.Context, namespace, clusterName string) *OrchestratorInventory {
+	return &OrchestratorInventory{
+		context:           context,
+		namespace:         namespace,
+		clusterName:       clusterName,
+		operatorNamespace: os.Getenv(k8sutil.PodNamespaceEnvVar),
+	}
+}
+
+// Start refreshes the inventory and processes the orchestrator requests at set intervals
+func (o *OrchestratorInventory) Start(stopCh chan struct{}) {
+	for {
+		select {
+		case <-time.After(orchestratorInventoryInterval):
+			logger.Debug("refreshing the orchestrator inventory")
+			devices, err := o.refresh()
+			if err != nil {
+				logger.Warningf("failed to refresh the orchestrator inventory. %v", err)
+			}
+			if err := o.processRequests(devices); err != nil {
+				logger.Warningf("failed to process the orchestrator requests. %v", err)
+			}
+
+		case <-stopCh:
+			logger.Infof("stopping the orchestrator inventory in namespace %s", o.namespace)
+			return
+		}
+	}
+}
+
+// refresh publishes the current devices and daemons in the inventory ConfigMap and returns the devices
+func (o *OrchestratorInventory) refresh() (map[string][]InventoryDevice, error) {
+	devices, err := o.listDevices()
+	if err != nil {
+		return nil, err
+	}
+	daemons, err := o.listDaemons()
+	if err != nil {
+		return devices, err
+	}
+
+	devicesJSON, err := json.Marshal(devices)
+	if err != nil {
+		return devices, errors.Wrapf(err, "failed to marshal the inventory devices")
+	}
+	daemonsJSON, err := json.Marshal(daemons)
+	if err != nil {
+		return devices, errors.Wrapf(err, "failed to marshal the inventory daemons")
+	}
+	data := map[string]string{
+		inventoryDevicesKey:     string(devicesJSON),
+		inventoryDaemonsKey:     string(daemonsJSON),
+		inventoryLastRefreshKey: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	configMaps := o.context.Clientset.CoreV1().ConfigMaps(o.namespace)
+	existing, err := configMaps.Get(OrchestratorInventoryName, metav1.GetOptions{})
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return devices, errors.Wrapf(err, "failed to get the orchestrator inventory")
+		}
+		cm := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      OrchestratorInventoryName,
+				Namespace: o.namespace,
+				Labels:    controller.AppLabels(orchestratorAppName, o.namespace),
+			},
+			Data: data,
+		}
+		if _, err := configMaps.Create(cm); err != nil {
+			return devices, errors.Wrapf(err, "failed to create the orchestrator inventory")
+		}
+		logger.Infof("orchestrator inventory %q created", OrchestratorInventoryName)
+		return devices, nil
+	}
+
+	if existing.Data[inventoryDevicesKey] == data[inventoryDevicesKey] && existing.Data[inventoryDaemonsKey] == data[inventoryDaemonsKey] {
+		return devices, nil
+	}
+	existing.Data = data
+	if _, err := configMaps.Update(existing); err != nil {
+		return devices, errors.Wrapf(err, "failed to update the orchestrator inventory")
+	}
+	return devices, nil
+}
+
+// listDevices returns the devices found by the discovery daemon on each node, with whether they are available for a
+// new OSD. There are no devices when the discovery daemon is not running.
+func (o *OrchestratorInventory) listDevices() (map[string][]InventoryDevice, error) {
+	inventory := map[string][]InventoryDevice{}
+	if o.operatorNamespace == "" {
+		return inventory, nil
+	}
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.AppAttr, discoverDaemon.AppName)}
+	cms, err := o.context.Clientset.CoreV1().ConfigMaps(o.operatorNamespace).List(listOpts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the device configmaps")
+	}
+
+	for _, cm := range cms.Items {
+		node := cm.Labels[discoverDaemon.NodeAttr]
+		devicesJSON := cm.Data[discoverDaemon.LocalDiskCMData]
+		if node == "" || devicesJSON == "" {
+			continue
+		}
+		var disks []sys.LocalDisk
+		if err := json.Unmarshal([]byte(devicesJSON), &disks); err != nil {
+			logger.Warningf("failed to parse the devices of node %q. %v", node, err)
+			continue
+		}
+		inUse, err := discover.ListDevicesInUse(o.context, o.operatorNamespace, node)
+		if err != nil {
+			logger.Warningf("failed to list the devices in use on node %q. %v", node, err)
+		}
+		used := map[string]bool{}
+		for _, disk := range inUse {
+			used[disk.Name] = true
+		}
+
+		devices := []InventoryDevice{}
+		for _, disk := range disks {
+			devices = append(devices, toInventoryDevice(disk, used[disk.Name]))
+		}
+		sort.Slice(devices, func(i, j int) bool { return devices[i].Path < devices[j].Path })
+		inventory[node] = devices
+	}
+	return inventory, nil
+}
+
+// toInventoryDevice converts a discovered device. The availability reported by ceph-volume is used when the discovery
+// daemon runs the ceph-volume inventory.
+func toInventoryDevice(disk sys.LocalDisk, inUse bool) InventoryDevice {
+	device := InventoryDevice{
+		Path:       path.Join("/dev", disk.Name),
+		Size:       disk.Size,
+		Rotational: disk.Rotational,
+		Type:       disk.Type,
+		Model:      disk.Model,
+		Vendor:     disk.Vendor,
+		Serial:     disk.Serial,
+		DevLinks:   strings.Fields(disk.DevLinks),
+	}
+
+	reasons := []string{}
+	var cv discoverDaemon.CephVolumeInventory
+	if disk.CephVolumeData != "" && json.Unmarshal([]byte(disk.CephVolumeData), &cv) == nil {
+		var cvReasons []string
+		if !cv.Available && json.Unmarshal(cv.RejectedReasons, &cvReasons) == nil {
+			reasons = append(reasons, cvReasons...)
+		}
+		if !cv.Available && len(reasons) == 0 {
+			reasons = append(reasons, "rejected by ceph-volume")
+		}
+	} else {
+		if disk.Readonly {
+			reasons = append(reasons, "read-only")
+		}
+		if disk.Filesystem != "" {
+			reasons = append(reasons, fmt.Sprintf("has a %s filesystem", disk.Filesystem))
+		}
+		if len(disk.Partitions) > 0 || disk.HasChildren {
+			reasons = append(reasons, "has partitions")
+		}
+		if len(reasons) == 0 && !disk.Empty {
+			reasons = append(reasons, "not empty")
+		}
+	}
+	if inUse {
+		reasons = append(reasons, "used by a rook cluster")
+	}
+	device.Available = len(reasons) == 0
+	if !device.Available {
+		device.RejectedReasons = reasons
+	}
+	return device
+}
+
+// listDaemons returns the ceph daemons managed by the operator in the cluster namespace
+func (o *OrchestratorInventory) listDaemons() ([]InventoryDaemon, error) {
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.ClusterAttr, o.namespace)}
+	pods, err := o.context.Clientset.CoreV1().Pods(o.namespace).List(listOpts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the ceph pods")
+	}
+	// the ceph version is only reported on the deployments
+	deployments, err := o.context.Clientset.AppsV1().Deployments(o.namespace).List(listOpts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the ceph deployments")
+	}
+	versions := map[string]string{}
+	for _, d := range deployments.Items {
+		versions[d.Labels[k8sutil.AppAttr]+"/"+d.Labels["ceph_daemon_id"]] = d.Labels[controller.CephVersionLabelKey]
+	}
+
+	daemons := []InventoryDaemon{}
+	for _, pod := range pods.Items {
+		app := pod.Labels[k8sutil.AppAttr]
+		daemonType, ok := orchestratorDaemonTypes[app]
+		if !ok || pod.Labels["ceph_daemon_id"] == "" {
+			continue
+		}
+		daemon := InventoryDaemon{
+			DaemonType: daemonType,
+			DaemonID:   pod.Labels["ceph_daemon_id"],
+			Hostname:   pod.Spec.NodeName,
+			PodName:    pod.Name,
+			Version:    versions[app+"/"+pod.Labels["ceph_daemon_id"]],
+		}
+		if len(pod.Spec.Containers) > 0 {
+			daemon.ContainerImage = pod.Spec.Containers[0].Image
+		}
+		if pod.Status.StartTime != nil {
+			daemon.Started = pod.Status.StartTime.UTC().Format(time.RFC3339)
+		}
+		daemon.Status, daemon.StatusDesc = daemonStatus(pod)
+		daemons = append(daemons, daemon)
+	}
+	sort.Slice(daemons, func(i, j int) bool {
+		if daemons[i].DaemonType != daemons[j].DaemonType {
+			return daemons[i].DaemonType < daemons[j].DaemonType
+		}
+		return daemons[i].DaemonID < daemons[j].DaemonID
+	})
+	return daemons, nil
+}
+
+// daemonStatus returns the orchestrator status of the daemon of a pod with its description
+func daemonStatus(pod v1.Pod) (int, string) {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting != nil && status.State.Waiting.Reason == crashLoopBackOffReason {
+			return -1, strings.ToLower(status.State.Waiting.Reason)
+		}
+	}
+	switch pod.Status.Phase {
+	case v1.PodRunning:
+		for _, status := range pod.Status.ContainerStatuses {
+			if !status.Ready {
+				return 0, "starting"
+			}
+		}
+		return 1, "running"
+	case v1.PodFailed:
+		return -1, "error"
+	case v1.PodPending:
+		return 0, "starting"
+	}
+	return 0, "stopped"
+}
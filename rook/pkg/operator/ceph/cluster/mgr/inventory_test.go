@@ -0,0 +1,214 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"encoding/json"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/rook/rook/pkg/util/sys"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestToInventoryDevice(t *testing.T) {
+	disk := sys.LocalDisk{Name: "sdb", Size: 100, Rotational: true, Type: "disk", Empty: true, DevLinks: "/dev/disk/by-id/wwn-1 /dev/disk/by-path/pci-1"}
+	device := toInventoryDevice(disk, false)
+	assert.Equal(t, "/dev/sdb", device.Path)
+	assert.Equal(t, []string{"/dev/disk/by-id/wwn-1", "/dev/disk/by-path/pci-1"}, device.DevLinks)
+	assert.True(t, device.Available)
+	assert.Empty(t, device.RejectedReasons)
+
+	// used by an OSD
+	device = toInventoryDevice(disk, true)
+	assert.False(t, device.Available)
+	assert.Equal(t, []string{"used by a rook cluster"}, device.RejectedReasons)
+
+	disk = sys.LocalDisk{Name: "sdc", Filesystem: "ext4", Readonly: true}
+	device = toInventoryDevice(disk, false)
+	assert.False(t, device.Available)
+	assert.Equal(t, []string{"read-only", "has a ext4 filesystem"}, device.RejectedReasons)
+
+	// the ceph-volume inventory takes precedence
+	disk = sys.LocalDisk{Name: "sdd", Empty: true, CephVolumeData: `{"path":"/dev/sdd","available":false,"rejected_reasons":["locked","LVM detected"]}`}
+	device = toInventoryDevice(disk, false)
+	assert.False(t, device.Available)
+	assert.Equal(t, []string{"locked", "LVM detected"}, device.RejectedReasons)
+}
+
+func TestRefreshInventory(t *testing.T) {
+	clientset := testop.New(t, 1)
+	inventory := &OrchestratorInventory{
+		context:           &clusterd.Context{Clientset: clientset},
+		namespace:         "ns",
+		clusterName:       "cluster",
+		operatorNamespace: "operator-ns",
+	}
+
+	disks, _ := json.Marshal([]sys.LocalDisk{{Name: "sdc", Empty: true}, {Name: "sdb"}})
+	_, err := clientset.CoreV1().ConfigMaps("operator-ns").Create(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "local-device-node0", Labels: map[string]string{"app": "rook-discover", "rook.io/node": "node0"}},
+		Data:       map[string]string{"devices": string(disks)},
+	})
+	assert.NoError(t, err)
+
+	labels := map[string]string{"app": "rook-ceph-osd", "rook_cluster": "ns", "ceph_daemon_id": "0"}
+	_, err = clientset.AppsV1().Deployments("ns").Create(&apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-0", Labels: map[string]string{"app": "rook-ceph-osd", "rook_cluster": "ns", "ceph_daemon_id": "0", "ceph-version": "15.2.1-0"}},
+	})
+	assert.NoError(t, err)
+	_, err = clientset.CoreV1().Pods("ns").Create(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-0-abc", Labels: labels},
+		Spec:       v1.PodSpec{NodeName: "node0", Containers: []v1.Container{{Image: "ceph/ceph:v15.2.1"}}},
+		Status:     v1.PodStatus{Phase: v1.PodRunning, ContainerStatuses: []v1.ContainerStatus{{Ready: true}}},
+	})
+	assert.NoError(t, err)
+	// the prepare pods are not daemons
+	_, err = clientset.CoreV1().Pods("ns").Create(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-prepare-node0", Labels: map[string]string{"app": "rook-ceph-osd-prepare", "rook_cluster": "ns"}},
+	})
+	assert.NoError(t, err)
+
+	devices, err := inventory.refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(devices["node0"]))
+	assert.Equal(t, "/dev/sdb", devices["node0"][0].Path)
+	assert.False(t, devices["node0"][0].Available)
+	assert.True(t, devices["node0"][1].Available)
+
+	cm, err := clientset.CoreV1().ConfigMaps("ns").Get(OrchestratorInventoryName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	var daemons []InventoryDaemon
+	assert.NoError(t, json.Unmarshal([]byte(cm.Data["daemons"]), &daemons))
+	assert.Equal(t, []InventoryDaemon{{
+		DaemonType:     "osd",
+		DaemonID:       "0",
+		Hostname:       "node0",
+		PodName:        "rook-ceph-osd-0-abc",
+		ContainerImage: "ceph/ceph:v15.2.1",
+		Version:        "15.2.1-0",
+		Status:         1,
+		StatusDesc:     "running",
+	}}, daemons)
+	assert.NotEqual(t, "", cm.Data["lastRefresh"])
+
+	// the inventory is updated when a daemon crash loops
+	pod, _ := clientset.CoreV1().Pods("ns").Get("rook-ceph-osd-0-abc", metav1.GetOptions{})
+	pod.Status.ContainerStatuses = []v1.ContainerStatus{{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}}}
+	_, err = clientset.CoreV1().Pods("ns").Update(pod)
+	assert.NoError(t, err)
+	_, err = inventory.refresh()
+	assert.NoError(t, err)
+	cm, _ = clientset.CoreV1().ConfigMaps("ns").Get(OrchestratorInventoryName, metav1.GetOptions{})
+	assert.NoError(t, json.Unmarshal([]byte(cm.Data["daemons"]), &daemons))
+	assert.Equal(t, -1, daemons[0].Status)
+}
+
+func TestProcessRequests(t *testing.T) {
+	clientset := testop.New(t, 1)
+	cluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "ns"},
+		Spec: cephv1.ClusterSpec{Storage: rookv1.StorageScopeSpec{
+			Nodes: []rookv1.Node{{Name: "node0", Selection: rookv1.Selection{Devices: []rookv1.Device{{Name: "sdb"}}}}},
+		}},
+	}
+	inventory := &OrchestratorInventory{
+		context:     &clusterd.Context{Clientset: clientset, RookClientset: rookclient.NewSimpleClientset(cluster)},
+		namespace:   "ns",
+		clusterName: "cluster",
+	}
+	devices := map[string][]InventoryDevice{
+		"node0": {
+			{Path: "/dev/sdb", Available: false, RejectedReasons: []string{"used by a rook cluster"}},
+			{Path: "/dev/sdc", DevLinks: []string{"/dev/disk/by-id/wwn-c"}, Available: true},
+			{Path: "/dev/sdd", Available: false, RejectedReasons: []string{"has partitions"}},
+		},
+	}
+
+	createRequest := func(name, request string) {
+		_, err := clientset.CoreV1().ConfigMaps("ns").Create(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"app": "rook-ceph-orchestrator-request"}},
+			Data:       map[string]string{"request": request},
+		})
+		assert.NoError(t, err)
+	}
+	createRequest("add-sdc", `{"action":"osd-create","host":"node0","devices":["/dev/disk/by-id/wwn-c"]}`)
+	createRequest("add-sdd", `{"action":"osd-create","host":"node0","devices":["/dev/sdd"]}`)
+	createRequest("unknown-node", `{"action":"osd-create","host":"node9","devices":["sdb"]}`)
+	createRequest("unknown-action", `{"action":"osd-remove","host":"node0","devices":["sdb"]}`)
+
+	assert.NoError(t, inventory.processRequests(devices))
+
+	state := func(name string) string {
+		cm, err := clientset.CoreV1().ConfigMaps("ns").Get(name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		return cm.Data["state"]
+	}
+	assert.Equal(t, "Completed", state("add-sdc"))
+	assert.Equal(t, "Failed", state("add-sdd"))
+	assert.Equal(t, "Failed", state("unknown-node"))
+	assert.Equal(t, "Failed", state("unknown-action"))
+
+	cluster, err := inventory.context.RookClientset.CephV1().CephClusters("ns").Get("cluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []rookv1.Device{{Name: "sdb"}, {FullPath: "/dev/disk/by-id/wwn-c"}}, cluster.Spec.Storage.Nodes[0].Devices)
+
+	// the processed requests are not applied again
+	cluster.Spec.Storage.Nodes[0].Devices = nil
+	_, err = inventory.context.RookClientset.CephV1().CephClusters("ns").Update(cluster)
+	assert.NoError(t, err)
+	assert.NoError(t, inventory.processRequests(devices))
+	cluster, _ = inventory.context.RookClientset.CephV1().CephClusters("ns").Get("cluster", metav1.GetOptions{})
+	assert.Empty(t, cluster.Spec.Storage.Nodes[0].Devices)
+}
+
+func TestAddOSDDevices(t *testing.T) {
+	storage := &rookv1.StorageScopeSpec{}
+	changed, err := addOSDDevices(storage, "node0", []string{"/dev/sdb", "sdc", "/dev/disk/by-id/wwn-d"})
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, []rookv1.Node{{Name: "node0", Selection: rookv1.Selection{Devices: []rookv1.Device{{Name: "sdb"}, {Name: "sdc"}, {FullPath: "/dev/disk/by-id/wwn-d"}}}}}, storage.Nodes)
+
+	// the devices already in the spec are not added again
+	changed, err = addOSDDevices(storage, "node0", []string{"sdb", "/dev/disk/by-id/wwn-d"})
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, 3, len(storage.Nodes[0].Devices))
+
+	_, err = addOSDDevices(storage, "node0", []string{"/dev/"})
+	assert.Error(t, err)
+	_, err = addOSDDevices(storage, "node0", []string{"disk/sdb"})
+	assert.Error(t, err)
+	_, err = addOSDDevices(storage, "", []string{"sdb"})
+	assert.Error(t, err)
+
+	useAll := true
+	storage.Nodes[0].UseAllDevices = &useAll
+	_, err = addOSDDevices(storage, "node0", []string{"sde"})
+	assert.Error(t, err)
+
+	storage = &rookv1.StorageScopeSpec{UseAllNodes: true}
+	_, err = addOSDDevices(storage, "node0", []string{"sdb"})
+	assert.Error(t, err)
+}
@@ -20,6 +20,7 @@ package mgr
 import (
 	"fmt"
 	"path"
+	"reflect"
 	"strconv"
 	"strings"
 
@@ -54,7 +55,7 @@ const (
 	crashModuleName        = "crash"
 	PgautoscalerModuleName = "pg_autoscaler"
 	balancerModuleName     = "balancer"
-	balancerModuleMode     = "upmap"
+	defaultBalancerMode    = "upmap"
 	metricsPort            = 9283
 	monitoringPath         = "/etc/ceph-monitoring/"
 	serviceMonitorFile     = "service-monitor.yaml"
@@ -78,6 +79,7 @@ type Cluster struct {
 	dashboard         cephv1.DashboardSpec
 	monitoringSpec    cephv1.MonitoringSpec
 	mgrSpec           cephv1.MgrSpec
+	balancer          cephv1.BalancerSpec
 	cephVersion       cephv1.CephVersionSpec
 	rookVersion       string
 	exitCode          func(err error) (int, bool)
@@ -98,12 +100,17 @@ func New(
 	dashboard cephv1.DashboardSpec,
 	monitoringSpec cephv1.MonitoringSpec,
 	mgrSpec cephv1.MgrSpec,
+	balancer cephv1.BalancerSpec,
 	resources v1.ResourceRequirements,
 	priorityClassName string,
 	ownerRef metav1.OwnerReference,
 	dataDirHostPath string,
 	skipUpgradeChecks bool,
 ) *Cluster {
+	replicas := 1
+	if mgrSpec.Count > 0 {
+		replicas = mgrSpec.Count
+	}
 	return &Cluster{
 		clusterInfo:       clusterInfo,
 		context:           context,
@@ -112,11 +119,12 @@ func New(
 		annotations:       annotations,
 		rookVersion:       rookVersion,
 		cephVersion:       cephVersion,
-		Replicas:          1,
+		Replicas:          replicas,
 		dataDir:           k8sutil.DataDir,
 		dashboard:         dashboard,
 		monitoringSpec:    monitoringSpec,
 		mgrSpec:           mgrSpec,
+		balancer:          balancer,
 		Network:           network,
 		resources:         resources,
 		priorityClassName: priorityClassName,
@@ -194,6 +202,15 @@ func (c *Cluster) Start() error {
 		}
 	}
 
+	if err := c.removeExtraMgrs(daemonIDs); err != nil {
+		logger.Errorf("failed to remove extra mgrs. %v", err)
+	}
+
+	// label the active mgr pod before the services select it
+	if err := reconcileActiveMgr(c.context, c.Namespace, c.ownerRef.Name); err != nil {
+		logger.Warningf("failed to check the active mgr. %v", err)
+	}
+
 	if err := c.configureDashboardService(); err != nil {
 		logger.Errorf("failed to enable dashboard. %v", err)
 	}
@@ -208,6 +225,9 @@ func (c *Cluster) Start() error {
 			return errors.Wrapf(err, "failed to create mgr service")
 		}
 		logger.Infof("mgr metrics service already exists")
+		if err := c.updateServiceSelector(service); err != nil {
+			return err
+		}
 	} else {
 		logger.Infof("mgr metrics service started")
 	}
@@ -237,6 +257,50 @@ func (c *Cluster) Start() error {
 	return nil
 }
 
+// removeExtraMgrs deletes the deployments of the mgrs beyond the desired count
+func (c *Cluster) removeExtraMgrs(daemonIDs []string) error {
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.AppAttr, AppName)}
+	deployments, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).List(listOpts)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list mgr deployments")
+	}
+	for _, d := range deployments.Items {
+		daemonID := d.Labels[config.MgrType]
+		found := false
+		for _, id := range daemonIDs {
+			if id == daemonID {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		logger.Infof("removing mgr %q beyond the desired count of %d mgrs", daemonID, c.Replicas)
+		if err := k8sutil.DeleteDeployment(c.context.Clientset, c.Namespace, d.Name); err != nil {
+			return errors.Wrapf(err, "failed to delete mgr deployment %q", d.Name)
+		}
+	}
+	return nil
+}
+
+// updateServiceSelector updates the selector of an existing mgr service if it changed with the number of mgrs
+func (c *Cluster) updateServiceSelector(service *v1.Service) error {
+	existing, err := c.context.Clientset.CoreV1().Services(c.Namespace).Get(service.Name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get mgr service %q", service.Name)
+	}
+	if reflect.DeepEqual(existing.Spec.Selector, service.Spec.Selector) {
+		return nil
+	}
+	logger.Infof("updating the selector of mgr service %q", service.Name)
+	existing.Spec.Selector = service.Spec.Selector
+	if _, err := c.context.Clientset.CoreV1().Services(c.Namespace).Update(existing); err != nil {
+		return errors.Wrapf(err, "failed to update mgr service %q", service.Name)
+	}
+	return nil
+}
+
 func (c *Cluster) configureModules(daemonIDs []string) {
 	// Configure the modules asynchronously so we can complete all the configuration much sooner.
 	startModuleConfiguration("http bind settings", c.clearHTTPBindFix)
@@ -246,6 +310,14 @@ func (c *Cluster) configureModules(daemonIDs []string) {
 	// "crash" is part of the "always_on_modules" list as of Octopus
 	if !c.clusterInfo.CephVersion.IsAtLeastOctopus() {
 		startModuleConfiguration("crash", c.enableCrashModule)
+		// The balancer is turned on with its settings if they are in the spec, unless the module is in the spec
+		if !IsModuleInSpec(c.mgrSpec.Modules, balancerModuleName) {
+			if c.balancerSpecified() {
+				startModuleConfiguration("balancer", c.enableBalancerModule)
+			} else {
+				startModuleConfiguration("balancer settings", c.clearBalancerSettings)
+			}
+		}
 	} else {
 		// The balancer module must be configured on Octopus
 		// It is a bit confusing but as of Octopus modules that are in the "always_on_modules" list
@@ -285,8 +357,8 @@ func (c *Cluster) enableCrashModule() error {
 func (c *Cluster) enableBalancerModule() error {
 	// The order MATTERS, always configure this module first, then turn it on
 
-	// This sets min compat client to luminous and the balancer module mode
-	err := client.ConfigureBalancerModule(c.context, c.Namespace, balancerModuleMode)
+	// This sets min compat client to luminous, the balancer module mode and the settings of the balancer spec
+	err := c.configureBalancer()
 	if err != nil {
 		return errors.Wrapf(err, "failed to configure module %q", balancerModuleName)
 	}
@@ -309,6 +381,9 @@ func (c *Cluster) configureMgrModules() error {
 		if wellKnownModule(module.Name) {
 			return errors.Errorf("cannot configure mgr module %q that is configured with other cluster settings", module.Name)
 		}
+		if module.Name == balancerModuleName && len(module.Settings) > 0 {
+			return errors.Errorf("cannot set settings of mgr module %q, they are configured with the balancer cluster settings", module.Name)
+		}
 		minVersion, versionOK := c.moduleMeetsMinVersion(module.Name)
 		if !versionOK {
 			return errors.Errorf("module %q cannot be configured because it requires at least Ceph version %q", module.Name, minVersion.String())
@@ -316,8 +391,8 @@ func (c *Cluster) configureMgrModules() error {
 
 		if module.Enabled {
 			if module.Name == balancerModuleName {
-				// Configure balancer module mode
-				err := client.ConfigureBalancerModule(c.context, c.Namespace, balancerModuleMode)
+				// Configure balancer module mode and settings
+				err := c.configureBalancer()
 				if err != nil {
 					return errors.Wrapf(err, "failed to configure module %q", module.Name)
 				}
@@ -348,7 +423,7 @@ func (c *Cluster) configureMgrModules() error {
 		}
 	}
 
-	return nil
+	return c.configureMgrModuleSettings()
 }
 
 func (c *Cluster) moduleMeetsMinVersion(name string) (*cephver.CephVersion, bool) {
@@ -403,6 +478,9 @@ func (c *Cluster) deployPrometheusRule(name, namespace string) error {
 	if err != nil {
 		return errors.Wrapf(err, "prometheus rule could not be deployed")
 	}
+	if err := customizePrometheusRule(prometheusRule, c.monitoringSpec); err != nil {
+		return errors.Wrapf(err, "prometheus rule could not be customized")
+	}
 	prometheusRule.SetName(name)
 	prometheusRule.SetNamespace(namespace)
 	owners := append(prometheusRule.GetOwnerReferences(), c.ownerRef)
@@ -68,6 +68,7 @@ func TestStartMGR(t *testing.T) {
 		cephv1.DashboardSpec{Enabled: true, SSL: true},
 		cephv1.MonitoringSpec{Enabled: true, RulesNamespace: ""},
 		cephv1.MgrSpec{},
+		cephv1.BalancerSpec{},
 		v1.ResourceRequirements{},
 		"my-priority-class",
 		metav1.OwnerReference{},
@@ -155,6 +156,9 @@ func TestConfigureModules(t *testing.T) {
 					configSettings[args[3]] = args[4]
 				}
 			}
+			if command == "ceph" && args[0] == "config" && args[1] == "get" && args[2] == "mgr.a" {
+				return "{}", nil
+			}
 			return "", nil //return "{\"key\":\"mysecurekey\"}", nil
 		},
 	}
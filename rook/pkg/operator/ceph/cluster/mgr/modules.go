@@ -0,0 +1,98 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+)
+
+// configureMgrModuleSettings sets the settings of the modules of the spec on each mgr. The settings are diffed
+// against the current config of the mgrs, only the settings that changed are set and the settings of the modules
+// that are not in the spec anymore are removed. The settings of the modules configured with other cluster settings,
+// such as the balancer, are left untouched.
+func (c *Cluster) configureMgrModuleSettings() error {
+	desired := map[string]string{}
+	for _, module := range c.mgrSpec.Modules {
+		for key, value := range module.Settings {
+			if key == "" || strings.Contains(key, "/") {
+				return errors.Errorf("invalid setting %q for mgr module %q", key, module.Name)
+			}
+			desired[moduleSettingKey(module.Name, key)] = value
+		}
+	}
+
+	monStore := config.GetMonStore(c.context, c.Namespace)
+	for _, daemonID := range c.getDaemonIDs() {
+		who := fmt.Sprintf("mgr.%s", daemonID)
+		options, err := monStore.GetDaemon(who)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the current config of %s", who)
+		}
+		current := map[string]string{}
+		for _, option := range options {
+			if module, ok := moduleOfSetting(option.Option); ok && !wellKnownModule(module) && module != balancerModuleName {
+				current[option.Option] = option.Value
+			}
+		}
+
+		keys := []string{}
+		for key := range desired {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if value, ok := current[key]; ok && value == desired[key] {
+				continue
+			}
+			logger.Infof("setting mgr module setting %q to %q on %s", key, desired[key], who)
+			if _, err := client.MgrSetConfig(c.context, c.Namespace, daemonID, key, desired[key], false); err != nil {
+				return errors.Wrapf(err, "failed to set mgr module setting %q on %s", key, who)
+			}
+		}
+
+		for key := range current {
+			if _, ok := desired[key]; ok {
+				continue
+			}
+			logger.Infof("removing stale mgr module setting %q from %s", key, who)
+			if _, err := client.MgrSetConfig(c.context, c.Namespace, daemonID, key, "", false); err != nil {
+				return errors.Wrapf(err, "failed to remove mgr module setting %q from %s", key, who)
+			}
+		}
+	}
+	return nil
+}
+
+// moduleSettingKey returns the config key of a module setting
+func moduleSettingKey(module, key string) string {
+	return fmt.Sprintf("mgr/%s/%s", module, key)
+}
+
+// moduleOfSetting returns the module of a config key if it is a module setting
+func moduleOfSetting(option string) (string, bool) {
+	parts := strings.SplitN(option, "/", 3)
+	if len(parts) != 3 || parts[0] != "mgr" || parts[1] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
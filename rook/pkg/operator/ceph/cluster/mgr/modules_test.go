@@ -0,0 +1,96 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigureMgrModuleSettings(t *testing.T) {
+	// the config of mgr.a in the mon store
+	store := map[string]string{
+		"mgr/dashboard/ssl":              "false",
+		"mgr/telemetry/contact":          "old@example.com",
+		"mgr/influx/hostname":            "influx",
+		"mon_warn_on_pool_no_redundancy": "false",
+	}
+	sets := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			if args[0] != "config" || args[2] != "mgr.a" {
+				return "", nil
+			}
+			switch args[1] {
+			case "get":
+				if !strings.HasPrefix(args[3], "--") {
+					return store[args[3]], nil
+				}
+				options := map[string]map[string]string{}
+				for key, value := range store {
+					options[key] = map[string]string{"section": "mgr.a", "value": value}
+				}
+				b, _ := json.Marshal(options)
+				return string(b), nil
+			case "set":
+				sets++
+				store[args[3]] = args[4]
+			case "rm":
+				delete(store, args[3])
+			}
+			return "", nil
+		},
+	}
+	c := &Cluster{context: &clusterd.Context{Executor: executor}, Namespace: "ns", Replicas: 1}
+	c.mgrSpec.Modules = []cephv1.Module{
+		{Name: "telemetry", Enabled: true, Settings: map[string]string{"contact": "admin@example.com", "channel_ident": "true"}},
+	}
+
+	// the settings are applied and the settings of the influx module that is not in the spec are removed
+	assert.NoError(t, c.configureMgrModuleSettings())
+	assert.Equal(t, 2, sets)
+	assert.Equal(t, map[string]string{
+		"mgr/dashboard/ssl":              "false",
+		"mgr/telemetry/contact":          "admin@example.com",
+		"mgr/telemetry/channel_ident":    "true",
+		"mon_warn_on_pool_no_redundancy": "false",
+	}, store)
+
+	// nothing is set when the settings did not change
+	sets = 0
+	assert.NoError(t, c.configureMgrModuleSettings())
+	assert.Equal(t, 0, sets)
+
+	// a setting removed from the spec is removed from the mgr
+	delete(c.mgrSpec.Modules[0].Settings, "channel_ident")
+	assert.NoError(t, c.configureMgrModuleSettings())
+	assert.Equal(t, 0, sets)
+	_, ok := store["mgr/telemetry/channel_ident"]
+	assert.False(t, ok)
+	assert.Equal(t, "admin@example.com", store["mgr/telemetry/contact"])
+
+	// invalid setting
+	c.mgrSpec.Modules[0].Settings = map[string]string{"a/b": "c"}
+	assert.Error(t, c.configureMgrModuleSettings())
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	orchestratorRequestAppName = "rook-ceph-orchestrator-request"
+	orchestratorRequestKey     = "request"
+	orchestratorStateKey       = "state"
+	orchestratorMessageKey     = "message"
+	requestCompleted           = "Completed"
+	requestFailed              = "Failed"
+	osdCreateAction            = "osd-create"
+	devPrefix                  = "/dev/"
+	diskByPrefix               = "/dev/disk/"
+)
+
+// OrchestratorRequest is a request of the orchestrator in a ConfigMap labeled app=rook-ceph-orchestrator-request
+type OrchestratorRequest struct {
+	// Action is the requested action, only osd-create is supported
+	Action string `json:"action"`
+	// Host is the name of the node of the OSDs
+	Host string `json:"host"`
+	// Devices are the devices of the OSDs, such as /dev/sdb or /dev/disk/by-id/<id>
+	Devices []string `json:"devices"`
+}
+
+// processRequests applies the pending orchestrator requests in the order they were created and records their result
+// in their ConfigMap. The devices of the inventory are used to reject the devices that are not available.
+func (o *OrchestratorInventory) processRequests(inventory map[string][]InventoryDevice) error {
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.AppAttr, orchestratorRequestAppName)}
+	configMaps := o.context.Clientset.CoreV1().ConfigMaps(o.namespace)
+	cms, err := configMaps.List(listOpts)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the orchestrator requests")
+	}
+	sort.Slice(cms.Items, func(i, j int) bool {
+		return cms.Items[i].CreationTimestamp.Before(&cms.Items[j].CreationTimestamp)
+	})
+
+	for i := range cms.Items {
+		cm := &cms.Items[i]
+		if cm.Data[orchestratorStateKey] != "" {
+			continue
+		}
+		state, message := requestCompleted, ""
+		if err := o.processRequest(cm.Data[orchestratorRequestKey], inventory); err != nil {
+			logger.Errorf("failed orchestrator request %q. %v", cm.Name, err)
+			state, message = requestFailed, err.Error()
+		} else {
+			logger.Infof("orchestrator request %q completed", cm.Name)
+			message = "the storage spec of the cluster was updated"
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[orchestratorStateKey] = state
+		cm.Data[orchestratorMessageKey] = message
+		if _, err := configMaps.Update(cm); err != nil {
+			return errors.Wrapf(err, "failed to record the result of orchestrator request %q", cm.Name)
+		}
+	}
+	return nil
+}
+
+// processRequest applies an orchestrator request to the CephCluster
+func (o *OrchestratorInventory) processRequest(data string, inventory map[string][]InventoryDevice) error {
+	var request OrchestratorRequest
+	if err := json.Unmarshal([]byte(data), &request); err != nil {
+		return errors.Wrapf(err, "failed to parse the request")
+	}
+	if request.Action != osdCreateAction {
+		return errors.Errorf("unsupported action %q", request.Action)
+	}
+	if err := checkDevicesAvailable(request.Host, request.Devices, inventory); err != nil {
+		return err
+	}
+	if _, err := o.context.Clientset.CoreV1().Nodes().Get(request.Host, metav1.GetOptions{}); err != nil {
+		return errors.Wrapf(err, "failed to get node %q", request.Host)
+	}
+
+	cluster, err := o.context.RookClientset.CephV1().CephClusters(o.namespace).Get(o.clusterName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get cluster %q", o.clusterName)
+	}
+	changed, err := addOSDDevices(&cluster.Spec.Storage, request.Host, request.Devices)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+	logger.Infof("adding osd devices %v of node %q to the storage spec of cluster %q", request.Devices, request.Host, o.clusterName)
+	if _, err := o.context.RookClientset.CephV1().CephClusters(o.namespace).Update(cluster); err != nil {
+		return errors.Wrapf(err, "failed to update the storage spec of cluster %q", o.clusterName)
+	}
+	return nil
+}
+
+// checkDevicesAvailable returns an error if a device of the request is in the inventory but not available. The
+// devices are not checked when the node is not in the inventory since the discovery daemon may not run.
+func checkDevicesAvailable(host string, devices []string, inventory map[string][]InventoryDevice) error {
+	nodeDevices, ok := inventory[host]
+	if !ok {
+		return nil
+	}
+	for _, device := range devices {
+		for _, d := range nodeDevices {
+			if d.Path != device && !contains(d.DevLinks, device) {
+				continue
+			}
+			if !d.Available {
+				return errors.Errorf("device %q of node %q is not available: %s", device, host, strings.Join(d.RejectedReasons, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// addOSDDevices adds the devices of a node to the storage spec. Returns whether the spec changed.
+func addOSDDevices(storage *rookv1.StorageScopeSpec, host string, devices []string) (bool, error) {
+	if host == "" || len(devices) == 0 {
+		return false, errors.New("the host and the devices of the osds are required")
+	}
+	if storage.UseAllNodes {
+		return false, errors.New("cannot add devices to a node of the storage spec when all the nodes are used")
+	}
+
+	index := -1
+	for i := range storage.Nodes {
+		if storage.Nodes[i].Name == host {
+			index = i
+			break
+		}
+	}
+	changed := false
+	if index < 0 {
+		storage.Nodes = append(storage.Nodes, rookv1.Node{Name: host})
+		index = len(storage.Nodes) - 1
+		changed = true
+	}
+	node := &storage.Nodes[index]
+	if node.UseAllDevices != nil && *node.UseAllDevices {
+		return false, errors.Errorf("cannot add devices to node %q of the storage spec when all its devices are used", host)
+	}
+
+	for _, path := range devices {
+		device, err := toStorageDevice(path)
+		if err != nil {
+			return false, err
+		}
+		found := false
+		for _, d := range node.Devices {
+			if (device.Name != "" && d.Name == device.Name) || (device.FullPath != "" && d.FullPath == device.FullPath) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			node.Devices = append(node.Devices, device)
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// toStorageDevice converts the path of a device to a device of the storage spec. The persistent paths are kept as
+// full paths and the other paths are converted to device names.
+func toStorageDevice(path string) (rookv1.Device, error) {
+	switch {
+	case strings.HasPrefix(path, diskByPrefix):
+		return rookv1.Device{FullPath: path}, nil
+	case strings.HasPrefix(path, devPrefix) && len(path) > len(devPrefix):
+		return rookv1.Device{Name: strings.TrimPrefix(path, devPrefix)}, nil
+	case path != "" && !strings.Contains(path, "/"):
+		return rookv1.Device{Name: path}, nil
+	}
+	return rookv1.Device{}, errors.Errorf("invalid device %q", path)
+}
+
+// contains returns whether a list of strings contains a string
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
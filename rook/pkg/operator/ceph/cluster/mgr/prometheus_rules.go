@@ -0,0 +1,169 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	osdNearFullAlert       = "CephOSDNearFull"
+	osdCriticallyFullAlert = "CephOSDCriticallyFull"
+	monQuorumAtRiskAlert   = "CephMonQuorumAtRisk"
+	osdsDownAlert          = "CephOSDsDown"
+	osdAlertGroup          = "osd-alert.rules"
+)
+
+var (
+	// the comparison with the threshold at the end of the expression of an alert
+	thresholdExpr = regexp.MustCompile(`>=\s*[0-9.]+\s*$`)
+	// the percentage in the description of an alert
+	percentDescription = regexp.MustCompile(`[0-9]+%`)
+	// a prometheus duration
+	prometheusDuration = regexp.MustCompile(`^([0-9]+(ms|s|m|h|d|w|y))+$`)
+)
+
+// customizePrometheusRule applies the alert thresholds, the disabled alerts and the extra labels and annotations of
+// the monitoring spec to the rules
+func customizePrometheusRule(rule *monitoringv1.PrometheusRule, spec cephv1.MonitoringSpec) error {
+	thresholds := spec.AlertThresholds
+	disabled := map[string]bool{}
+	for _, alert := range spec.DisabledAlerts {
+		disabled[alert] = true
+	}
+	found := map[string]bool{}
+
+	if thresholds.OSDDownCount > 0 {
+		addOSDsDownAlert(rule, thresholds.OSDDownCount)
+	}
+
+	groups := []monitoringv1.RuleGroup{}
+	for _, group := range rule.Spec.Groups {
+		rules := []monitoringv1.Rule{}
+		for _, r := range group.Rules {
+			if r.Alert == "" {
+				// recording rule
+				rules = append(rules, r)
+				continue
+			}
+			found[r.Alert] = true
+			if disabled[r.Alert] {
+				logger.Infof("prometheus alert %q is disabled", r.Alert)
+				continue
+			}
+
+			var err error
+			switch r.Alert {
+			case osdNearFullAlert:
+				err = setPercentThreshold(&r, thresholds.OSDNearFullPercent)
+			case osdCriticallyFullAlert:
+				err = setPercentThreshold(&r, thresholds.OSDCriticallyFullPercent)
+			case monQuorumAtRiskAlert:
+				if thresholds.MonQuorumLossDuration != "" {
+					if !prometheusDuration.MatchString(thresholds.MonQuorumLossDuration) {
+						err = errors.Errorf("invalid mon quorum loss duration %q", thresholds.MonQuorumLossDuration)
+					}
+					r.For = thresholds.MonQuorumLossDuration
+				}
+			}
+			if err != nil {
+				return errors.Wrapf(err, "failed to set the threshold of alert %q", r.Alert)
+			}
+
+			r.Labels = mergeMaps(r.Labels, spec.AlertLabels)
+			r.Annotations = mergeMaps(r.Annotations, spec.AlertAnnotations)
+			rules = append(rules, r)
+		}
+		if len(rules) > 0 {
+			group.Rules = rules
+			groups = append(groups, group)
+		}
+	}
+	rule.Spec.Groups = groups
+
+	for _, alert := range spec.DisabledAlerts {
+		if !found[alert] {
+			logger.Warningf("cannot disable unknown prometheus alert %q", alert)
+		}
+	}
+	return nil
+}
+
+// setPercentThreshold sets the utilization percentage from which an alert fires in its expression and description
+func setPercentThreshold(r *monitoringv1.Rule, percent int) error {
+	if percent == 0 {
+		return nil
+	}
+	if percent < 0 || percent > 100 {
+		return errors.Errorf("invalid percentage %d", percent)
+	}
+	expr := r.Expr.String()
+	if !thresholdExpr.MatchString(expr) {
+		return errors.Errorf("no threshold in expression %q", expr)
+	}
+	ratio := strconv.FormatFloat(float64(percent)/100, 'f', -1, 64)
+	r.Expr = intstr.FromString(thresholdExpr.ReplaceAllString(expr, ">= "+ratio))
+	if description, ok := r.Annotations["description"]; ok {
+		r.Annotations["description"] = percentDescription.ReplaceAllString(description, fmt.Sprintf("%d%%", percent))
+	}
+	return nil
+}
+
+// addOSDsDownAlert adds the alert that fires when the number of down OSDs reaches the threshold
+func addOSDsDownAlert(rule *monitoringv1.PrometheusRule, count int) {
+	alert := monitoringv1.Rule{
+		Alert: osdsDownAlert,
+		Annotations: map[string]string{
+			"description":    fmt.Sprintf("{{ $value }} OSDs are down, the threshold is %d. Check the OSDs and their disks.", count),
+			"message":        "Several OSDs are down",
+			"severity_level": "error",
+			"storage_type":   "ceph",
+		},
+		Expr:   intstr.FromString(fmt.Sprintf("count(ceph_osd_up == 0) >= %d\n", count)),
+		For:    "1m",
+		Labels: map[string]string{"severity": "critical"},
+	}
+	for i := range rule.Spec.Groups {
+		if rule.Spec.Groups[i].Name == osdAlertGroup {
+			rule.Spec.Groups[i].Rules = append(rule.Spec.Groups[i].Rules, alert)
+			return
+		}
+	}
+	rule.Spec.Groups = append(rule.Spec.Groups, monitoringv1.RuleGroup{Name: osdAlertGroup, Rules: []monitoringv1.Rule{alert}})
+}
+
+// mergeMaps returns the entries of base overridden by the entries of overrides
+func mergeMaps(base, overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return base
+	}
+	merged := map[string]string{}
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"path"
+	"runtime"
+	"testing"
+
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadPrometheusRule(t *testing.T) *monitoringv1.PrometheusRule {
+	_, filename, _, _ := runtime.Caller(0)
+	rulesFile := path.Join(path.Dir(filename), "../../../../../cluster/examples/kubernetes/ceph/monitoring/prometheus-ceph-v14-rules.yaml")
+	rule, err := k8sutil.GetPrometheusRule(rulesFile)
+	require.NoError(t, err)
+	return rule
+}
+
+func findAlert(rule *monitoringv1.PrometheusRule, name string) *monitoringv1.Rule {
+	for _, group := range rule.Spec.Groups {
+		for i := range group.Rules {
+			if group.Rules[i].Alert == name {
+				return &group.Rules[i]
+			}
+		}
+	}
+	return nil
+}
+
+func TestCustomizePrometheusRule(t *testing.T) {
+	// the rules are unchanged by default
+	rule := loadPrometheusRule(t)
+	original := loadPrometheusRule(t)
+	assert.NoError(t, customizePrometheusRule(rule, cephv1.MonitoringSpec{Enabled: true}))
+	assert.Equal(t, original, rule)
+	assert.Nil(t, findAlert(rule, osdsDownAlert))
+
+	spec := cephv1.MonitoringSpec{
+		Enabled: true,
+		AlertThresholds: cephv1.AlertThresholdsSpec{
+			OSDNearFullPercent:       80,
+			OSDCriticallyFullPercent: 90,
+			MonQuorumLossDuration:    "5m",
+			OSDDownCount:             2,
+		},
+		DisabledAlerts:   []string{"CephNodeDown", "CephMgrIsAbsent", "UnknownAlert"},
+		AlertLabels:      map[string]string{"team": "storage", "severity": "page"},
+		AlertAnnotations: map[string]string{"runbook_url": "https://runbooks.example.com"},
+	}
+	assert.NoError(t, customizePrometheusRule(rule, spec))
+
+	nearFull := findAlert(rule, osdNearFullAlert)
+	require.NotNil(t, nearFull)
+	assert.Contains(t, nearFull.Expr.String(), ">= 0.8")
+	assert.NotContains(t, nearFull.Expr.String(), "0.75")
+	assert.Contains(t, nearFull.Annotations["description"], "crossed 80%")
+	criticallyFull := findAlert(rule, osdCriticallyFullAlert)
+	require.NotNil(t, criticallyFull)
+	assert.Contains(t, criticallyFull.Expr.String(), ">= 0.9")
+	assert.Equal(t, "5m", findAlert(rule, monQuorumAtRiskAlert).For)
+
+	osdsDown := findAlert(rule, osdsDownAlert)
+	require.NotNil(t, osdsDown)
+	assert.Contains(t, osdsDown.Expr.String(), ">= 2")
+
+	// the disabled alerts and the groups left without rules are removed
+	assert.Nil(t, findAlert(rule, "CephNodeDown"))
+	assert.Nil(t, findAlert(rule, "CephMgrIsAbsent"))
+	for _, group := range rule.Spec.Groups {
+		assert.NotEqual(t, 0, len(group.Rules))
+		assert.NotEqual(t, "ceph-node-alert.rules", group.Name)
+	}
+
+	// the labels and annotations are added to the alerts only
+	assert.Equal(t, "storage", osdsDown.Labels["team"])
+	assert.Equal(t, "page", criticallyFull.Labels["severity"])
+	assert.Equal(t, "https://runbooks.example.com", nearFull.Annotations["runbook_url"])
+	assert.Equal(t, "ceph", nearFull.Annotations["storage_type"])
+	for _, group := range rule.Spec.Groups {
+		for _, r := range group.Rules {
+			if r.Record != "" {
+				assert.Equal(t, "", r.Labels["team"])
+			}
+		}
+	}
+
+	// invalid thresholds
+	rule = loadPrometheusRule(t)
+	assert.Error(t, customizePrometheusRule(rule, cephv1.MonitoringSpec{AlertThresholds: cephv1.AlertThresholdsSpec{OSDNearFullPercent: 101}}))
+	rule = loadPrometheusRule(t)
+	assert.Error(t, customizePrometheusRule(rule, cephv1.MonitoringSpec{AlertThresholds: cephv1.AlertThresholdsSpec{MonQuorumLossDuration: "5 minutes"}}))
+}
@@ -263,7 +263,7 @@ func (c *Cluster) makeMetricsService(name string) *v1.Service {
 			Labels:    labels,
 		},
 		Spec: v1.ServiceSpec{
-			Selector: labels,
+			Selector: c.serviceSelector(),
 			Type:     v1.ServiceTypeClusterIP,
 			Ports: []v1.ServicePort{
 				{
@@ -292,7 +292,7 @@ func (c *Cluster) makeDashboardService(name string) *v1.Service {
 			Labels:    labels,
 		},
 		Spec: v1.ServiceSpec{
-			Selector: labels,
+			Selector: c.serviceSelector(),
 			Type:     v1.ServiceTypeClusterIP,
 			Ports: []v1.ServicePort{
 				{
@@ -307,6 +307,15 @@ func (c *Cluster) makeDashboardService(name string) *v1.Service {
 	return svc
 }
 
+// serviceSelector returns the selector of the mgr services. When several mgrs run, only the active mgr is selected.
+func (c *Cluster) serviceSelector() map[string]string {
+	selector := controller.AppLabels(AppName, c.Namespace)
+	if c.Replicas > 1 {
+		selector[mgrRoleLabelKey] = activeMgrRole
+	}
+	return selector
+}
+
 func (c *Cluster) getPodLabels(daemonName string) map[string]string {
 	labels := controller.PodLabels(AppName, c.Namespace, "mgr", daemonName)
 	// leave "instance" key for legacy usage
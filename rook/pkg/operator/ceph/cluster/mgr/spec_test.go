@@ -48,6 +48,7 @@ func TestPodSpec(t *testing.T) {
 		cephv1.DashboardSpec{Port: 1234},
 		cephv1.MonitoringSpec{},
 		cephv1.MgrSpec{},
+		cephv1.BalancerSpec{},
 		v1.ResourceRequirements{
 			Limits: v1.ResourceList{
 				v1.ResourceCPU:    *resource.NewQuantity(200.0, resource.BinarySI),
@@ -102,6 +103,7 @@ func TestServiceSpec(t *testing.T) {
 		cephv1.DashboardSpec{},
 		cephv1.MonitoringSpec{},
 		cephv1.MgrSpec{},
+		cephv1.BalancerSpec{},
 		v1.ResourceRequirements{},
 		"my-priority-class",
 		metav1.OwnerReference{},
@@ -130,6 +132,7 @@ func TestHostNetwork(t *testing.T) {
 		cephv1.DashboardSpec{Port: 1234},
 		cephv1.MonitoringSpec{},
 		cephv1.MgrSpec{},
+		cephv1.BalancerSpec{},
 		v1.ResourceRequirements{},
 		"my-priority-class",
 		metav1.OwnerReference{},
@@ -165,6 +168,7 @@ func TestHttpBindFix(t *testing.T) {
 		cephv1.DashboardSpec{Port: 1234},
 		cephv1.MonitoringSpec{},
 		cephv1.MgrSpec{},
+		cephv1.BalancerSpec{},
 		v1.ResourceRequirements{},
 		"my-priority-class",
 		metav1.OwnerReference{},
@@ -201,6 +205,7 @@ func TestApplyPrometheusAnnotations(t *testing.T) {
 		cephv1.DashboardSpec{},
 		cephv1.MonitoringSpec{},
 		cephv1.MgrSpec{},
+		cephv1.BalancerSpec{},
 		v1.ResourceRequirements{},
 		"my-priority-class",
 		metav1.OwnerReference{},
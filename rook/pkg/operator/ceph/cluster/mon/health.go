@@ -28,6 +28,7 @@ import (
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	cephutil "github.com/rook/rook/pkg/daemon/ceph/util"
 	"github.com/rook/rook/pkg/operator/ceph/controller"
+	opmetrics "github.com/rook/rook/pkg/operator/ceph/metrics"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -281,6 +282,7 @@ func (c *Cluster) failoverMon(name string) error {
 
 	// Only increment the max mon id if the new pod started successfully
 	c.maxMonID++
+	opmetrics.IncMonFailovers(c.Namespace)
 
 	return c.removeMon(name)
 }
@@ -45,6 +45,8 @@ const (
 	EncryptedDeviceKey = "encryptedDevice"
 	MetadataDeviceKey  = "metadataDevice"
 	DeviceClassKey     = "deviceClass"
+	// MigrateToBluestoreKey opts in the migration of the filestore OSDs to bluestore
+	MigrateToBluestoreKey = "migrateToBluestore"
 )
 
 type StoreConfig struct {
@@ -78,6 +80,21 @@ func ToStoreConfig(config map[string]string) StoreConfig {
 	return storeConfig
 }
 
+// CephOptions returns the entries of the config that are not Rook settings, they are Ceph config options to set on
+// the OSDs
+func CephOptions(config map[string]string) map[string]string {
+	options := map[string]string{}
+	for k, v := range config {
+		switch k {
+		case StoreTypeKey, WalSizeMBKey, DatabaseSizeMBKey, JournalSizeMBKey, OSDsPerDeviceKey, EncryptedDeviceKey,
+			MetadataDeviceKey, DeviceClassKey, MigrateToBluestoreKey:
+			continue
+		}
+		options[k] = v
+	}
+	return options
+}
+
 func MetadataDevice(config map[string]string) string {
 	for k, v := range config {
 		switch k {
@@ -89,6 +106,11 @@ func MetadataDevice(config map[string]string) string {
 	return ""
 }
 
+// MigrateToBluestore returns whether the filestore OSDs must be migrated to bluestore
+func MigrateToBluestore(config map[string]string) bool {
+	return config[MigrateToBluestoreKey] == "true"
+}
+
 func convertToIntIgnoreErr(raw string) int {
 	val, err := strconv.Atoi(raw)
 	if err != nil {
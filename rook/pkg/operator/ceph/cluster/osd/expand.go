@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/util"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	// how long to wait for the storage provider to resize a PVC
+	pvcResizeRetries  = 30
+	pvcResizeInterval = 10 * time.Second
+	// how long to wait for ceph to report the new capacity of an expanded OSD
+	osdCapacityRetries  = 20
+	osdCapacityInterval = 15 * time.Second
+)
+
+// pvcExpansionRequested returns whether the data PVC size desired for the OSD is larger than the size
+// the running OSD deployment was started with
+func pvcExpansionRequested(d *apps.Deployment, osdProps osdProperties) bool {
+	if !osdProps.onPVC() || osdProps.pvcSize == "" || len(d.Spec.Template.Spec.Containers) == 0 {
+		return false
+	}
+
+	desiredSize, err := resource.ParseQuantity(osdProps.pvcSize)
+	if err != nil {
+		logger.Warningf("failed to parse desired size %q of pvc %q. %v", osdProps.pvcSize, osdProps.pvc.ClaimName, err)
+		return false
+	}
+
+	for _, envVar := range d.Spec.Template.Spec.Containers[0].Env {
+		if envVar.Name != osdPVCSizeVarName {
+			continue
+		}
+		currentSize, err := resource.ParseQuantity(envVar.Value)
+		if err != nil {
+			logger.Warningf("failed to parse current size %q of pvc %q. %v", envVar.Value, osdProps.pvc.ClaimName, err)
+			return false
+		}
+		return desiredSize.Cmp(currentSize) > 0
+	}
+
+	// deployments created before the size was tracked are expanded by the init container on their next restart
+	return false
+}
+
+// isPVCResized returns whether the storage provider completed the resize of the PVC. A pending file system resize
+// is considered complete since the node side of the expansion only happens when the OSD pod is restarted.
+func isPVCResized(pvc *v1.PersistentVolumeClaim, desiredSize resource.Quantity) bool {
+	for _, condition := range pvc.Status.Conditions {
+		if condition.Type == v1.PersistentVolumeClaimFileSystemResizePending && condition.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+
+	capacity, ok := pvc.Status.Capacity[v1.ResourceStorage]
+	if !ok {
+		return false
+	}
+	return capacity.Cmp(desiredSize) >= 0
+}
+
+// waitForPVCResize waits for the PVC backing the OSD to be resized to the size requested in the spec
+func (c *Cluster) waitForPVCResize(osdProps osdProperties) error {
+	desiredSize, err := resource.ParseQuantity(osdProps.pvcSize)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse desired size %q of pvc %q", osdProps.pvcSize, osdProps.pvc.ClaimName)
+	}
+
+	logger.Infof("waiting for pvc %q to be resized to %s", osdProps.pvc.ClaimName, desiredSize.String())
+	return util.Retry(pvcResizeRetries, pvcResizeInterval, func() error {
+		pvc, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(osdProps.pvc.ClaimName, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to get pvc %q", osdProps.pvc.ClaimName)
+		}
+		if !isPVCResized(pvc, desiredSize) {
+			return errors.Errorf("pvc %q is not resized to %s yet", pvc.Name, desiredSize.String())
+		}
+		return nil
+	})
+}
+
+// getOSDCapacityKB returns the capacity of the OSD as reported by ceph
+func (c *Cluster) getOSDCapacityKB(osdID int) (int64, error) {
+	usage, err := client.GetOSDUsage(c.context, c.Namespace)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get osd usage")
+	}
+
+	for _, node := range usage.OSDNodes {
+		if node.ID == osdID {
+			kb, err := node.KB.Int64()
+			if err != nil {
+				return 0, errors.Wrapf(err, "failed to parse capacity of osd.%d", osdID)
+			}
+			return kb, nil
+		}
+	}
+
+	return 0, errors.Errorf("osd.%d not found in osd usage", osdID)
+}
+
+// waitForOSDExpansion waits for ceph to report a capacity larger than the previous capacity of the OSD
+func (c *Cluster) waitForOSDExpansion(osdID int, previousKB int64) error {
+	return util.Retry(osdCapacityRetries, osdCapacityInterval, func() error {
+		kb, err := c.getOSDCapacityKB(osdID)
+		if err != nil {
+			return err
+		}
+		if kb <= previousKB {
+			return errors.Errorf("osd.%d capacity is still %d KB", osdID, kb)
+		}
+		logger.Infof("osd.%d capacity expanded from %d KB to %d KB", osdID, previousKB, kb)
+		return nil
+	})
+}
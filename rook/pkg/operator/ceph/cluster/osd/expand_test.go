@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	testexec "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPVCExpansionRequested(t *testing.T) {
+	d := &apps.Deployment{}
+	d.Spec.Template.Spec.Containers = []v1.Container{{Env: []v1.EnvVar{{Name: osdPVCSizeVarName, Value: "10Gi"}}}}
+	osdProps := osdProperties{
+		pvc:     v1.PersistentVolumeClaimVolumeSource{ClaimName: "set1-data-0"},
+		pvcSize: "10Gi",
+	}
+
+	// same size
+	assert.False(t, pvcExpansionRequested(d, osdProps))
+
+	// smaller size
+	osdProps.pvcSize = "5Gi"
+	assert.False(t, pvcExpansionRequested(d, osdProps))
+
+	// larger size
+	osdProps.pvcSize = "20Gi"
+	assert.True(t, pvcExpansionRequested(d, osdProps))
+
+	// not on pvc
+	assert.False(t, pvcExpansionRequested(d, osdProperties{pvcSize: "20Gi"}))
+
+	// size not tracked on the deployment
+	d.Spec.Template.Spec.Containers[0].Env = []v1.EnvVar{}
+	assert.False(t, pvcExpansionRequested(d, osdProps))
+}
+
+func TestIsPVCResized(t *testing.T) {
+	desired := resource.MustParse("20Gi")
+	pvc := &v1.PersistentVolumeClaim{}
+	assert.False(t, isPVCResized(pvc, desired))
+
+	pvc.Status.Capacity = v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")}
+	assert.False(t, isPVCResized(pvc, desired))
+
+	pvc.Status.Conditions = []v1.PersistentVolumeClaimCondition{
+		{Type: v1.PersistentVolumeClaimFileSystemResizePending, Status: v1.ConditionTrue},
+	}
+	assert.True(t, isPVCResized(pvc, desired))
+
+	pvc.Status.Conditions = nil
+	pvc.Status.Capacity[v1.ResourceStorage] = resource.MustParse("20Gi")
+	assert.True(t, isPVCResized(pvc, desired))
+}
+
+func TestWaitForOSDExpansion(t *testing.T) {
+	defer func(retries int, interval time.Duration) {
+		osdCapacityRetries = retries
+		osdCapacityInterval = interval
+	}(osdCapacityRetries, osdCapacityInterval)
+	osdCapacityRetries = 1
+	osdCapacityInterval = time.Millisecond
+	capacity := "10485760"
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "df" {
+				return `{"nodes":[{"id":0,"name":"osd.0","kb":` + capacity + `}],"summary":{}}`, nil
+			}
+			return "", nil
+		},
+	}
+	c := &Cluster{context: &clusterd.Context{Executor: executor, Clientset: testexec.New(t, 1)}, Namespace: "ns"}
+
+	kb, err := c.getOSDCapacityKB(0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10485760), kb)
+
+	_, err = c.getOSDCapacityKB(1)
+	assert.Error(t, err)
+
+	// the capacity did not grow
+	err = c.waitForOSDExpansion(0, kb)
+	assert.Error(t, err)
+
+	// the capacity grew
+	capacity = "20971520"
+	err = c.waitForOSDExpansion(0, kb)
+	assert.NoError(t, err)
+}
+
+func TestWaitForPVCResize(t *testing.T) {
+	defer func(retries int, interval time.Duration) {
+		pvcResizeRetries = retries
+		pvcResizeInterval = interval
+	}(pvcResizeRetries, pvcResizeInterval)
+	pvcResizeRetries = 1
+	pvcResizeInterval = time.Millisecond
+	clientset := testexec.New(t, 1)
+	c := &Cluster{context: &clusterd.Context{Clientset: clientset}, Namespace: "ns"}
+	pvc := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "set1-data-0", Namespace: "ns"}}
+	pvc.Status.Capacity = v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")}
+	_, err := clientset.CoreV1().PersistentVolumeClaims("ns").Create(pvc)
+	assert.NoError(t, err)
+
+	osdProps := osdProperties{pvc: v1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.Name}, pvcSize: "20Gi"}
+	assert.Error(t, c.waitForPVCResize(osdProps))
+
+	pvc.Status.Capacity[v1.ResourceStorage] = resource.MustParse("20Gi")
+	_, err = clientset.CoreV1().PersistentVolumeClaims("ns").Update(pvc)
+	assert.NoError(t, err)
+	assert.NoError(t, c.waitForPVCResize(osdProps))
+}
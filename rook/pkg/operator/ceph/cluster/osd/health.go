@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
@@ -43,13 +44,31 @@ var (
 type OSDHealthMonitor struct {
 	context                        *clusterd.Context
 	namespace                      string
+	clusterName                    string
 	removeOSDsIfOUTAndSafeToRemove bool
 	cephVersion                    cephver.CephVersion
+	healthCheck                    cephv1.OSDHealthCheckSpec
+	osdTransitions                 map[int]osdTransitions
+	crashLoopingSince              map[int]time.Time
+	unhealthyOSDs                  map[int]unhealthyOSD
+	// remediatedOSDs are the OSDs marked out or noup by the monitor, loaded from the CephCluster status
+	remediatedOSDs map[int]bool
 }
 
 // NewOSDHealthMonitor instantiates OSD monitoring
-func NewOSDHealthMonitor(context *clusterd.Context, namespace string, removeOSDsIfOUTAndSafeToRemove bool, cephVersion cephver.CephVersion) *OSDHealthMonitor {
-	return &OSDHealthMonitor{context, namespace, removeOSDsIfOUTAndSafeToRemove, cephVersion}
+func NewOSDHealthMonitor(context *clusterd.Context, namespace, clusterName string, removeOSDsIfOUTAndSafeToRemove bool, cephVersion cephver.CephVersion,
+	healthCheck cephv1.OSDHealthCheckSpec) *OSDHealthMonitor {
+	return &OSDHealthMonitor{
+		context:                        context,
+		namespace:                      namespace,
+		clusterName:                    clusterName,
+		removeOSDsIfOUTAndSafeToRemove: removeOSDsIfOUTAndSafeToRemove,
+		cephVersion:                    cephVersion,
+		healthCheck:                    healthCheck,
+		osdTransitions:                 map[int]osdTransitions{},
+		crashLoopingSince:              map[int]time.Time{},
+		unhealthyOSDs:                  map[int]unhealthyOSD{},
+	}
 }
 
 // Start runs monitoring logic for osds status at set intervals
@@ -71,9 +90,10 @@ func (m *OSDHealthMonitor) Start(stopCh chan struct{}) {
 	}
 }
 
-// Update updates the removeOSDsIfOUTAndSafeToRemove
-func (m *OSDHealthMonitor) Update(removeOSDsIfOUTAndSafeToRemove bool) {
+// Update updates the removeOSDsIfOUTAndSafeToRemove and the health check policy
+func (m *OSDHealthMonitor) Update(removeOSDsIfOUTAndSafeToRemove bool, healthCheck cephv1.OSDHealthCheckSpec) {
 	m.removeOSDsIfOUTAndSafeToRemove = removeOSDsIfOUTAndSafeToRemove
+	m.healthCheck = healthCheck
 }
 
 // checkOSDHealth takes action when needed if the OSDs are not healthy
@@ -83,6 +103,8 @@ func (m *OSDHealthMonitor) checkOSDHealth() error {
 		return err
 	}
 
+	m.checkUnhealthyOSDs(osdDump)
+
 	for _, osdStatus := range osdDump.OSDs {
 		id64, err := osdStatus.OSD.Int64()
 		if err != nil {
@@ -111,6 +133,11 @@ func (m *OSDHealthMonitor) checkOSDHealth() error {
 
 		if in != inStatus {
 			logger.Debugf("osd.%d is marked 'OUT'", id)
+			if m.remediatedOSDs[id] {
+				// the unhealthy osd was marked out to recover its data, it is kept until an admin handles it
+				logger.Debugf("osd.%d was marked out by the osd health check and is not removed", id)
+				continue
+			}
 			if m.removeOSDsIfOUTAndSafeToRemove {
 				if err := m.removeOSDDeploymentIfSafeToDestroy(id); err != nil {
 					logger.Errorf("error handling marked out osd osd.%d. %v", id, err)
@@ -20,6 +20,7 @@ import (
 	"testing"
 	"time"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
@@ -93,7 +94,7 @@ func TestOSDHealthCheck(t *testing.T) {
 	}
 
 	// Initializing an OSD monitoring
-	osdMon := NewOSDHealthMonitor(context, cluster, true, cephVersion)
+	osdMon := NewOSDHealthMonitor(context, cluster, "", true, cephVersion, cephv1.OSDHealthCheckSpec{})
 
 	// Run OSD monitoring routine
 	err := osdMon.checkOSDHealth()
@@ -112,7 +113,7 @@ func TestMonitorStart(t *testing.T) {
 	}
 
 	stopCh := make(chan struct{})
-	osdMon := NewOSDHealthMonitor(&clusterd.Context{}, "cluster", true, cephVersion)
+	osdMon := NewOSDHealthMonitor(&clusterd.Context{}, "cluster", "", true, cephVersion, cephv1.OSDHealthCheckSpec{})
 	logger.Infof("starting osd monitor")
 	go osdMon.Start(stopCh)
 	close(stopCh)
@@ -140,7 +141,7 @@ func TestOSDRestartIfStuck(t *testing.T) {
 	_, err := context.Clientset.CoreV1().Pods(namespace).Create(&pod)
 	assert.NoError(t, err)
 
-	m := NewOSDHealthMonitor(context, namespace, false, cephver.CephVersion{})
+	m := NewOSDHealthMonitor(context, namespace, "", false, cephver.CephVersion{}, cephv1.OSDHealthCheckSpec{})
 
 	assert.NoError(t, k8sutil.ForceDeletePodIfStuck(m.context, pod))
 
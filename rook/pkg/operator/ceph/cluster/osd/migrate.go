@@ -0,0 +1,389 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	osdconfig "github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	"github.com/rook/rook/pkg/util"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	filestoreObjectStore         = "filestore"
+	migrationPhaseDraining       = "Draining"
+	migrationPhaseReprovisioning = "Reprovisioning"
+	migrationPhaseRecovering     = "Recovering"
+	migrationPhaseCompleted      = "Completed"
+	migrationPhaseIncomplete     = "Incomplete"
+	migrationPhaseFailed         = "Failed"
+	migrateOSDIDsEnvVarName      = "ROOK_MIGRATE_OSD_IDS"
+	migrationNotPreparedHintFmt  = "osd.%d was not prepared again with bluestore, check the logs of the osd prepare job of its node"
+	migrationUnsupportedHintFmt  = "filestore osds %v are directory based or were created before ceph-volume and cannot be migrated to bluestore, they must be replaced by new osds"
+)
+
+var (
+	// how long to wait for the drained OSDs to be safe to destroy, and for the migrated OSDs to recover
+	migrationCleanRetries  = 60
+	migrationCleanInterval = 10 * time.Second
+)
+
+// migrateToBluestore returns whether the filestore OSDs must be migrated to bluestore
+func (c *Cluster) migrateToBluestore() bool {
+	return osdconfig.MigrateToBluestore(c.DesiredStorage.Config)
+}
+
+// startProvisioningOverNodesWithMigration provisions the OSDs on the nodes. When the migration to bluestore is
+// enabled, the OSDs of one failure domain are destroyed and prepared again with bluestore by the prepare jobs, keeping
+// their IDs. The next failure domain is migrated by the next orchestration, see MigrationPending.
+func (c *Cluster) startProvisioningOverNodesWithMigration(config *provisionConfig) {
+	c.prepareBluestoreMigration(config)
+
+	c.startProvisioningOverNodes(config)
+
+	c.migrationPending = c.completeBluestoreMigration(config)
+}
+
+// MigrationPending returns whether the orchestration migrated a failure domain to bluestore and filestore OSDs are
+// left to migrate, in which case another orchestration must be run
+func (c *Cluster) MigrationPending() bool {
+	return c.migrationPending
+}
+
+// getFilestoreOSDs returns the filestore OSDs that were created by ceph-volume and the other filestore OSDs, and
+// exposes the object store of each OSD, the OSDs left to migrate and the OSDs that cannot be migrated in the status
+func (c *Cluster) getFilestoreOSDs() ([]int, []int, error) {
+	metadata, err := client.GetOSDMetadata(c.context, c.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migratable := []int{}
+	others := []int{}
+	statuses := []cephv1.OSDStoreStatus{}
+	for _, m := range metadata {
+		statuses = append(statuses, cephv1.OSDStoreStatus{ID: m.ID, StoreType: m.ObjectStore})
+		if m.ObjectStore != filestoreObjectStore {
+			continue
+		}
+		// ceph-volume mounts the data of the osds in the default osd data directory, the directory based osds and the
+		// osds created before ceph-volume have no device that the prepare job can prepare again
+		if m.OSDData == fmt.Sprintf("%s%d", activateOSDMountPath, m.ID) {
+			migratable = append(migratable, m.ID)
+		} else {
+			others = append(others, m.ID)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].ID < statuses[j].ID })
+	sort.Ints(migratable)
+	sort.Ints(others)
+	c.updateStorageStatus(func(storage *cephv1.StorageStatus) {
+		storage.OSDs = statuses
+		if storage.Migration == nil && len(migratable)+len(others) > 0 {
+			storage.Migration = &cephv1.StoreMigrationStatus{}
+		}
+		if storage.Migration != nil {
+			storage.Migration.PendingOSDs = migratable
+			storage.Migration.UnsupportedOSDs = others
+		}
+	})
+	return migratable, others, nil
+}
+
+// prepareBluestoreMigration drains and destroys the filestore OSDs of the next failure domain, and records the
+// destroyed OSDs so the prepare job of their node prepares them again with bluestore
+func (c *Cluster) prepareBluestoreMigration(config *provisionConfig) {
+	if !c.migrateToBluestore() {
+		return
+	}
+
+	filestoreOSDs, others, err := c.getFilestoreOSDs()
+	if err != nil {
+		config.addError("failed to get the store type of the osds. %v", err)
+		return
+	}
+	if len(others) > 0 {
+		logger.Warningf(migrationUnsupportedHintFmt, others)
+	}
+
+	osdDump, err := client.GetOSDDump(c.context, c.Namespace)
+	if err != nil {
+		config.addError("failed to get osd dump for the bluestore migration. %v", err)
+		return
+	}
+	destroyed, err := osdDump.DestroyedOSDs()
+	if err != nil {
+		config.addError("failed to get the destroyed osds. %v", err)
+		return
+	}
+	if len(destroyed) > 0 {
+		// resume the migration of a failure domain that was interrupted in a previous orchestration
+		logger.Infof("resuming the bluestore migration of destroyed osds %v", destroyed)
+		if config.migrations, err = c.getOSDNodes(destroyed); err != nil {
+			config.addError("failed to find the nodes of the destroyed osds %v. %v", destroyed, err)
+		}
+		return
+	}
+	if status := c.migrationStatus(); status != nil && len(status.OSDs) > 0 &&
+		(status.Phase == migrationPhaseReprovisioning || status.Phase == migrationPhaseRecovering) {
+		// the osds were prepared again but the previous orchestration did not mark them in or did not see them recover
+		logger.Infof("resuming the bluestore migration of osds %v in phase %q", status.OSDs, status.Phase)
+		if config.migrations, err = c.getOSDNodes(status.OSDs); err != nil {
+			config.addError("failed to find the nodes of the migrated osds %v. %v", status.OSDs, err)
+		}
+		return
+	}
+
+	if len(filestoreOSDs) == 0 {
+		logger.Debugf("no filestore osd to migrate to bluestore")
+		if len(others) > 0 {
+			c.updateMigrationStatus(migrationPhaseIncomplete, "", nil, fmt.Sprintf(migrationUnsupportedHintFmt, others))
+		}
+		return
+	}
+
+	bucket, osdIDs, err := c.nextMigrationFailureDomain(filestoreOSDs)
+	if err != nil {
+		config.addError("failed to find the next failure domain to migrate to bluestore. %v", err)
+		return
+	}
+
+	logger.Infof("migrating filestore osds %v of failure domain %q to bluestore", osdIDs, bucket)
+	if err := c.destroyFilestoreOSDs(bucket, osdIDs); err != nil {
+		c.updateMigrationStatus(migrationPhaseFailed, bucket, osdIDs, err.Error())
+		config.addError("failed to migrate the osds of failure domain %q to bluestore. %v", bucket, err)
+		return
+	}
+
+	if config.migrations, err = c.getOSDNodes(osdIDs); err != nil {
+		config.addError("failed to find the nodes of the destroyed osds %v. %v", osdIDs, err)
+		return
+	}
+	c.updateMigrationStatus(migrationPhaseReprovisioning, bucket, osdIDs, "preparing the osds again with bluestore")
+}
+
+// nextMigrationFailureDomain returns the name of the first CRUSH bucket that has filestore OSDs and its OSDs
+func (c *Cluster) nextMigrationFailureDomain(filestoreOSDs []int) (string, []int, error) {
+	failureDomain := c.updateStrategy.FailureDomain
+	if failureDomain == "" {
+		failureDomain = defaultUpdateFailureDomain
+	}
+
+	domains := map[string][]int{}
+	for _, osdID := range filestoreOSDs {
+		location, err := client.FindOSDInCrushMap(c.context, c.Namespace, osdID)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "failed to find the location of osd.%d", osdID)
+		}
+		bucket, ok := location.Location[failureDomain]
+		if !ok || bucket == "" {
+			bucket = location.Location[defaultUpdateFailureDomain]
+		}
+		domains[bucket] = append(domains[bucket], osdID)
+	}
+
+	buckets := []string{}
+	for bucket := range domains {
+		buckets = append(buckets, bucket)
+	}
+	sort.Strings(buckets)
+	return buckets[0], domains[buckets[0]], nil
+}
+
+// destroyFilestoreOSDs moves the data out of the OSDs, stops them and destroys them while keeping their IDs
+func (c *Cluster) destroyFilestoreOSDs(bucket string, osdIDs []int) error {
+	c.updateMigrationStatus(migrationPhaseDraining, bucket, osdIDs, "marking the osds out and waiting for them to be safe to destroy")
+	for _, osdID := range osdIDs {
+		if output, err := client.OSDOut(c.context, c.Namespace, osdID); err != nil {
+			return errors.Wrapf(err, "failed to mark osd.%d out. %s", osdID, output)
+		}
+	}
+
+	err := util.Retry(migrationCleanRetries, migrationCleanInterval, func() error {
+		for _, osdID := range osdIDs {
+			safe, err := client.OsdSafeToDestroy(c.context, c.Namespace, osdID, c.clusterInfo.CephVersion)
+			if err != nil {
+				return err
+			}
+			if !safe {
+				return errors.Errorf("osd.%d is not safe to destroy yet", osdID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to wait for osds %v to be safe to destroy", osdIDs)
+	}
+
+	for _, osdID := range osdIDs {
+		// the deployment is kept to remember the node of the osd, it is updated when the osd is prepared again
+		if err := c.stopOSDDeployment(osdID); err != nil {
+			return err
+		}
+		if err := client.DestroyOSD(c.context, c.Namespace, osdID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopOSDDeployment scales the deployments of the OSD down to zero
+func (c *Cluster) stopOSDDeployment(osdID int) error {
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%d", OsdIdLabelKey, osdID)}
+	deployments, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).List(listOpts)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the deployments of osd.%d", osdID)
+	}
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		replicas := int32(0)
+		d.Spec.Replicas = &replicas
+		if _, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Update(d); err != nil {
+			return errors.Wrapf(err, "failed to stop deployment %q", d.Name)
+		}
+	}
+	return nil
+}
+
+// getOSDNodes returns the OSD IDs indexed by the name of the node where their deployment is scheduled
+func (c *Cluster) getOSDNodes(osdIDs []int) (map[string][]int, error) {
+	nodes := map[string][]int{}
+	for _, osdID := range osdIDs {
+		listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%d", OsdIdLabelKey, osdID)}
+		deployments, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).List(listOpts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the deployments of osd.%d", osdID)
+		}
+		if len(deployments.Items) == 0 {
+			return nil, errors.Errorf("no deployment found for osd.%d", osdID)
+		}
+		nodeName := deployments.Items[0].Spec.Template.Spec.NodeSelector[v1.LabelHostname]
+		if nodeName == "" {
+			return nil, errors.Errorf("deployment %q of osd.%d has no node selector", deployments.Items[0].Name, osdID)
+		}
+		nodes[nodeName] = append(nodes[nodeName], osdID)
+	}
+	return nodes, nil
+}
+
+// completeBluestoreMigration marks the OSDs prepared again with bluestore in and waits for the recovery. It returns
+// whether the migration of the failure domain completed and filestore OSDs are left to migrate by the next
+// orchestration.
+func (c *Cluster) completeBluestoreMigration(config *provisionConfig) bool {
+	if len(config.migrations) == 0 || len(config.errorMessages) > 0 {
+		return false
+	}
+
+	osdIDs := []int{}
+	for _, ids := range config.migrations {
+		osdIDs = append(osdIDs, ids...)
+	}
+	sort.Ints(osdIDs)
+
+	osdDump, err := client.GetOSDDump(c.context, c.Namespace)
+	if err != nil {
+		config.addError("failed to get osd dump to complete the bluestore migration. %v", err)
+		return false
+	}
+	destroyed, err := osdDump.DestroyedOSDs()
+	if err != nil {
+		config.addError("failed to get the destroyed osds. %v", err)
+		return false
+	}
+	if len(destroyed) > 0 {
+		message := fmt.Sprintf(migrationNotPreparedHintFmt, destroyed[0])
+		c.updateMigrationStatus(migrationPhaseFailed, "", osdIDs, message)
+		config.addError(message)
+		return false
+	}
+
+	for _, osdID := range osdIDs {
+		if output, err := client.OSDIn(c.context, c.Namespace, osdID); err != nil {
+			config.addError("failed to mark osd.%d in. %s. %v", osdID, output, err)
+			return false
+		}
+	}
+
+	c.updateMigrationStatus(migrationPhaseRecovering, "", osdIDs, "waiting for all pgs to be active+clean")
+	err = util.Retry(migrationCleanRetries, migrationCleanInterval, func() error {
+		return client.IsClusterCleanError(c.context, c.Namespace)
+	})
+	if err != nil {
+		config.addError("failed to wait for the recovery of the osds %v migrated to bluestore. %v", osdIDs, err)
+		return false
+	}
+
+	logger.Infof("osds %v were migrated to bluestore", osdIDs)
+	c.updateMigrationStatus(migrationPhaseCompleted, "", osdIDs, "osds migrated to bluestore")
+
+	pending, _, err := c.getFilestoreOSDs()
+	if err != nil {
+		config.addError("failed to get the filestore osds left to migrate. %v", err)
+		return false
+	}
+	if len(pending) > 0 {
+		logger.Infof("filestore osds %v are left to migrate to bluestore by the next orchestration", pending)
+		return true
+	}
+	return false
+}
+
+// migrationStatus returns the progress of the bluestore migration recorded in the CephCluster status, nil if unknown
+func (c *Cluster) migrationStatus() *cephv1.StoreMigrationStatus {
+	if c.context.RookClientset == nil {
+		return nil
+	}
+	cluster, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Get(c.ownerRef.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get cluster %q to read the bluestore migration status. %v", c.ownerRef.Name, err)
+		return nil
+	}
+	if cluster.Status.Storage == nil {
+		return nil
+	}
+	return cluster.Status.Storage.Migration
+}
+
+// updateMigrationStatus reports the progress of the bluestore migration in the CephCluster status
+func (c *Cluster) updateMigrationStatus(phase, failureDomain string, osdIDs []int, message string) {
+	c.updateStorageStatus(func(storage *cephv1.StorageStatus) {
+		if failureDomain == "" && storage.Migration != nil {
+			failureDomain = storage.Migration.FailureDomain
+		}
+		var pending, unsupported []int
+		if storage.Migration != nil {
+			pending = storage.Migration.PendingOSDs
+			unsupported = storage.Migration.UnsupportedOSDs
+		}
+		storage.Migration = &cephv1.StoreMigrationStatus{
+			Phase:           phase,
+			FailureDomain:   failureDomain,
+			OSDs:            osdIDs,
+			PendingOSDs:     pending,
+			UnsupportedOSDs: unsupported,
+			Message:         message,
+			LastUpdated:     time.Now().UTC().Format(time.RFC3339),
+		}
+	})
+}
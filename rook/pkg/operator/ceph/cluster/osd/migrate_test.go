@@ -0,0 +1,204 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	osdconfig "github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	testexec "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBluestoreMigration(t *testing.T) {
+	defer func(retries int, interval time.Duration) {
+		migrationCleanRetries = retries
+		migrationCleanInterval = interval
+	}(migrationCleanRetries, migrationCleanInterval)
+	migrationCleanRetries = 1
+	migrationCleanInterval = time.Millisecond
+	clientset := testexec.New(t, 1)
+	rookClientset := rookclient.NewSimpleClientset(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "ns"}})
+
+	// osd.0 and osd.1 are filestore osds on node-b and node-a, osd.2 is a bluestore osd on node-a and osd.3 is a
+	// directory based filestore osd on node-a
+	nodes := map[int]string{0: "node-b", 1: "node-a", 2: "node-a", 3: "node-a"}
+	for id, node := range nodes {
+		d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(osdAppNameFmt, id),
+			Namespace: "ns",
+			Labels:    map[string]string{OsdIdLabelKey: fmt.Sprintf("%d", id)},
+		}}
+		d.Spec.Template.Spec.NodeSelector = map[string]string{v1.LabelHostname: node}
+		_, err := clientset.AppsV1().Deployments("ns").Create(d)
+		assert.NoError(t, err)
+	}
+
+	// the object store of the osds created by ceph-volume, osd.3 is a directory based osd
+	stores := map[int]string{0: "filestore", 1: "filestore", 2: "bluestore"}
+	destroyed := map[string]bool{}
+	var destroyCommands []string
+	mockCommand := func(command string, outFileArg string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		switch {
+		case args[0] == "status":
+			return `{"pgmap":{"num_pgs":100,"pgs_by_state":[{"state_name":"active+clean","count":100}]}}`, nil
+		case args[0] == "osd" && args[1] == "metadata":
+			metadata := ""
+			for id := 0; id < 3; id++ {
+				metadata += fmt.Sprintf(`{"id":%d,"hostname":"%s","osd_objectstore":"%s","osd_data":"/var/lib/ceph/osd/ceph-%d"},`, id, nodes[id], stores[id], id)
+			}
+			return `[` + metadata + `{"id":3,"hostname":"node-a","osd_objectstore":"filestore","osd_data":"/var/lib/rook/osd3"}]`, nil
+		case args[0] == "osd" && args[1] == "dump":
+			osds := ""
+			for id := 0; id < 4; id++ {
+				state := `"exists","up"`
+				if destroyed[fmt.Sprintf("%d", id)] {
+					state = `"destroyed","exists"`
+				}
+				if id > 0 {
+					osds += ","
+				}
+				osds += fmt.Sprintf(`{"osd":%d,"up":1,"in":1,"state":[%s]}`, id, state)
+			}
+			return fmt.Sprintf(`{"osds":[%s]}`, osds), nil
+		case args[0] == "osd" && args[1] == "find":
+			var id int
+			fmt.Sscanf(args[2], "%d", &id)
+			return fmt.Sprintf(`{"osd":%d,"crush_location":{"host":"%s","root":"default"}}`, id, nodes[id]), nil
+		case args[0] == "osd" && args[1] == "safe-to-destroy":
+			return fmt.Sprintf(`{"safe_to_destroy":[%s],"active":[],"missing_stats":[],"stored_pgs":[]}`, args[2]), nil
+		case args[0] == "osd" && args[1] == "destroy":
+			destroyed[args[2]] = true
+			destroyCommands = append(destroyCommands, args[2])
+		}
+		return "", nil
+	}
+	executor := &exectest.MockExecutor{MockExecuteCommandWithOutputFile: mockCommand}
+	context := &clusterd.Context{Executor: executor, Clientset: clientset, RookClientset: rookClientset}
+	c := &Cluster{context: context, Namespace: "ns", ownerRef: metav1.OwnerReference{Name: "mycluster"}, clusterInfo: &cephconfig.ClusterInfo{}}
+
+	// nothing is migrated nor reported by default
+	config := c.newProvisionConfig()
+	c.prepareBluestoreMigration(config)
+	assert.Equal(t, 0, len(config.errorMessages))
+	assert.Equal(t, 0, len(config.migrations))
+	cluster, err := rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Nil(t, cluster.Status.Storage)
+
+	// the filestore osd of the first host is destroyed and its deployment is stopped, the directory osd is left
+	c.DesiredStorage.Config = map[string]string{osdconfig.MigrateToBluestoreKey: "true"}
+	c.prepareBluestoreMigration(config)
+	assert.Equal(t, 0, len(config.errorMessages))
+	assert.Equal(t, []string{"1"}, destroyCommands)
+	assert.True(t, config.isMigrating(1))
+	assert.False(t, config.isMigrating(3))
+	assert.Equal(t, map[string][]int{"node-a": {1}}, config.migrations)
+	d, err := clientset.AppsV1().Deployments("ns").Get(fmt.Sprintf(osdAppNameFmt, 1), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), *d.Spec.Replicas)
+	cluster, err = rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []cephv1.OSDStoreStatus{{ID: 0, StoreType: "filestore"}, {ID: 1, StoreType: "filestore"}, {ID: 2, StoreType: "bluestore"},
+		{ID: 3, StoreType: "filestore"}}, cluster.Status.Storage.OSDs)
+	assert.Equal(t, migrationPhaseReprovisioning, cluster.Status.Storage.Migration.Phase)
+	assert.Equal(t, "node-a", cluster.Status.Storage.Migration.FailureDomain)
+	assert.Equal(t, []int{0, 1}, cluster.Status.Storage.Migration.PendingOSDs)
+	assert.Equal(t, []int{3}, cluster.Status.Storage.Migration.UnsupportedOSDs)
+
+	// the osd was not prepared again, the migration fails with a hint
+	assert.False(t, c.completeBluestoreMigration(config))
+	assert.Equal(t, 1, len(config.errorMessages))
+	cluster, err = rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, migrationPhaseFailed, cluster.Status.Storage.Migration.Phase)
+
+	// the next orchestration resumes the migration of the destroyed osd
+	config = c.newProvisionConfig()
+	c.prepareBluestoreMigration(config)
+	assert.Equal(t, 0, len(config.errorMessages))
+	assert.Equal(t, []string{"1"}, destroyCommands)
+	assert.Equal(t, map[string][]int{"node-a": {1}}, config.migrations)
+
+	// the osd was prepared again with bluestore but the recovery does not complete
+	destroyed = map[string]bool{}
+	stores[1] = "bluestore"
+	clean := false
+	executor.MockExecuteCommandWithOutputFile = func(command string, outFileArg string, args ...string) (string, error) {
+		if args[0] == "status" && !clean {
+			return `{"pgmap":{"num_pgs":100,"pgs_by_state":[{"state_name":"active+clean","count":90},{"state_name":"active+recovering","count":10}]}}`, nil
+		}
+		return mockCommand(command, outFileArg, args...)
+	}
+	assert.False(t, c.completeBluestoreMigration(config))
+	assert.Equal(t, 1, len(config.errorMessages))
+	cluster, err = rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, migrationPhaseRecovering, cluster.Status.Storage.Migration.Phase)
+
+	// the next orchestration resumes the recovery of the failure domain before migrating another one
+	clean = true
+	config = c.newProvisionConfig()
+	c.prepareBluestoreMigration(config)
+	assert.Equal(t, 0, len(config.errorMessages))
+	assert.Equal(t, []string{"1"}, destroyCommands)
+	assert.Equal(t, map[string][]int{"node-a": {1}}, config.migrations)
+
+	// the failure domain is completed, the next one is left to the next orchestration
+	assert.True(t, c.completeBluestoreMigration(config))
+	assert.Equal(t, 0, len(config.errorMessages))
+	cluster, err = rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, migrationPhaseCompleted, cluster.Status.Storage.Migration.Phase)
+	assert.Equal(t, "node-a", cluster.Status.Storage.Migration.FailureDomain)
+	assert.Equal(t, []int{1}, cluster.Status.Storage.Migration.OSDs)
+	assert.Equal(t, []int{0}, cluster.Status.Storage.Migration.PendingOSDs)
+
+	// the filestore osd of the next host is migrated
+	config = c.newProvisionConfig()
+	c.prepareBluestoreMigration(config)
+	assert.Equal(t, 0, len(config.errorMessages))
+	assert.Equal(t, []string{"1", "0"}, destroyCommands)
+	assert.Equal(t, map[string][]int{"node-b": {0}}, config.migrations)
+	destroyed = map[string]bool{}
+	stores[0] = "bluestore"
+	assert.False(t, c.completeBluestoreMigration(config))
+	assert.Equal(t, 0, len(config.errorMessages))
+
+	// only the directory based osd is left, it cannot be migrated and is reported in the status
+	config = c.newProvisionConfig()
+	c.prepareBluestoreMigration(config)
+	assert.Equal(t, 0, len(config.errorMessages))
+	assert.Equal(t, 0, len(config.migrations))
+	cluster, err = rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, migrationPhaseIncomplete, cluster.Status.Storage.Migration.Phase)
+	assert.Empty(t, cluster.Status.Storage.Migration.PendingOSDs)
+	assert.Equal(t, []int{3}, cluster.Status.Storage.Migration.UnsupportedOSDs)
+	assert.Equal(t, fmt.Sprintf(migrationUnsupportedHintFmt, []int{3}), cluster.Status.Storage.Migration.Message)
+}
@@ -71,6 +71,9 @@ const (
 	cephOsdPodMinimumMemory      uint64 = 2048 // minimum amount of memory in MB to run the pod
 	bluestorePVCMetadata                = "metadata"
 	bluestorePVCData                    = "data"
+	// the default ratio of the memory limit of the osd containers set as osd_memory_target, as the
+	// osd_memory_target_cgroup_limit_ratio of ceph
+	defaultOSDMemoryTargetRatio = 0.8
 )
 
 // Cluster keeps track of the OSDs
@@ -94,6 +97,10 @@ type Cluster struct {
 	kv                                         *k8sutil.ConfigMapKVStore
 	skipUpgradeChecks                          bool
 	continueUpgradeAfterChecksEvenIfNotHealthy bool
+	updateStrategy                             cephv1.OSDUpdateStrategySpec
+	memoryTargetRatio                          string
+	// migrationPending is true when filestore OSDs are left to migrate to bluestore by the next orchestration
+	migrationPending bool
 }
 
 // New creates an instance of the OSD manager
@@ -114,6 +121,8 @@ func New(
 	ownerRef metav1.OwnerReference,
 	skipUpgradeChecks bool,
 	continueUpgradeAfterChecksEvenIfNotHealthy bool,
+	updateStrategy cephv1.OSDUpdateStrategySpec,
+	memoryTargetRatio string,
 ) *Cluster {
 	return &Cluster{
 		clusterInfo:       clusterInfo,
@@ -133,6 +142,8 @@ func New(
 		kv:                k8sutil.NewConfigMapKVStore(namespace, context.Clientset, ownerRef),
 		skipUpgradeChecks: skipUpgradeChecks,
 		continueUpgradeAfterChecksEvenIfNotHealthy: continueUpgradeAfterChecksEvenIfNotHealthy,
+		updateStrategy:    updateStrategy,
+		memoryTargetRatio: memoryTargetRatio,
 	}
 }
 
@@ -176,6 +187,7 @@ type osdProperties struct {
 	portable            bool
 	tuneSlowDeviceClass bool
 	crushDeviceClass    string
+	migrateOSDIDs       []int
 }
 
 func (osdProps osdProperties) onPVC() bool {
@@ -205,8 +217,19 @@ func (c *Cluster) Start() error {
 	logger.Infof("start provisioning the osds on pvcs, if needed")
 	c.startProvisioningOverPVCs(config)
 
+	logger.Infof("remove the osds of the storageClassDeviceSets that were scaled down, if needed")
+	c.scaleDownStorageClassDeviceSets(config)
+
 	logger.Infof("start provisioning the osds on nodes, if needed")
-	c.startProvisioningOverNodes(config)
+	c.startProvisioningOverNodesWithMigration(config)
+
+	if c.updateByFailureDomain() {
+		logger.Infof("update the osds by failure domain, if needed")
+		c.updateOSDsByFailureDomain(config)
+	}
+
+	logger.Infof("apply the config overrides of the osds, if needed")
+	c.applyOSDConfigOverrides()
 
 	if len(config.errorMessages) > 0 {
 		return errors.Errorf("%d failures encountered while running osds in namespace %s: %+v",
@@ -391,6 +414,7 @@ func (c *Cluster) startProvisioningOverNodes(config *provisionConfig) {
 			resources:      n.Resources,
 			storeConfig:    storeConfig,
 			metadataDevice: metadataDevice,
+			migrateOSDIDs:  config.migrations[n.Name],
 		}
 		job, err := c.makeJob(osdProps, config)
 		if err != nil {
@@ -483,7 +507,7 @@ func (c *Cluster) startOSDDaemonsOnPVC(pvcName string, config *provisionConfig,
 		}
 
 		if createErr != nil && kerrors.IsAlreadyExists(createErr) {
-			if err = updateDeploymentAndWait(c.context, dp, c.Namespace, opconfig.OsdType, strconv.Itoa(osd.ID), c.skipUpgradeChecks, c.continueUpgradeAfterChecksEvenIfNotHealthy); err != nil {
+			if err = c.updateOSDDeploymentOnPVC(config, createdDeployment, dp, osdProps, osd); err != nil {
 				logger.Errorf("failed to update osd deployment %d. %v", osd.ID, err)
 			}
 		}
@@ -491,6 +515,39 @@ func (c *Cluster) startOSDDaemonsOnPVC(pvcName string, config *provisionConfig,
 	}
 }
 
+// updateOSDDeploymentOnPVC updates the deployment of an OSD running on a PVC. If the PVC was expanded, the OSD
+// is only restarted once the PVC is resized so the expand init container can grow bluestore, and the update does
+// not complete until ceph reports the new capacity.
+func (c *Cluster) updateOSDDeploymentOnPVC(config *provisionConfig, current, desired *apps.Deployment, osdProps osdProperties, osd OSDInfo) error {
+	expanding := pvcExpansionRequested(current, osdProps)
+	var previousKB int64
+	if expanding {
+		logger.Infof("pvc %q of osd %d is being expanded to %s", osdProps.pvc.ClaimName, osd.ID, osdProps.pvcSize)
+		var err error
+		previousKB, err = c.getOSDCapacityKB(osd.ID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get capacity of osd %d before expansion", osd.ID)
+		}
+		if err := c.waitForPVCResize(osdProps); err != nil {
+			return errors.Wrapf(err, "failed to wait for pvc %q to be resized", osdProps.pvc.ClaimName)
+		}
+	}
+
+	if !expanding {
+		return c.updateOSDDeployment(config, desired, osd.ID)
+	}
+
+	// the update is gated on the osd being ok-to-stop, so expanded osds are restarted one at a time
+	if err := updateDeploymentAndWait(c.context, desired, c.Namespace, opconfig.OsdType, strconv.Itoa(osd.ID), c.skipUpgradeChecks, c.continueUpgradeAfterChecksEvenIfNotHealthy); err != nil {
+		return err
+	}
+
+	if err := c.waitForOSDExpansion(osd.ID, previousKB); err != nil {
+		return errors.Wrapf(err, "failed to verify the expansion of osd %d", osd.ID)
+	}
+	return nil
+}
+
 func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig, configMap *v1.ConfigMap, status *OrchestrationStatus) {
 
 	osds := status.OSDs
@@ -563,7 +620,7 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 		}
 
 		if createErr != nil && kerrors.IsAlreadyExists(createErr) {
-			if err = updateDeploymentAndWait(c.context, dp, c.Namespace, opconfig.OsdType, strconv.Itoa(osd.ID), c.skipUpgradeChecks, c.continueUpgradeAfterChecksEvenIfNotHealthy); err != nil {
+			if err = c.updateOSDDeployment(config, dp, osd.ID); err != nil {
 				logger.Errorf("failed to update osd deployment %d. %v", osd.ID, err)
 			}
 		}
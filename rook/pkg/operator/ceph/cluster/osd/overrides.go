@@ -0,0 +1,250 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	osdconfig "github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// applyOSDConfigOverrides sets the Ceph config options of the storage spec and the options derived from the
+// resources of each OSD in the mon config store, removes the options that were dropped from the spec or are no
+// longer derived and reports the overrides of the OSDs in the status
+func (c *Cluster) applyOSDConfigOverrides() {
+	desired, derived, err := c.getDesiredOSDConfig()
+	if err != nil {
+		logger.Warningf("failed to get the config overrides of the osds. %v", err)
+		return
+	}
+
+	// the options applied from the spec and derived in the previous orchestration
+	previous := map[int]map[string]string{}
+	previousDerived := map[int]map[string]string{}
+	if storage := c.getStorageStatus(); storage != nil {
+		for _, s := range storage.OSDConfig {
+			previous[s.ID] = s.Spec
+			previousDerived[s.ID] = s.Derived
+		}
+	}
+
+	osdIDs := []int{}
+	for osdID := range desired {
+		if len(desired[osdID]) > 0 || len(previous[osdID]) > 0 || len(derived[osdID]) > 0 || len(previousDerived[osdID]) > 0 {
+			osdIDs = append(osdIDs, osdID)
+		}
+	}
+	sort.Ints(osdIDs)
+
+	monStore := opconfig.GetMonStore(c.context, c.Namespace)
+	statuses := []cephv1.OSDConfigStatus{}
+	for _, osdID := range osdIDs {
+		who := fmt.Sprintf("osd.%d", osdID)
+		spec := map[string]string{}
+		for option, value := range desired[osdID] {
+			if err := monStore.Set(who, option, value); err != nil {
+				logger.Warningf("failed to set option %q on %s. %v", option, who, err)
+				continue
+			}
+			spec[option] = value
+		}
+		var derivedApplied map[string]string
+		for option, value := range derived[osdID] {
+			// the options of the spec have precedence over the derived options
+			if _, ok := desired[osdID][option]; ok {
+				continue
+			}
+			if err := monStore.Set(who, option, value); err != nil {
+				logger.Warningf("failed to set derived option %q on %s. %v", option, who, err)
+				continue
+			}
+			if derivedApplied == nil {
+				derivedApplied = map[string]string{}
+			}
+			derivedApplied[option] = value
+		}
+
+		// keep the options that failed to be removed to try again to remove them in the next orchestration
+		removeDropped := func(previousOptions, kept map[string]string) {
+			for option, value := range previousOptions {
+				if _, ok := desired[osdID][option]; ok {
+					continue
+				}
+				if _, ok := derived[osdID][option]; ok {
+					continue
+				}
+				if err := monStore.Delete(who, option); err != nil {
+					logger.Warningf("failed to remove option %q from %s. %v", option, who, err)
+					kept[option] = value
+					continue
+				}
+				logger.Infof("removed option %q from %s", option, who)
+			}
+		}
+		removeDropped(previous[osdID], spec)
+		if len(previousDerived[osdID]) > 0 {
+			if derivedApplied == nil {
+				derivedApplied = map[string]string{}
+			}
+			removeDropped(previousDerived[osdID], derivedApplied)
+			if len(derivedApplied) == 0 {
+				derivedApplied = nil
+			}
+		}
+
+		status := cephv1.OSDConfigStatus{ID: osdID, Spec: spec, Derived: derivedApplied}
+		options, err := monStore.GetDaemon(who)
+		if err != nil {
+			logger.Warningf("failed to get the effective config of %s. %v", who, err)
+		}
+		if len(options) > 0 {
+			status.Effective = map[string]string{}
+			for _, option := range options {
+				status.Effective[option.Option] = option.Value
+			}
+		}
+		if len(status.Spec) > 0 || len(status.Derived) > 0 || len(status.Effective) > 0 {
+			statuses = append(statuses, status)
+		}
+	}
+
+	c.updateStorageStatus(func(storage *cephv1.StorageStatus) {
+		storage.OSDConfig = statuses
+	})
+}
+
+// getDesiredOSDConfig returns the Ceph config options of the storage spec indexed by OSD ID. The options of the OSDs
+// on nodes come from the config of their node and of their devices, the device config having precedence. The
+// options of the OSDs on PVCs come from the osdConfig of their storageClassDeviceSet. The options derived from the
+// resources of the OSD containers are returned separately.
+func (c *Cluster) getDesiredOSDConfig() (map[int]map[string]string, map[int]map[string]string, error) {
+	metadata, err := client.GetOSDMetadata(c.context, c.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	osdDevices := map[int][]string{}
+	for _, m := range metadata {
+		if m.Devices != "" {
+			osdDevices[m.ID] = strings.Split(m.Devices, ",")
+		}
+	}
+
+	// the osdConfig of the storageClassDeviceSets indexed by pvc name
+	pvcOptions := map[string]map[string]string{}
+	for _, set := range c.DesiredStorage.StorageClassDeviceSets {
+		indexes, err := c.getDeviceSetPVCs(set.Name)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to list pvcs of storageClassDeviceSet %q", set.Name)
+		}
+		for _, pvcNames := range indexes {
+			for _, pvcName := range pvcNames {
+				pvcOptions[pvcName] = set.OSDConfig
+			}
+		}
+	}
+
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.AppAttr, AppName)}
+	deployments, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).List(listOpts)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to list osd deployments")
+	}
+
+	memoryTargetRatio, err := opconfig.ParseMemoryRatio(c.memoryTargetRatio, defaultOSDMemoryTargetRatio)
+	if err != nil {
+		logger.Warningf("not deriving the memory target of the osds. %v", err)
+	}
+
+	desired := map[int]map[string]string{}
+	derived := map[int]map[string]string{}
+	for _, d := range deployments.Items {
+		osdID, err := strconv.Atoi(d.Labels[OsdIdLabelKey])
+		if err != nil {
+			logger.Warningf("skipping deployment %q with invalid osd id %q", d.Name, d.Labels[OsdIdLabelKey])
+			continue
+		}
+
+		options := map[string]string{}
+		if pvcName, ok := d.Labels[OSDOverPVCLabelKey]; ok {
+			for option, value := range pvcOptions[pvcName] {
+				options[option] = value
+			}
+		} else if node := c.findStorageNode(d.Spec.Template.Spec.NodeSelector[v1.LabelHostname]); node != nil {
+			for option, value := range osdconfig.CephOptions(node.Config) {
+				options[option] = value
+			}
+			for _, device := range node.Devices {
+				if !osdOnDevice(osdDevices[osdID], device.Name) {
+					continue
+				}
+				for option, value := range osdconfig.CephOptions(device.Config) {
+					options[option] = value
+				}
+			}
+		}
+		desired[osdID] = options
+
+		if memoryTargetRatio > 0 {
+			if target := osdMemoryTarget(&d, memoryTargetRatio); target > 0 {
+				derived[osdID] = map[string]string{"osd_memory_target": strconv.FormatInt(target, 10)}
+			}
+		}
+	}
+	return desired, derived, nil
+}
+
+// osdMemoryTarget returns the ratio of the memory limit of the osd container of a deployment, or 0 if the container
+// has no memory limit
+func osdMemoryTarget(d *apps.Deployment, ratio float64) int64 {
+	for _, container := range d.Spec.Template.Spec.Containers {
+		if container.Name == "osd" {
+			return opconfig.MemoryFromLimit(container.Resources, ratio)
+		}
+	}
+	return 0
+}
+
+// findStorageNode returns the node of the storage spec with the given name
+func (c *Cluster) findStorageNode(name string) *rookv1.Node {
+	for i := range c.DesiredStorage.Nodes {
+		if c.DesiredStorage.Nodes[i].Name == name {
+			return &c.DesiredStorage.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// osdOnDevice returns whether the devices reported by an OSD include the device of the storage spec
+func osdOnDevice(osdDevices []string, deviceName string) bool {
+	for _, d := range osdDevices {
+		if d == strings.TrimPrefix(deviceName, "/dev/") {
+			return true
+		}
+	}
+	return false
+}
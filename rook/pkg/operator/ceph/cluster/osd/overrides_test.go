@@ -0,0 +1,199 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	testexec "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplyOSDConfigOverrides(t *testing.T) {
+	clientset := testexec.New(t, 1)
+	rookClientset := rookclient.NewSimpleClientset(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "ns"}})
+
+	// osd.0 and osd.1 run on the devices sdb and sdc of node-a, osd.2 runs on a pvc of set1
+	pvc := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+		Name:      "set1-data-0",
+		Namespace: "ns",
+		Labels:    makeStorageClassDeviceSetPVCLabel("set1", "set1-data-0", 0),
+	}}
+	_, err := clientset.CoreV1().PersistentVolumeClaims("ns").Create(pvc)
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(osdAppNameFmt, i),
+			Namespace: "ns",
+			Labels:    map[string]string{"app": AppName, OsdIdLabelKey: fmt.Sprintf("%d", i)},
+		}}
+		if i == 2 {
+			d.Labels[OSDOverPVCLabelKey] = "set1-data-0"
+		} else {
+			d.Spec.Template.Spec.NodeSelector = map[string]string{v1.LabelHostname: "node-a"}
+		}
+		_, err := clientset.AppsV1().Deployments("ns").Create(d)
+		assert.NoError(t, err)
+	}
+
+	// the mon config store
+	store := map[string]map[string]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			switch {
+			case args[0] == "osd" && args[1] == "metadata":
+				return `[{"id":0,"devices":"sdb"},{"id":1,"devices":"sdc"},{"id":2,"devices":"sdd"}]`, nil
+			case args[0] == "config" && args[1] == "set":
+				if store[args[2]] == nil {
+					store[args[2]] = map[string]string{}
+				}
+				store[args[2]][args[3]] = args[4]
+			case args[0] == "config" && args[1] == "rm":
+				delete(store[args[2]], args[3])
+			case args[0] == "config" && args[1] == "get":
+				result := map[string]map[string]string{}
+				for option, value := range store[args[2]] {
+					result[option] = map[string]string{"section": args[2], "value": value}
+				}
+				out, _ := json.Marshal(result)
+				return string(out), nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: clientset, RookClientset: rookClientset}
+	c := &Cluster{context: context, Namespace: "ns", ownerRef: metav1.OwnerReference{Name: "mycluster"}}
+	c.DesiredStorage.Nodes = []rookv1.Node{{
+		Name:   "node-a",
+		Config: map[string]string{"osd_max_backfills": "2", "storeType": "bluestore"},
+		Selection: rookv1.Selection{Devices: []rookv1.Device{
+			{Name: "sdc", Config: map[string]string{"osd_max_backfills": "4", "osdsPerDevice": "1"}},
+		}},
+	}}
+	c.DesiredStorage.StorageClassDeviceSets = []rookv1.StorageClassDeviceSet{{Name: "set1", Count: 1, OSDConfig: map[string]string{"osd_op_queue": "wpq"}}}
+
+	// the rook settings are not set, the device config has precedence over the node config
+	c.applyOSDConfigOverrides()
+	assert.Equal(t, map[string]map[string]string{
+		"osd.0": {"osd_max_backfills": "2"},
+		"osd.1": {"osd_max_backfills": "4"},
+		"osd.2": {"osd_op_queue": "wpq"},
+	}, store)
+	cluster, err := rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(cluster.Status.Storage.OSDConfig))
+	assert.Equal(t, map[string]string{"osd_max_backfills": "4"}, cluster.Status.Storage.OSDConfig[1].Spec)
+
+	// an option set manually is reported but not removed, the options dropped from the spec are removed
+	store["osd.0"]["debug_osd"] = "20"
+	c.DesiredStorage.Nodes[0].Config = nil
+	c.DesiredStorage.StorageClassDeviceSets[0].OSDConfig = nil
+	c.applyOSDConfigOverrides()
+	assert.Equal(t, map[string]map[string]string{
+		"osd.0": {"debug_osd": "20"},
+		"osd.1": {"osd_max_backfills": "4"},
+		"osd.2": {},
+	}, store)
+	cluster, err = rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []cephv1.OSDConfigStatus{
+		{ID: 0, Spec: map[string]string{}, Effective: map[string]string{"debug_osd": "20"}},
+		{ID: 1, Spec: map[string]string{"osd_max_backfills": "4"}, Effective: map[string]string{"osd_max_backfills": "4"}},
+	}, cluster.Status.Storage.OSDConfig)
+}
+
+func TestApplyOSDMemoryTarget(t *testing.T) {
+	clientset := testexec.New(t, 1)
+	rookClientset := rookclient.NewSimpleClientset(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "ns"}})
+	d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:      fmt.Sprintf(osdAppNameFmt, 0),
+		Namespace: "ns",
+		Labels:    map[string]string{"app": AppName, OsdIdLabelKey: "0"},
+	}}
+	d.Spec.Template.Spec.NodeSelector = map[string]string{v1.LabelHostname: "node-a"}
+	d.Spec.Template.Spec.Containers = []v1.Container{{
+		Name:      "osd",
+		Resources: v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("4Gi")}},
+	}}
+	_, err := clientset.AppsV1().Deployments("ns").Create(d)
+	assert.NoError(t, err)
+
+	store := map[string]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			switch {
+			case args[0] == "osd" && args[1] == "metadata":
+				return `[{"id":0,"devices":"sdb"}]`, nil
+			case args[0] == "config" && args[1] == "set":
+				store[args[3]] = args[4]
+			case args[0] == "config" && args[1] == "rm":
+				delete(store, args[3])
+			}
+			return "{}", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: clientset, RookClientset: rookClientset}
+	c := &Cluster{context: context, Namespace: "ns", ownerRef: metav1.OwnerReference{Name: "mycluster"}}
+	c.DesiredStorage.Nodes = []rookv1.Node{{Name: "node-a"}}
+	osdConfig := func() cephv1.OSDConfigStatus {
+		cluster, err := rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+		assert.NoError(t, err)
+		if len(cluster.Status.Storage.OSDConfig) != 1 {
+			return cephv1.OSDConfigStatus{}
+		}
+		return cluster.Status.Storage.OSDConfig[0]
+	}
+
+	// the default ratio of the memory limit
+	c.applyOSDConfigOverrides()
+	assert.Equal(t, map[string]string{"osd_memory_target": "3435973836"}, store)
+	assert.Equal(t, map[string]string{"osd_memory_target": "3435973836"}, osdConfig().Derived)
+
+	c.memoryTargetRatio = "0.5"
+	c.applyOSDConfigOverrides()
+	assert.Equal(t, map[string]string{"osd_memory_target": "2147483648"}, store)
+
+	// the option of the spec has precedence
+	c.DesiredStorage.Nodes[0].Config = map[string]string{"osd_memory_target": "1073741824"}
+	c.applyOSDConfigOverrides()
+	assert.Equal(t, map[string]string{"osd_memory_target": "1073741824"}, store)
+	assert.Nil(t, osdConfig().Derived)
+	assert.Equal(t, map[string]string{"osd_memory_target": "1073741824"}, osdConfig().Spec)
+
+	// the derived option is set again when dropped from the spec, and removed without memory limit
+	c.DesiredStorage.Nodes[0].Config = nil
+	c.applyOSDConfigOverrides()
+	assert.Equal(t, map[string]string{"osd_memory_target": "2147483648"}, store)
+	d.Spec.Template.Spec.Containers[0].Resources = v1.ResourceRequirements{}
+	_, err = clientset.AppsV1().Deployments("ns").Update(d)
+	assert.NoError(t, err)
+	c.applyOSDConfigOverrides()
+	assert.Empty(t, store)
+	assert.Nil(t, osdConfig().Derived)
+}
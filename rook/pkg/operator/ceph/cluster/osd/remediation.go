@@ -0,0 +1,356 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultFlapThreshold     = 5
+	defaultFlapWindow        = 30 * time.Minute
+	defaultCrashLoopTimeout  = 30 * time.Minute
+	defaultMaxRemediatedOSDs = 1
+	crashLoopBackOffReason   = "CrashLoopBackOff"
+	osdFlappingReason        = "OSDFlapping"
+	osdCrashLoopingReason    = "OSDCrashLoopBackOff"
+	osdsHealthyReason        = "OSDsHealthy"
+)
+
+// osdTransitions tracks the down transitions of an OSD
+type osdTransitions struct {
+	up     bool
+	upFrom string
+	downs  []time.Time
+}
+
+// unhealthyOSD is an OSD that flaps or crash loops
+type unhealthyOSD struct {
+	reason  string
+	message string
+}
+
+// checkUnhealthyOSDs detects the OSDs that flap or whose pod crash loops. The newly unhealthy OSDs are remediated
+// according to the health check policy, up to the maximum of remediated OSDs, and reported with an event, and the
+// unhealthy OSDs are reported in a condition.
+func (m *OSDHealthMonitor) checkUnhealthyOSDs(osdDump *client.OSDDump) {
+	now := time.Now()
+	m.releaseRemediatedOSDs(osdDump)
+
+	unhealthy := m.trackFlappingOSDs(osdDump, now)
+	crashLooping, err := m.trackCrashLoopingOSDs(now)
+	if err != nil {
+		logger.Warningf("failed to check the osd pods in CrashLoopBackOff. %v", err)
+	}
+	for osdID, osd := range crashLooping {
+		if _, ok := unhealthy[osdID]; !ok {
+			unhealthy[osdID] = osd
+		}
+	}
+
+	osdIDs := []int{}
+	for osdID := range unhealthy {
+		osdIDs = append(osdIDs, osdID)
+	}
+	sort.Ints(osdIDs)
+
+	action := m.remediationAction()
+	messages := []string{}
+	for _, osdID := range osdIDs {
+		osd := unhealthy[osdID]
+		messages = append(messages, osd.message)
+		pending := action != cephv1.OSDRemediationNone && !m.remediatedOSDs[osdID]
+		if _, ok := m.unhealthyOSDs[osdID]; ok && (!pending || !m.canRemediate()) {
+			// the osd was already reported, and remediated or still waiting for the remediation of fewer osds
+			continue
+		}
+
+		logger.Warningf("%s", osd.message)
+		message := osd.message
+		switch {
+		case !pending:
+		case !m.canRemediate():
+			message = fmt.Sprintf("%s. the osd was not remediated since %d osds are already remediated, the maximum of the osd health check",
+				message, len(m.remediatedOSDs))
+		default:
+			if err := m.remediateOSD(osdID); err != nil {
+				logger.Errorf("failed to remediate osd.%d. %v", osdID, err)
+				message = fmt.Sprintf("%s. failed to remediate the osd. %v", message, err)
+			} else {
+				m.remediatedOSDs[osdID] = true
+				m.saveRemediatedOSDs()
+				message = fmt.Sprintf("%s. the osd was remediated with action %q", message, action)
+			}
+		}
+		m.recordEvent(osd.reason, message)
+	}
+
+	if len(unhealthy) > 0 {
+		m.exportCondition(v1.ConditionTrue, unhealthy[osdIDs[0]].reason, strings.Join(messages, ". "))
+	} else if len(m.unhealthyOSDs) > 0 {
+		m.exportCondition(v1.ConditionFalse, osdsHealthyReason, "no osd is flapping or crash looping")
+	}
+	m.unhealthyOSDs = unhealthy
+}
+
+// trackFlappingOSDs records the down transitions of the OSDs and returns the OSDs that went down more times than the
+// threshold within the sliding window
+func (m *OSDHealthMonitor) trackFlappingOSDs(osdDump *client.OSDDump, now time.Time) map[int]unhealthyOSD {
+	threshold := m.healthCheck.FlapThreshold
+	if threshold <= 0 {
+		threshold = defaultFlapThreshold
+	}
+	window := parseHealthCheckDuration(m.healthCheck.FlapWindow, defaultFlapWindow)
+
+	flapping := map[int]unhealthyOSD{}
+	found := map[int]bool{}
+	for _, osdStatus := range osdDump.OSDs {
+		id64, err := osdStatus.OSD.Int64()
+		if err != nil {
+			continue
+		}
+		id := int(id64)
+		found[id] = true
+		up, err := osdStatus.Up.Int64()
+		if err != nil {
+			continue
+		}
+		isUp := up == upStatus
+		upFrom := osdStatus.UpFrom.String()
+
+		t, ok := m.osdTransitions[id]
+		if ok && t.up && (!isUp || upFrom != t.upFrom) {
+			// the osd went down since the last check, or went down and up again between two checks
+			t.downs = append(t.downs, now)
+		}
+		downs := []time.Time{}
+		for _, down := range t.downs {
+			if now.Sub(down) <= window {
+				downs = append(downs, down)
+			}
+		}
+		m.osdTransitions[id] = osdTransitions{up: isUp, upFrom: upFrom, downs: downs}
+
+		if len(downs) >= threshold {
+			flapping[id] = unhealthyOSD{
+				reason:  osdFlappingReason,
+				message: fmt.Sprintf("osd.%d went down %d times in the last %s", id, len(downs), window),
+			}
+		}
+	}
+
+	// forget the osds that were removed
+	for id := range m.osdTransitions {
+		if !found[id] {
+			delete(m.osdTransitions, id)
+		}
+	}
+	return flapping
+}
+
+// trackCrashLoopingOSDs returns the OSDs whose pod is in CrashLoopBackOff for longer than the timeout
+func (m *OSDHealthMonitor) trackCrashLoopingOSDs(now time.Time) (map[int]unhealthyOSD, error) {
+	timeout := parseHealthCheckDuration(m.healthCheck.CrashLoopTimeout, defaultCrashLoopTimeout)
+
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.AppAttr, AppName)}
+	pods, err := m.context.Clientset.CoreV1().Pods(m.namespace).List(listOpts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list osd pods")
+	}
+
+	crashLooping := map[int]unhealthyOSD{}
+	found := map[int]bool{}
+	for _, pod := range pods.Items {
+		id, err := strconv.Atoi(pod.Labels[OsdIdLabelKey])
+		if err != nil {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Waiting == nil || status.State.Waiting.Reason != crashLoopBackOffReason {
+				continue
+			}
+			found[id] = true
+			since, ok := m.crashLoopingSince[id]
+			if !ok {
+				since = now
+				m.crashLoopingSince[id] = since
+			}
+			if now.Sub(since) >= timeout {
+				crashLooping[id] = unhealthyOSD{
+					reason: osdCrashLoopingReason,
+					message: fmt.Sprintf("osd.%d pod %q is in CrashLoopBackOff since %s after %d restarts",
+						id, pod.Name, since.UTC().Format(time.RFC3339), status.RestartCount),
+				}
+			}
+			break
+		}
+	}
+
+	for id := range m.crashLoopingSince {
+		if !found[id] {
+			delete(m.crashLoopingSince, id)
+		}
+	}
+	return crashLooping, nil
+}
+
+// canRemediate returns whether another OSD can be remediated without exceeding the maximum of remediated OSDs
+func (m *OSDHealthMonitor) canRemediate() bool {
+	max := m.healthCheck.MaxRemediatedOSDs
+	if max <= 0 {
+		max = defaultMaxRemediatedOSDs
+	}
+	return len(m.remediatedOSDs) < max
+}
+
+// releaseRemediatedOSDs forgets the remediated OSDs that are up and in again, or that were removed. The remediated
+// OSDs are loaded from the CephCluster status the first time.
+func (m *OSDHealthMonitor) releaseRemediatedOSDs(osdDump *client.OSDDump) {
+	if m.remediatedOSDs == nil {
+		m.remediatedOSDs = map[int]bool{}
+		for _, osdID := range m.loadRemediatedOSDs() {
+			m.remediatedOSDs[osdID] = true
+		}
+	}
+
+	released := false
+	for osdID := range m.remediatedOSDs {
+		up, in, err := osdDump.StatusByID(int64(osdID))
+		if err == nil && (up != upStatus || in != inStatus) {
+			continue
+		}
+		logger.Infof("remediated osd.%d is up and in again or was removed", osdID)
+		delete(m.remediatedOSDs, osdID)
+		released = true
+	}
+	if released {
+		m.saveRemediatedOSDs()
+	}
+}
+
+// loadRemediatedOSDs returns the remediated OSDs recorded in the CephCluster status
+func (m *OSDHealthMonitor) loadRemediatedOSDs() []int {
+	if m.context.RookClientset == nil || m.clusterName == "" {
+		return nil
+	}
+	cluster, err := m.context.RookClientset.CephV1().CephClusters(m.namespace).Get(m.clusterName, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get cluster %q to load the remediated osds. %v", m.clusterName, err)
+		return nil
+	}
+	if cluster.Status.Storage == nil {
+		return nil
+	}
+	return cluster.Status.Storage.RemediatedOSDs
+}
+
+// saveRemediatedOSDs records the remediated OSDs in the CephCluster status, so they are not removed by
+// removeOSDsIfOutAndSafeToRemove after a restart of the operator
+func (m *OSDHealthMonitor) saveRemediatedOSDs() {
+	if m.clusterName == "" {
+		return
+	}
+	osdIDs := []int{}
+	for osdID := range m.remediatedOSDs {
+		osdIDs = append(osdIDs, osdID)
+	}
+	sort.Ints(osdIDs)
+	updateStorageStatus(m.context, m.namespace, m.clusterName, func(storage *cephv1.StorageStatus) {
+		storage.RemediatedOSDs = osdIDs
+	})
+}
+
+// remediationAction returns the action of the health check policy
+func (m *OSDHealthMonitor) remediationAction() cephv1.OSDRemediationAction {
+	if m.healthCheck.Action == "" {
+		return cephv1.OSDRemediationNone
+	}
+	return m.healthCheck.Action
+}
+
+// remediateOSD applies the action of the health check policy to an unhealthy OSD
+func (m *OSDHealthMonitor) remediateOSD(osdID int) error {
+	switch m.remediationAction() {
+	case cephv1.OSDRemediationOut:
+		logger.Infof("marking unhealthy osd.%d out", osdID)
+		if output, err := client.OSDOut(m.context, m.namespace, osdID); err != nil {
+			return errors.Wrapf(err, "failed to mark osd.%d out. %s", osdID, output)
+		}
+	case cephv1.OSDRemediationNoUp:
+		logger.Infof("setting noup on unhealthy osd.%d", osdID)
+		return client.SetOSDNoUp(m.context, m.namespace, osdID)
+	case cephv1.OSDRemediationNone:
+	default:
+		return errors.Errorf("unknown osd remediation action %q", m.healthCheck.Action)
+	}
+	return nil
+}
+
+// recordEvent records a warning event on the CephCluster
+func (m *OSDHealthMonitor) recordEvent(reason, message string) {
+	if m.context.RookClientset == nil || m.clusterName == "" {
+		return
+	}
+	cluster, err := m.context.RookClientset.CephV1().CephClusters(m.namespace).Get(m.clusterName, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get cluster %q to record event %q. %v", m.clusterName, reason, err)
+		return
+	}
+	object := v1.ObjectReference{
+		APIVersion: cephv1.SchemeGroupVersion.String(),
+		Kind:       "CephCluster",
+		Name:       cluster.Name,
+		Namespace:  cluster.Namespace,
+		UID:        cluster.UID,
+	}
+	if err := k8sutil.CreateEvent(m.context.Clientset, object, v1.EventTypeWarning, reason, message); err != nil {
+		logger.Warningf("%v", err)
+	}
+}
+
+// exportCondition reports the unhealthy OSDs in the conditions of the CephCluster
+func (m *OSDHealthMonitor) exportCondition(status v1.ConditionStatus, reason, message string) {
+	if m.context.RookClientset == nil || m.clusterName == "" {
+		return
+	}
+	opconfig.ConditionExport(m.context, m.namespace, m.clusterName, cephv1.ConditionOSDUnhealthy, status, reason, message)
+}
+
+// parseHealthCheckDuration parses a duration of the health check policy, the default is used if it is not valid
+func parseHealthCheckDuration(value string, defaultValue time.Duration) time.Duration {
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		logger.Warningf("invalid duration %q in the osd health check, using %s. %v", value, defaultValue, err)
+		return defaultValue
+	}
+	return d
+}
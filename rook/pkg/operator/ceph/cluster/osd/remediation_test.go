@@ -0,0 +1,186 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	testexec "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckUnhealthyOSDs(t *testing.T) {
+	clientset := testexec.New(t, 1)
+	rookClientset := rookclient.NewSimpleClientset(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "ns"}})
+
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			commands = append(commands, strings.Join(args[0:3], " "))
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: clientset, RookClientset: rookClientset}
+	m := NewOSDHealthMonitor(context, "ns", "mycluster", false, cephver.CephVersion{},
+		cephv1.OSDHealthCheckSpec{Action: cephv1.OSDRemediationNoUp, FlapThreshold: 2, FlapWindow: "1h", MaxRemediatedOSDs: 2})
+
+	dump := func(up, upFrom int) *client.OSDDump {
+		osdDump := &client.OSDDump{}
+		assert.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(`{"osds":[{"osd":0,"up":%d,"in":1,"up_from":%d},{"osd":1,"up":1,"in":1,"up_from":5}]}`, up, upFrom)), osdDump))
+		return osdDump
+	}
+
+	// osd.0 goes down and up again between two checks, then goes down
+	m.checkUnhealthyOSDs(dump(1, 10))
+	m.checkUnhealthyOSDs(dump(1, 12))
+	assert.Equal(t, 0, len(commands))
+	m.checkUnhealthyOSDs(dump(0, 12))
+	assert.Equal(t, []string{"osd add-noup osd.0"}, commands)
+	events, err := clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events.Items))
+	assert.Equal(t, osdFlappingReason, events.Items[0].Reason)
+	assert.Equal(t, "CephCluster", events.Items[0].InvolvedObject.Kind)
+	cluster, err := rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	condition := findOSDUnhealthyCondition(cluster)
+	assert.NotNil(t, condition)
+	assert.Equal(t, v1.ConditionTrue, condition.Status)
+	// the phase of the cluster is not changed by the condition
+	assert.NotEqual(t, cephv1.ConditionOSDUnhealthy, cluster.Status.Phase)
+
+	// the osd is only remediated once
+	m.checkUnhealthyOSDs(dump(0, 12))
+	assert.Equal(t, 1, len(commands))
+
+	// the pod of osd.1 is in CrashLoopBackOff for longer than the timeout
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-1-abc", Namespace: "ns", Labels: map[string]string{"app": AppName, OsdIdLabelKey: "1"}}}
+	pod.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "osd", RestartCount: 20,
+		State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: crashLoopBackOffReason}}}}
+	_, err = clientset.CoreV1().Pods("ns").Create(pod)
+	assert.NoError(t, err)
+	m.checkUnhealthyOSDs(dump(0, 12))
+	assert.Equal(t, 1, len(commands))
+	m.crashLoopingSince[1] = time.Now().Add(-time.Hour)
+	m.healthCheck.Action = cephv1.OSDRemediationOut
+	m.checkUnhealthyOSDs(dump(0, 12))
+	assert.Equal(t, []string{"osd add-noup osd.0", "osd out 1"}, commands)
+	events, err = clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(events.Items))
+	cluster, err = rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1}, cluster.Status.Storage.RemediatedOSDs)
+
+	// the osds are healthy again once the flaps are out of the window and the pod is running
+	assert.NoError(t, clientset.CoreV1().Pods("ns").Delete(pod.Name, &metav1.DeleteOptions{}))
+	m.healthCheck.FlapWindow = "1ns"
+	m.checkUnhealthyOSDs(dump(0, 12))
+	assert.Equal(t, 0, len(m.unhealthyOSDs))
+	cluster, err = rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, v1.ConditionFalse, findOSDUnhealthyCondition(cluster).Status)
+}
+
+func TestRemediatedOSDsLimit(t *testing.T) {
+	clientset := testexec.New(t, 1)
+	// osd.2 was marked out by the health check before the operator restarted
+	rookClientset := rookclient.NewSimpleClientset(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "ns"},
+		Status: cephv1.ClusterStatus{Storage: &cephv1.StorageStatus{RemediatedOSDs: []int{2}}}})
+
+	osd2 := `"up":0,"in":0`
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			switch args[1] {
+			case "dump":
+				return fmt.Sprintf(`{"osds":[{"osd":0,"up":1,"in":1,"up_from":5},{"osd":1,"up":1,"in":1,"up_from":5},{"osd":2,%s,"up_from":5}]}`, osd2), nil
+			case "safe-to-destroy":
+				commands = append(commands, "osd safe-to-destroy")
+				return `{"safe_to_destroy":[2],"active":[],"missing_stats":[],"stored_pgs":[]}`, nil
+			}
+			commands = append(commands, strings.Join(args[0:3], " "))
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: clientset, RookClientset: rookClientset}
+	deployment := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-2", Namespace: "ns", Labels: map[string]string{OsdIdLabelKey: "2"}}}
+	_, err := clientset.AppsV1().Deployments("ns").Create(deployment)
+	assert.NoError(t, err)
+	m := NewOSDHealthMonitor(context, "ns", "mycluster", true, cephver.CephVersion{Major: 14},
+		cephv1.OSDHealthCheckSpec{Action: cephv1.OSDRemediationOut})
+
+	// the pod of osd.1 is in CrashLoopBackOff for longer than the timeout
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-1-abc", Namespace: "ns", Labels: map[string]string{"app": AppName, OsdIdLabelKey: "1"}}}
+	pod.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "osd", RestartCount: 20,
+		State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: crashLoopBackOffReason}}}}
+	_, err = clientset.CoreV1().Pods("ns").Create(pod)
+	assert.NoError(t, err)
+	m.crashLoopingSince[1] = time.Now().Add(-time.Hour)
+
+	// osd.1 is not remediated since osd.2 is remediated, and osd.2 is not removed although it is out
+	assert.NoError(t, m.checkOSDHealth())
+	assert.Equal(t, 0, len(commands))
+	assert.Equal(t, map[int]bool{2: true}, m.remediatedOSDs)
+	events, err := clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events.Items))
+	assert.Contains(t, events.Items[0].Message, "the osd was not remediated")
+	dp, err := clientset.AppsV1().Deployments("ns").Get(deployment.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotNil(t, dp)
+
+	// the limit is only reported once
+	assert.NoError(t, m.checkOSDHealth())
+	assert.Equal(t, 0, len(commands))
+	events, err = clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events.Items))
+
+	// osd.2 is up and in again, so osd.1 is remediated
+	osd2 = `"up":1,"in":1`
+	assert.NoError(t, m.checkOSDHealth())
+	assert.Equal(t, []string{"osd out 1"}, commands)
+	assert.Equal(t, map[int]bool{1: true}, m.remediatedOSDs)
+	cluster, err := rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, cluster.Status.Storage.RemediatedOSDs)
+}
+
+func findOSDUnhealthyCondition(cluster *cephv1.CephCluster) *cephv1.Condition {
+	for i := range cluster.Status.Conditions {
+		if cluster.Status.Conditions[i].Type == cephv1.ConditionOSDUnhealthy {
+			return &cluster.Status.Conditions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	deviceSetPhaseDraining        = "Draining"
+	deviceSetPhaseWaitingForClean = "WaitingForClean"
+	deviceSetPhasePurging         = "Purging"
+	deviceSetPhaseCompleted       = "Completed"
+	deviceSetPhaseFailed          = "Failed"
+)
+
+var (
+	// how long to wait for the PGs to be active+clean after the OSDs of a device set were reweighted to zero
+	scaleDownCleanRetries  = 60
+	scaleDownCleanInterval = 10 * time.Second
+)
+
+// scaleDownStorageClassDeviceSets removes the OSDs of the storageClassDeviceSets whose count was lowered. The OSDs
+// with the highest set index are removed first, one at a time, after their data was migrated to the other OSDs.
+func (c *Cluster) scaleDownStorageClassDeviceSets(config *provisionConfig) {
+	for _, set := range c.DesiredStorage.StorageClassDeviceSets {
+		indexes, err := c.getDeviceSetPVCs(set.Name)
+		if err != nil {
+			config.addError("failed to list pvcs of storageClassDeviceSet %q. %v", set.Name, err)
+			continue
+		}
+
+		var removed []int
+		for index := range indexes {
+			if index >= set.Count {
+				removed = append(removed, index)
+			}
+		}
+		if len(removed) == 0 {
+			continue
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(removed)))
+		logger.Infof("scaling down storageClassDeviceSet %q from %d to %d", set.Name, len(indexes), set.Count)
+
+		count := len(indexes)
+		for _, index := range removed {
+			if err := c.removeDeviceSetIndex(set, index, indexes[index], count); err != nil {
+				config.addError("failed to remove index %d of storageClassDeviceSet %q. %v", index, set.Name, err)
+				break
+			}
+			count--
+		}
+		if count == set.Count {
+			c.updateDeviceSetStatus(set, count, deviceSetPhaseCompleted, nil, fmt.Sprintf("scaled down to %d", set.Count))
+		}
+	}
+}
+
+// getDeviceSetPVCs returns the names of the PVCs of the storageClassDeviceSet indexed by their set index
+func (c *Cluster) getDeviceSetPVCs(setName string) (map[int][]string, error) {
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", CephDeviceSetLabelKey, setName)}
+	pvcs, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).List(listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := map[int][]string{}
+	for _, pvc := range pvcs.Items {
+		index, err := strconv.Atoi(pvc.Labels[CephSetIndexLabelKey])
+		if err != nil {
+			logger.Warningf("skipping pvc %q with invalid set index %q", pvc.Name, pvc.Labels[CephSetIndexLabelKey])
+			continue
+		}
+		indexes[index] = append(indexes[index], pvc.Name)
+	}
+	return indexes, nil
+}
+
+// removeDeviceSetIndex drains, purges and deletes the OSDs running on the PVCs of a device set index
+func (c *Cluster) removeDeviceSetIndex(set rookv1.StorageClassDeviceSet, index int, pvcNames []string, count int) error {
+	osdIDs := []int{}
+	deployments := []string{}
+	for _, pvcName := range pvcNames {
+		listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", OSDOverPVCLabelKey, pvcName)}
+		osdDeployments, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).List(listOpts)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list osd deployments for pvc %q", pvcName)
+		}
+		for _, d := range osdDeployments.Items {
+			osdID, err := strconv.Atoi(d.Labels[OsdIdLabelKey])
+			if err != nil {
+				return errors.Wrapf(err, "failed to parse osd id of deployment %q", d.Name)
+			}
+			osdIDs = append(osdIDs, osdID)
+			deployments = append(deployments, d.Name)
+		}
+	}
+
+	c.updateDeviceSetStatus(set, count, deviceSetPhaseDraining, osdIDs, fmt.Sprintf("reweighting osds of index %d to zero", index))
+	for _, osdID := range osdIDs {
+		if err := client.CrushReweight(c.context, c.Namespace, osdID, 0); err != nil {
+			c.updateDeviceSetStatus(set, count, deviceSetPhaseFailed, osdIDs, err.Error())
+			return err
+		}
+	}
+
+	if len(osdIDs) > 0 {
+		c.updateDeviceSetStatus(set, count, deviceSetPhaseWaitingForClean, osdIDs, "waiting for all pgs to be active+clean")
+		err := util.Retry(scaleDownCleanRetries, scaleDownCleanInterval, func() error {
+			return client.IsClusterCleanError(c.context, c.Namespace)
+		})
+		if err != nil {
+			// the osds are left reweighted, the next orchestration will keep waiting for the data to be migrated
+			c.updateDeviceSetStatus(set, count, deviceSetPhaseWaitingForClean, osdIDs, fmt.Sprintf("pgs are not active+clean yet. %v", err))
+			return errors.Wrapf(err, "failed to wait for pgs to be active+clean")
+		}
+	}
+
+	c.updateDeviceSetStatus(set, count, deviceSetPhasePurging, osdIDs, fmt.Sprintf("purging osds of index %d", index))
+	if err := c.purgeDeviceSetOSDs(osdIDs, deployments, pvcNames); err != nil {
+		c.updateDeviceSetStatus(set, count, deviceSetPhaseFailed, osdIDs, err.Error())
+		return err
+	}
+
+	logger.Infof("removed osds %v of index %d of storageClassDeviceSet %q", osdIDs, index, set.Name)
+	return nil
+}
+
+// purgeDeviceSetOSDs removes the drained OSDs from the cluster and deletes their deployments and PVCs
+func (c *Cluster) purgeDeviceSetOSDs(osdIDs []int, deployments, pvcNames []string) error {
+	for _, osdID := range osdIDs {
+		if output, err := client.OSDOut(c.context, c.Namespace, osdID); err != nil {
+			return errors.Wrapf(err, "failed to mark osd.%d out. %s", osdID, output)
+		}
+	}
+
+	for _, name := range deployments {
+		if err := k8sutil.DeleteDeployment(c.context.Clientset, c.Namespace, name); err != nil {
+			return errors.Wrapf(err, "failed to delete osd deployment %q", name)
+		}
+	}
+
+	for _, osdID := range osdIDs {
+		if output, err := client.OSDDown(c.context, c.Namespace, osdID); err != nil {
+			return errors.Wrapf(err, "failed to mark osd.%d down. %s", osdID, output)
+		}
+		if err := client.PurgeOSD(c.context, c.Namespace, osdID); err != nil {
+			return err
+		}
+	}
+
+	for _, pvcName := range pvcNames {
+		err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Delete(pvcName, &metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete pvc %q", pvcName)
+		}
+	}
+	return nil
+}
+
+// updateDeviceSetStatus reports the progress of the scale-down of a device set in the CephCluster status
+func (c *Cluster) updateDeviceSetStatus(set rookv1.StorageClassDeviceSet, count int, phase string, osdIDs []int, message string) {
+	status := cephv1.DeviceSetStatus{
+		Name:         set.Name,
+		Count:        count,
+		DesiredCount: set.Count,
+		Phase:        phase,
+		Message:      message,
+		OSDs:         osdIDs,
+		LastUpdated:  time.Now().UTC().Format(time.RFC3339),
+	}
+	c.updateStorageStatus(func(storage *cephv1.StorageStatus) {
+		for i := range storage.DeviceSets {
+			if storage.DeviceSets[i].Name == set.Name {
+				storage.DeviceSets[i] = status
+				return
+			}
+		}
+		storage.DeviceSets = append(storage.DeviceSets, status)
+	})
+}
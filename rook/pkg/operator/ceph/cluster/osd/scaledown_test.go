@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	testexec "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestScaleDownStorageClassDeviceSets(t *testing.T) {
+	scaleDownCleanRetries = 1
+	scaleDownCleanInterval = time.Millisecond
+	clientset := testexec.New(t, 1)
+	rookClientset := rookclient.NewSimpleClientset(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "ns"}})
+
+	// three indexes of the set are provisioned, each with an osd deployment
+	for i := 0; i < 3; i++ {
+		pvcName := fmt.Sprintf("set1-data-%d", i)
+		pvc := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+			Name:      pvcName,
+			Namespace: "ns",
+			Labels:    makeStorageClassDeviceSetPVCLabel("set1", pvcName, i),
+		}}
+		_, err := clientset.CoreV1().PersistentVolumeClaims("ns").Create(pvc)
+		assert.NoError(t, err)
+
+		d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(osdAppNameFmt, i),
+			Namespace: "ns",
+			Labels:    map[string]string{OSDOverPVCLabelKey: pvcName, OsdIdLabelKey: fmt.Sprintf("%d", i)},
+		}}
+		_, err = clientset.AppsV1().Deployments("ns").Create(d)
+		assert.NoError(t, err)
+	}
+
+	clean := false
+	var reweighted, purged []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			if args[0] == "status" {
+				if clean {
+					return `{"pgmap":{"num_pgs":100,"pgs_by_state":[{"state_name":"active+clean","count":100}]}}`, nil
+				}
+				return `{"pgmap":{"num_pgs":100,"pgs_by_state":[{"state_name":"active+clean","count":90},{"state_name":"active+remapped+backfilling","count":10}]}}`, nil
+			}
+			if args[0] == "osd" && args[1] == "crush" && args[2] == "reweight" {
+				reweighted = append(reweighted, args[3])
+			}
+			if args[0] == "osd" && args[1] == "purge" {
+				purged = append(purged, args[2])
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: clientset, RookClientset: rookClientset}
+	c := &Cluster{context: context, Namespace: "ns", ownerRef: metav1.OwnerReference{Name: "mycluster"}}
+	c.DesiredStorage.StorageClassDeviceSets = []rookv1.StorageClassDeviceSet{{Name: "set1", Count: 1}}
+
+	// the pgs do not become clean, the osds are drained but not removed
+	config := c.newProvisionConfig()
+	c.scaleDownStorageClassDeviceSets(config)
+	assert.Equal(t, 1, len(config.errorMessages))
+	assert.Equal(t, []string{"osd.2"}, reweighted)
+	assert.Equal(t, 0, len(purged))
+	pvcs, err := clientset.CoreV1().PersistentVolumeClaims("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(pvcs.Items))
+
+	cluster, err := rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(cluster.Status.Storage.DeviceSets))
+	assert.Equal(t, deviceSetPhaseWaitingForClean, cluster.Status.Storage.DeviceSets[0].Phase)
+	assert.Equal(t, 3, cluster.Status.Storage.DeviceSets[0].Count)
+	assert.Equal(t, []int{2}, cluster.Status.Storage.DeviceSets[0].OSDs)
+
+	// the pgs are clean, the highest indexes are removed first
+	clean = true
+	reweighted = nil
+	config = c.newProvisionConfig()
+	c.scaleDownStorageClassDeviceSets(config)
+	assert.Equal(t, 0, len(config.errorMessages))
+	assert.Equal(t, []string{"osd.2", "osd.1"}, reweighted)
+	assert.Equal(t, []string{"2", "1"}, purged)
+
+	pvcs, err = clientset.CoreV1().PersistentVolumeClaims("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(pvcs.Items))
+	assert.Equal(t, "set1-data-0", pvcs.Items[0].Name)
+	deployments, err := clientset.AppsV1().Deployments("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(deployments.Items))
+
+	cluster, err = rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, deviceSetPhaseCompleted, cluster.Status.Storage.DeviceSets[0].Phase)
+	assert.Equal(t, 1, cluster.Status.Storage.DeviceSets[0].Count)
+
+	// nothing to remove when the count matches
+	purged = nil
+	config = c.newProvisionConfig()
+	c.scaleDownStorageClassDeviceSets(config)
+	assert.Equal(t, 0, len(config.errorMessages))
+	assert.Equal(t, 0, len(purged))
+}
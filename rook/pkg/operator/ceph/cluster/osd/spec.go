@@ -49,6 +49,7 @@ const (
 	cvModeVarName                       = "ROOK_CV_MODE"
 	lvBackedPVVarName                   = "ROOK_LV_BACKED_PV"
 	CrushDeviceClassVarName             = "ROOK_OSD_CRUSH_DEVICE_CLASS"
+	osdPVCSizeVarName                   = "ROOK_OSD_PVC_SIZE"
 	rookBinariesMountPath               = "/rook"
 	rookBinariesVolumeName              = "rook-binaries"
 	activateOSDVolumeName               = "activate-osd"
@@ -236,7 +237,7 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 	// If the OSD runs on PVC
 	if osdProps.onPVC() {
 		// add the PVC size to the pod spec so that if the size changes the OSD will be restarted and pick up the change
-		envVars = append(envVars, v1.EnvVar{Name: "ROOK_OSD_PVC_SIZE", Value: osdProps.pvcSize})
+		envVars = append(envVars, v1.EnvVar{Name: osdPVCSizeVarName, Value: osdProps.pvcSize})
 
 		// Append tuning flag if necessary
 		if osdProps.tuneSlowDeviceClass {
@@ -865,6 +866,14 @@ func (c *Cluster) provisionOSDContainer(osdProps osdProperties, copyBinariesMoun
 		envVars = append(envVars, metadataDeviceEnvVar(osdProps.metadataDevice))
 	}
 
+	if len(osdProps.migrateOSDIDs) > 0 {
+		ids := make([]string, len(osdProps.migrateOSDIDs))
+		for i, id := range osdProps.migrateOSDIDs {
+			ids[i] = strconv.Itoa(id)
+		}
+		envVars = append(envVars, v1.EnvVar{Name: migrateOSDIDsEnvVarName, Value: strings.Join(ids, ",")})
+	}
+
 	volumeMounts := append(controller.CephVolumeMounts(provisionConfig.DataPathMap, true), []v1.VolumeMount{
 		{Name: "devices", MountPath: "/dev"},
 		{Name: "udev", MountPath: "/run/udev"},
@@ -22,6 +22,8 @@ import (
 	"fmt"
 	"time"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/util"
@@ -46,8 +48,10 @@ const (
 )
 
 type provisionConfig struct {
-	errorMessages []string
-	DataPathMap   *config.DataPathMap // location to store data in container
+	errorMessages  []string
+	DataPathMap    *config.DataPathMap // location to store data in container
+	pendingUpdates []osdUpdate         // osd updates deferred to be applied by failure domain
+	migrations     map[string][]int    // destroyed osds to prepare again with bluestore, indexed by node name
 }
 
 func (c *Cluster) newProvisionConfig() *provisionConfig {
@@ -56,6 +60,18 @@ func (c *Cluster) newProvisionConfig() *provisionConfig {
 	}
 }
 
+// isMigrating returns whether the OSD was destroyed to be prepared again with bluestore
+func (c *provisionConfig) isMigrating(osdID int) bool {
+	for _, ids := range c.migrations {
+		for _, id := range ids {
+			if id == osdID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (c *provisionConfig) addError(message string, args ...interface{}) {
 	logger.Errorf(message, args...)
 	c.errorMessages = append(c.errorMessages, fmt.Sprintf(message, args...))
@@ -279,3 +295,43 @@ func (c *Cluster) handleStatusConfigMapStatus(nodeName string, config *provision
 	}
 	return false
 }
+
+// getStorageStatus returns the storage status of the CephCluster, or nil if it was not reported yet
+func (c *Cluster) getStorageStatus() *cephv1.StorageStatus {
+	if c.context.RookClientset == nil {
+		return nil
+	}
+
+	cluster, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Get(c.ownerRef.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get cluster %q to read the storage status. %v", c.ownerRef.Name, err)
+		return nil
+	}
+	return cluster.Status.Storage
+}
+
+// updateStorageStatus applies the given change to the storage status of the CephCluster
+func (c *Cluster) updateStorageStatus(update func(storage *cephv1.StorageStatus)) {
+	updateStorageStatus(c.context, c.Namespace, c.ownerRef.Name, update)
+}
+
+// updateStorageStatus applies the given change to the storage status of a CephCluster
+func updateStorageStatus(context *clusterd.Context, namespace, clusterName string, update func(storage *cephv1.StorageStatus)) {
+	if context.RookClientset == nil {
+		return
+	}
+
+	cluster, err := context.RookClientset.CephV1().CephClusters(namespace).Get(clusterName, metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get cluster %q to update the storage status. %v", clusterName, err)
+		return
+	}
+	if cluster.Status.Storage == nil {
+		cluster.Status.Storage = &cephv1.StorageStatus{}
+	}
+	update(cluster.Status.Storage)
+
+	if _, err := context.RookClientset.CephV1().CephClusters(namespace).Update(cluster); err != nil {
+		logger.Warningf("failed to update the storage status of cluster %q. %v", clusterName, err)
+	}
+}
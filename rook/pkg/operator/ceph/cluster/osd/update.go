@@ -0,0 +1,226 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/banzaicloud/k8s-objectmatcher/patch"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultUpdateFailureDomain = "host"
+	nooutFlag                  = "noout"
+)
+
+var (
+	// how long to wait for the OSDs of a failure domain to be ok-to-stop
+	failureDomainOkToStopRetries  = 5
+	failureDomainOkToStopInterval = 60 * time.Second
+)
+
+// osdUpdate is an OSD deployment whose update is deferred to be applied with the other OSDs of its failure domain
+type osdUpdate struct {
+	osdID   int
+	desired *apps.Deployment
+	current *apps.Deployment
+}
+
+// updateByFailureDomain returns whether all the OSDs of a failure domain are updated at the same time
+func (c *Cluster) updateByFailureDomain() bool {
+	return c.updateStrategy.Type == cephv1.OSDUpdateFailureDomain
+}
+
+// updateOSDDeployment updates the deployment of an existing OSD. With the failure domain strategy, the update is
+// deferred until all the OSDs are started so the OSDs of a failure domain can be updated together. The OSDs prepared
+// again with bluestore are stopped and must be started before the migration waits for their recovery.
+func (c *Cluster) updateOSDDeployment(config *provisionConfig, desired *apps.Deployment, osdID int) error {
+	if c.updateByFailureDomain() && !config.isMigrating(osdID) {
+		config.pendingUpdates = append(config.pendingUpdates, osdUpdate{osdID: osdID, desired: desired})
+		return nil
+	}
+	return updateDeploymentAndWait(c.context, desired, c.Namespace, opconfig.OsdType, strconv.Itoa(osdID), c.skipUpgradeChecks, c.continueUpgradeAfterChecksEvenIfNotHealthy)
+}
+
+// updateOSDsByFailureDomain applies the deferred OSD updates. All the OSDs of up to maxParallelDomains failure domains
+// are restarted at the same time, and the next failure domains are only updated once the PGs are clean again.
+func (c *Cluster) updateOSDsByFailureDomain(config *provisionConfig) {
+	if len(config.pendingUpdates) == 0 {
+		return
+	}
+
+	failureDomain := c.updateStrategy.FailureDomain
+	if failureDomain == "" {
+		failureDomain = defaultUpdateFailureDomain
+	}
+	maxParallelDomains := c.updateStrategy.MaxParallelDomains
+	if maxParallelDomains < 1 {
+		maxParallelDomains = 1
+	}
+
+	domains, err := c.groupUpdatesByFailureDomain(config.pendingUpdates, failureDomain)
+	if err != nil {
+		config.addError("failed to group the osd updates by %s. %v", failureDomain, err)
+		return
+	}
+	if len(domains) == 0 {
+		logger.Infof("no osd deployment changed, nothing to update")
+		return
+	}
+
+	names := []string{}
+	for name := range domains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i := 0; i < len(names); i += maxParallelDomains {
+		end := i + maxParallelDomains
+		if end > len(names) {
+			end = len(names)
+		}
+		buckets := names[i:end]
+		updates := []osdUpdate{}
+		for _, bucket := range buckets {
+			updates = append(updates, domains[bucket]...)
+		}
+
+		if err := c.updateFailureDomains(buckets, updates); err != nil {
+			// do not move on to the next failure domains if the osds of the current ones are not healthy
+			config.addError("failed to update the osds of %s %v. %v", failureDomain, buckets, err)
+			return
+		}
+	}
+}
+
+// groupUpdatesByFailureDomain returns the OSD updates that change the deployment, indexed by the name of the CRUSH
+// bucket of the failure domain the OSD belongs to
+func (c *Cluster) groupUpdatesByFailureDomain(updates []osdUpdate, failureDomain string) (map[string][]osdUpdate, error) {
+	domains := map[string][]osdUpdate{}
+	for _, update := range updates {
+		current, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(update.desired.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get deployment %q", update.desired.Name)
+		}
+		patchResult, err := patch.DefaultPatchMaker.Calculate(current, update.desired)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to calculate diff between current deployment %q and newly generated one", current.Name)
+		}
+		if patchResult.IsEmpty() {
+			logger.Debugf("deployment %q did not change, nothing to update", current.Name)
+			continue
+		}
+		update.current = current
+
+		location, err := client.FindOSDInCrushMap(c.context, c.Namespace, update.osdID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find the location of osd.%d", update.osdID)
+		}
+		bucket, ok := location.Location[failureDomain]
+		if !ok || bucket == "" {
+			// the osd is not under a bucket of the failure domain type, update it with the other osds of its host
+			logger.Warningf("osd.%d has no %s in its crush location %v, updating it by host", update.osdID, failureDomain, location.Location)
+			bucket, ok = location.Location[defaultUpdateFailureDomain]
+			if !ok || bucket == "" {
+				return nil, errors.Errorf("osd.%d has no host in its crush location %v", update.osdID, location.Location)
+			}
+		}
+		domains[bucket] = append(domains[bucket], update)
+	}
+	return domains, nil
+}
+
+// updateFailureDomains restarts all the OSDs of the failure domains at the same time with the noout flag set on
+// their CRUSH buckets, and waits for the PGs to be clean before returning
+func (c *Cluster) updateFailureDomains(buckets []string, updates []osdUpdate) error {
+	osdIDs := []int{}
+	for _, update := range updates {
+		osdIDs = append(osdIDs, update.osdID)
+	}
+	logger.Infof("updating osds %v of failure domains %v", osdIDs, buckets)
+
+	if !c.skipUpgradeChecks {
+		err := util.Retry(failureDomainOkToStopRetries, failureDomainOkToStopInterval, func() error {
+			return client.OkToStopOSDs(c.context, c.Namespace, osdIDs)
+		})
+		if err != nil {
+			if !c.continueUpgradeAfterChecksEvenIfNotHealthy {
+				return errors.Wrapf(err, "failed to check if osds %v can be updated", osdIDs)
+			}
+			logger.Infof("osds %v are not ok-to-stop but 'continueUpgradeAfterChecksEvenIfNotHealthy' is true, so proceeding to stop...", osdIDs)
+		}
+	}
+
+	// keep ceph from marking the osds out and rebalancing their data while they restart
+	osdDump, err := client.GetOSDDump(c.context, c.Namespace)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get osd dump")
+	}
+	for _, bucket := range buckets {
+		if osdDump.IsFlagSetOnCrushUnit(nooutFlag, bucket) {
+			// the flag is owned by someone else, for example the disruption controller
+			continue
+		}
+		if err := client.SetFlagOnCrushUnit(c.context, c.Namespace, bucket, nooutFlag); err != nil {
+			return err
+		}
+		defer func(bucket string) {
+			if err := client.UnsetFlagOnCrushUnit(c.context, c.Namespace, bucket, nooutFlag); err != nil {
+				logger.Errorf("failed to unset the noout flag on %q. %v", bucket, err)
+			}
+		}(bucket)
+	}
+
+	for _, update := range updates {
+		if err := patch.DefaultAnnotator.SetLastAppliedAnnotation(update.desired); err != nil {
+			return errors.Wrapf(err, "failed to set hash annotation on deployment %q", update.desired.Name)
+		}
+		logger.Infof("updating deployment %q", update.desired.Name)
+		if _, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Update(update.desired); err != nil {
+			return errors.Wrapf(err, "failed to update deployment %q", update.desired.Name)
+		}
+	}
+
+	for _, update := range updates {
+		if _, err := k8sutil.WaitForDeploymentUpdate(c.context.Clientset, c.Namespace, update.current); err != nil {
+			return err
+		}
+	}
+
+	if !c.skipUpgradeChecks {
+		update := updates[0]
+		if err := client.OkToContinue(c.context, c.Namespace, update.desired.Name, opconfig.OsdType, strconv.Itoa(update.osdID)); err != nil {
+			if !c.continueUpgradeAfterChecksEvenIfNotHealthy {
+				return errors.Wrapf(err, "failed to check if the update can continue after failure domains %v", buckets)
+			}
+			logger.Infof("failure domains %v are not clean but 'continueUpgradeAfterChecksEvenIfNotHealthy' is true, so continuing...", buckets)
+		}
+	}
+
+	logger.Infof("finished updating osds %v of failure domains %v", osdIDs, buckets)
+	return nil
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	testexec "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateOSDsByFailureDomain(t *testing.T) {
+	clientset := testexec.New(t, 1)
+	racks := map[string]string{"0": "rack-a", "1": "rack-a", "2": "rack-b", "3": "rack-c"}
+
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			switch {
+			case args[0] == "osd" && args[1] == "find":
+				return fmt.Sprintf(`{"osd":%s,"crush_location":{"host":"node-%s","rack":"%s","root":"default"}}`, args[2], args[2], racks[args[2]]), nil
+			case args[0] == "osd" && args[1] == "dump":
+				return `{"flags":"","crush_node_flags":{"rack-c":["noout"]}}`, nil
+			case args[0] == "osd" && (args[1] == "set-group" || args[1] == "unset-group"):
+				commands = append(commands, strings.Join(args[1:4], " "))
+			}
+			return "", nil
+		},
+	}
+	c := &Cluster{context: &clusterd.Context{Executor: executor, Clientset: clientset}, Namespace: "ns", skipUpgradeChecks: true,
+		updateStrategy: cephv1.OSDUpdateStrategySpec{Type: cephv1.OSDUpdateFailureDomain, FailureDomain: "rack", MaxParallelDomains: 2}}
+	assert.True(t, c.updateByFailureDomain())
+
+	config := c.newProvisionConfig()
+	for i := 0; i < 4; i++ {
+		d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf(osdAppNameFmt, i), Namespace: "ns"}}
+		d.Spec.Template.Spec.Containers = []v1.Container{{Name: "osd", Image: "ceph/ceph:v14.2.8"}}
+		d.Status.ObservedGeneration = 1
+		_, err := clientset.AppsV1().Deployments("ns").Create(d)
+		assert.NoError(t, err)
+
+		desired := d.DeepCopy()
+		if i != 1 {
+			// osd.1 does not change
+			desired.Spec.Template.Spec.Containers[0].Image = "ceph/ceph:v14.2.9"
+			// the fake clientset stores the status of the updated deployment as if it was rolled out
+			desired.Status = apps.DeploymentStatus{ObservedGeneration: 2, UpdatedReplicas: 1, ReadyReplicas: 1}
+		}
+		// the update is deferred
+		assert.NoError(t, c.updateOSDDeployment(config, desired, i))
+	}
+	assert.Equal(t, 4, len(config.pendingUpdates))
+
+	domains, err := c.groupUpdatesByFailureDomain(config.pendingUpdates, "rack")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(domains))
+	assert.Equal(t, 1, len(domains["rack-a"]))
+	assert.Equal(t, 0, domains["rack-a"][0].osdID)
+
+	// the osds under a bucket type that does not exist are grouped by host
+	domains, err = c.groupUpdatesByFailureDomain(config.pendingUpdates, "zone")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(domains))
+	assert.Equal(t, 1, len(domains["node-3"]))
+
+	// two racks are updated together, the noout flag already set on rack-c is left untouched
+	c.updateOSDsByFailureDomain(config)
+	assert.Equal(t, 0, len(config.errorMessages))
+	assert.Equal(t, []string{"set-group noout rack-a", "set-group noout rack-b", "unset-group noout rack-b", "unset-group noout rack-a"}, commands)
+	for i := 0; i < 4; i++ {
+		d, err := clientset.AppsV1().Deployments("ns").Get(fmt.Sprintf(osdAppNameFmt, i), metav1.GetOptions{})
+		assert.NoError(t, err)
+		if i == 1 {
+			assert.Equal(t, "ceph/ceph:v14.2.8", d.Spec.Template.Spec.Containers[0].Image)
+		} else {
+			assert.Equal(t, "ceph/ceph:v14.2.9", d.Spec.Template.Spec.Containers[0].Image)
+		}
+	}
+
+	// the osds are updated one by one by default
+	c.updateStrategy = cephv1.OSDUpdateStrategySpec{}
+	assert.False(t, c.updateByFailureDomain())
+}
+
+func TestUpdateMigratedOSDDeployment(t *testing.T) {
+	updated := []string{}
+	updateDeploymentAndWait = func(context *clusterd.Context, deployment *apps.Deployment, namespace, daemonType, daemonName string, skipUpgradeChecks, continueUpgradeAfterChecksEvenIfNotHealthy bool) error {
+		updated = append(updated, daemonName)
+		return nil
+	}
+	defer func() { updateDeploymentAndWait = mon.UpdateCephDeploymentAndWait }()
+
+	c := &Cluster{Namespace: "ns", updateStrategy: cephv1.OSDUpdateStrategySpec{Type: cephv1.OSDUpdateFailureDomain}}
+	config := c.newProvisionConfig()
+	config.migrations = map[string][]int{"node-a": {1}}
+	for i := 0; i < 2; i++ {
+		d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf(osdAppNameFmt, i), Namespace: "ns"}}
+		assert.NoError(t, c.updateOSDDeployment(config, d, i))
+	}
+
+	// the osd prepared again with bluestore is started right away, the other update is deferred
+	assert.Equal(t, []string{"1"}, updated)
+	assert.Equal(t, 1, len(config.pendingUpdates))
+	assert.Equal(t, 0, config.pendingUpdates[0].osdID)
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/crash"
 	"github.com/rook/rook/pkg/operator/ceph/file"
+	"github.com/rook/rook/pkg/operator/ceph/file/subvolumegroup"
 	"github.com/rook/rook/pkg/operator/ceph/nfs"
 	"github.com/rook/rook/pkg/operator/ceph/object"
 	objectuser "github.com/rook/rook/pkg/operator/ceph/object/user"
@@ -36,6 +37,7 @@ var AddToManagerFuncs = []func(manager.Manager, *clusterd.Context) error{
 	objectuser.Add,
 	object.Add,
 	file.Add,
+	subvolumegroup.Add,
 	nfs.Add,
 }
 
@@ -70,7 +70,7 @@ func setCondition(context *clusterd.Context, namespace, name string, newConditio
 	}
 	cluster.Status.Conditions = *conditions
 
-	if newCondition.Status == v1.ConditionTrue {
+	if newCondition.Status == v1.ConditionTrue && isPhaseCondition(newCondition.Type) {
 		cluster.Status.Phase = newCondition.Type
 		if state := translatePhasetoState(newCondition.Type); state != "" {
 			cluster.Status.State = state
@@ -87,6 +87,12 @@ func setCondition(context *clusterd.Context, namespace, name string, newConditio
 	}
 }
 
+// isPhaseCondition returns whether the condition sets the phase of the cluster when it is true. The conditions
+// reporting the health of the daemons do not.
+func isPhaseCondition(conditionType cephv1.ConditionType) bool {
+	return conditionType != cephv1.ConditionOSDUnhealthy
+}
+
 // translatePhasetoState convert the Phases to corresponding State
 // 1. We still need to set the State in case someone is still using it
 // instead of Phase. If we stopped setting the State it would be a
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+// ParseMemoryRatio parses the ratio of a memory limit, such as "0.8". The default ratio is returned if the
+// ratio is empty.
+func ParseMemoryRatio(ratio string, defaultRatio float64) (float64, error) {
+	if ratio == "" {
+		return defaultRatio, nil
+	}
+	r, err := strconv.ParseFloat(ratio, 64)
+	if err != nil || r <= 0 || r > 1 {
+		return 0, errors.Errorf("invalid memory ratio %q, it must be greater than 0 and at most 1", ratio)
+	}
+	return r, nil
+}
+
+// MemoryFromLimit returns the bytes of the ratio of the memory limit of a container, or 0 if the container has
+// no memory limit
+func MemoryFromLimit(resources v1.ResourceRequirements, ratio float64) int64 {
+	limit := resources.Limits.Memory()
+	if limit.IsZero() {
+		return 0
+	}
+	return int64(float64(limit.Value()) * ratio)
+}
+
+// SetDerived sets the options derived from the resources of a daemon in the mon config store, and removes the
+// options that were previously derived and no longer are
+func (m *MonStore) SetDerived(who string, derived, previous map[string]string) error {
+	for option, value := range derived {
+		if err := m.Set(who, option, value); err != nil {
+			return errors.Wrapf(err, "failed to set derived option %q to %q on %q", option, value, who)
+		}
+	}
+	for option := range previous {
+		if _, ok := derived[option]; ok {
+			continue
+		}
+		if err := m.Delete(who, option); err != nil {
+			return errors.Wrapf(err, "failed to remove derived option %q from %q", option, who)
+		}
+		logger.Infof("removed derived option %q from %q", option, who)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestParseMemoryRatio(t *testing.T) {
+	r, err := ParseMemoryRatio("", 0.8)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.8, r)
+
+	r, err = ParseMemoryRatio("0.25", 0.8)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.25, r)
+
+	for _, ratio := range []string{"0", "-0.5", "1.5", "50%", "half"} {
+		_, err = ParseMemoryRatio(ratio, 0.8)
+		assert.Error(t, err, ratio)
+	}
+}
+
+func TestMemoryFromLimit(t *testing.T) {
+	assert.Equal(t, int64(0), MemoryFromLimit(v1.ResourceRequirements{}, 0.5))
+
+	resources := v1.ResourceRequirements{
+		Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("4Gi")},
+	}
+	assert.Equal(t, int64(2*1024*1024*1024), MemoryFromLimit(resources, 0.5))
+}
+
+func TestMonStore_SetDerived(t *testing.T) {
+	calls := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outfile string, args ...string) (string, error) {
+			calls = append(calls, strings.Join(args[:4], " "))
+			return "", nil
+		},
+	}
+	monStore := GetMonStore(&clusterd.Context{Executor: executor}, "ns")
+
+	err := monStore.SetDerived("mds.a", map[string]string{"mds_cache_memory_limit": "1024"},
+		map[string]string{"mds_cache_memory_limit": "512", "mds_other": "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"config set mds.a mds_cache_memory_limit",
+		"config rm mds.a mds_other",
+	}, calls)
+}
@@ -103,11 +103,15 @@ func (m *MonStore) GetDaemon(who string) ([]Option, error) {
 	}
 	daemonOptions := []Option{}
 	for k := range result {
-		v := result[k].(map[string]interface{})
-		optionWho := v["section"].(string)
+		v, ok := result[k].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		optionWho, _ := v["section"].(string)
+		value, _ := v["value"].(string)
 		// Only get specialized options (don't take global one)
 		if optionWho == who {
-			daemonOptions = append(daemonOptions, Option{optionWho, k, v["value"].(string)})
+			daemonOptions = append(daemonOptions, Option{optionWho, k, value})
 		}
 	}
 	return daemonOptions, nil
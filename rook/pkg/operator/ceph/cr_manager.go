@@ -29,10 +29,10 @@ func (o *Operator) startManager(namespaceToWatch string, stopCh <-chan struct{})
 
 	// Set up a manager
 	mgrOpts := manager.Options{
-		LeaderElection: false,
-		Namespace:      namespaceToWatch,
+		LeaderElection:     false,
+		Namespace:          namespaceToWatch,
+		MetricsBindAddress: MetricsBindAddress,
 	}
-
 	logger.Info("setting up the controller-runtime manager")
 	kubeConfig, err := config.GetConfig()
 	if err != nil {
@@ -26,6 +26,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/nodedrain"
+	"github.com/rook/rook/pkg/operator/ceph/file/mds"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
@@ -192,7 +193,7 @@ func (r *ReconcileClusterDisruption) reconcileCephObjectStore(cephObjectStoreLis
 }
 
 // Setting naive minAvailable for MDS at: n -1
-// getting n from the cephfilesystem.spec.metadataserver.activecount
+// getting n from the cephfilesystem.spec.metadataserver.activecount, or the count chosen by the autoscaler
 func (r *ReconcileClusterDisruption) reconcileCephFilesystem(cephFilesystemList *cephv1.CephFilesystemList) error {
 	for _, filesystem := range cephFilesystemList.Items {
 		fsName := filesystem.ObjectMeta.Name
@@ -202,7 +203,7 @@ func (r *ReconcileClusterDisruption) reconcileCephFilesystem(cephFilesystemList
 			MatchLabels: map[string]string{"rook_file_system": fsName},
 		}
 
-		activeCount := filesystem.Spec.MetadataServer.ActiveCount
+		activeCount := mds.ActiveCount(&filesystem)
 		minAvailable := &intstr.IntOrString{IntVal: activeCount - 1}
 		if filesystem.Spec.MetadataServer.ActiveStandby {
 			minAvailable.IntVal++
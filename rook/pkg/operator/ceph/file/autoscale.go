@@ -0,0 +1,204 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/file/mds"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const (
+	mdsScaledReason = "MetadataServersScaled"
+	// the maximum number of active mds, as the active count of the CRD
+	maxActiveMDS = 10
+	// the default minimum time between two changes of the number of active mds
+	defaultMDSAutoscaleCooldown = 10 * time.Minute
+)
+
+// mdsAutoscaler scales the number of active mds of the filesystems having an autoscaling policy with their rate of
+// client requests. The number it chooses is recorded in the filesystem status, and the filesystem is reconciled to
+// set max_mds and the mds deployments to that number. It is run by the filesystem monitor.
+type mdsAutoscaler struct {
+	client  client.Client
+	context *clusterd.Context
+	events  chan event.GenericEvent
+}
+
+func newMDSAutoscaler(client client.Client, context *clusterd.Context) *mdsAutoscaler {
+	return &mdsAutoscaler{client: client, context: context, events: make(chan event.GenericEvent)}
+}
+
+// checkFilesystems checks the load of the ready filesystems having an autoscaling policy and returns the
+// filesystems whose number of active mds was changed
+func (a *mdsAutoscaler) checkFilesystems(filesystems []*cephv1.CephFilesystem, now time.Time) []*cephv1.CephFilesystem {
+	scaled := []*cephv1.CephFilesystem{}
+	for _, fs := range filesystems {
+		if fs.Spec.MetadataServer.Autoscale == nil {
+			continue
+		}
+		changed, err := a.checkFilesystem(fs, now)
+		if err != nil {
+			logger.Warningf("failed to autoscale the metadata servers of filesystem %q. %v", fs.Name, err)
+			continue
+		}
+		if changed {
+			scaled = append(scaled, fs)
+		}
+	}
+	return scaled
+}
+
+// checkFilesystem chooses the number of active mds of a filesystem from its rate of client requests, and
+// returns whether the number was changed
+func (a *mdsAutoscaler) checkFilesystem(fs *cephv1.CephFilesystem, now time.Time) (bool, error) {
+	spec := fs.Spec.MetadataServer.Autoscale
+	cooldown, err := mdsAutoscaleCooldown(spec)
+	if err != nil {
+		return false, err
+	}
+
+	// only scale once the ranks are as last chosen
+	current := mds.ActiveCount(fs)
+	details, err := cephclient.GetFilesystem(a.context, fs.Namespace, fs.Name)
+	if err != nil {
+		return false, err
+	}
+	if details.MDSMap.MaxMDS != int(current) || len(details.MDSMap.Up) != int(current) ||
+		len(details.MDSMap.Failed) != 0 || len(details.MDSMap.Damaged) != 0 {
+		logger.Debugf("filesystem %q doesn't have %d active ranks yet, not scaling", fs.Name, current)
+		return false, nil
+	}
+
+	fsStatus, err := cephclient.GetFilesystemStatus(a.context, fs.Namespace, fs.Name)
+	if err != nil {
+		return false, err
+	}
+	rate := fsStatus.RequestRate()
+
+	status := cephv1.MDSAutoscaleStatus{ActiveCount: current, RequestRate: int64(rate)}
+	if fs.Status.Autoscale != nil {
+		status.LastScaleTime = fs.Status.Autoscale.LastScaleTime
+	}
+	changed := false
+	if desired := desiredActiveCount(spec, current, rate); desired != current {
+		if inMDSAutoscaleCooldown(status.LastScaleTime, cooldown, now) {
+			logger.Debugf("filesystem %q would scale to %d active mds but was scaled less than %s ago", fs.Name, desired, cooldown)
+		} else {
+			status.ActiveCount = desired
+			status.LastScaleTime = now.UTC().Format(time.RFC3339)
+			changed = true
+		}
+	}
+
+	// the number of active mds is applied by the reconcile of the filesystem from the status, the filesystem is only
+	// scaled once the number is written
+	err = updateStatus(a.client, types.NamespacedName{Name: fs.Name, Namespace: fs.Namespace}, func(fsStatus *cephv1.CephFilesystemStatus) {
+		fsStatus.Autoscale = &status
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to save the autoscale status")
+	}
+
+	if changed {
+		message := fmt.Sprintf("scaling filesystem %q from %d to %d active metadata servers at %d requests per second per active metadata server",
+			fs.Name, current, status.ActiveCount, status.RequestRate)
+		logger.Infof("%s", message)
+		object := v1.ObjectReference{
+			APIVersion: cephv1.SchemeGroupVersion.String(),
+			Kind:       cephFilesystemKind,
+			Name:       fs.Name,
+			Namespace:  fs.Namespace,
+			UID:        fs.UID,
+		}
+		if err := k8sutil.CreateEvent(a.context.Clientset, object, v1.EventTypeNormal, mdsScaledReason, message); err != nil {
+			logger.Warningf("%v", err)
+		}
+	}
+	return changed, nil
+}
+
+// desiredActiveCount adds an active mds when the rate of requests per active mds is above the scale up rate and
+// removes one when the rate is below the scale down rate, within the bounds of the policy
+func desiredActiveCount(spec *cephv1.MDSAutoscaleSpec, current int32, rate float64) int32 {
+	min, max := mds.AutoscaleBounds(spec)
+	if rate > float64(spec.ScaleUpRequestRate) && current < max {
+		return current + 1
+	}
+	if rate < float64(spec.ScaleDownRequestRate) && current > min {
+		return current - 1
+	}
+	return current
+}
+
+func inMDSAutoscaleCooldown(lastScaleTime string, cooldown time.Duration, now time.Time) bool {
+	if lastScaleTime == "" {
+		return false
+	}
+	last, err := time.Parse(time.RFC3339, lastScaleTime)
+	if err != nil {
+		logger.Warningf("failed to parse the time of the last scaling %q. %v", lastScaleTime, err)
+		return false
+	}
+	return now.Sub(last) < cooldown
+}
+
+func mdsAutoscaleCooldown(spec *cephv1.MDSAutoscaleSpec) (time.Duration, error) {
+	if spec.Cooldown == "" {
+		return defaultMDSAutoscaleCooldown, nil
+	}
+	cooldown, err := time.ParseDuration(spec.Cooldown)
+	if err != nil || cooldown <= 0 {
+		return 0, errors.Errorf("invalid cooldown %q", spec.Cooldown)
+	}
+	return cooldown, nil
+}
+
+// validateMDSAutoscale checks the autoscaling policy of the metadata servers of the filesystem spec
+func validateMDSAutoscale(fs *cephv1.CephFilesystem) error {
+	spec := fs.Spec.MetadataServer.Autoscale
+	if spec == nil {
+		return nil
+	}
+	if spec.MinActive < 0 {
+		return errors.Errorf("invalid min active %d", spec.MinActive)
+	}
+	min, max := mds.AutoscaleBounds(spec)
+	if max < min || max > maxActiveMDS {
+		return errors.Errorf("invalid max active %d, it must be between %d and %d", max, min, maxActiveMDS)
+	}
+	if spec.ScaleUpRequestRate <= 0 {
+		return errors.Errorf("invalid scale up request rate %d, it must be positive", spec.ScaleUpRequestRate)
+	}
+	if spec.ScaleDownRequestRate < 0 || spec.ScaleDownRequestRate >= spec.ScaleUpRequestRate {
+		return errors.Errorf("invalid scale down request rate %d, it must be lower than the scale up request rate", spec.ScaleDownRequestRate)
+	}
+	if _, err := mdsAutoscaleCooldown(spec); err != nil {
+		return err
+	}
+	return nil
+}
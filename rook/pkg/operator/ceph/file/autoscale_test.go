@@ -0,0 +1,184 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newAutoscaleFilesystem(autoscale *cephv1.MDSAutoscaleSpec) *cephv1.CephFilesystem {
+	return &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
+		Spec: cephv1.FilesystemSpec{
+			MetadataServer: cephv1.MetadataServerSpec{ActiveCount: 1, Autoscale: autoscale},
+		},
+		Status: &cephv1.CephFilesystemStatus{Phase: k8sutil.ReadyStatus},
+	}
+}
+
+func TestValidateMDSAutoscale(t *testing.T) {
+	assert.NoError(t, validateMDSAutoscale(newAutoscaleFilesystem(nil)))
+	assert.NoError(t, validateMDSAutoscale(newAutoscaleFilesystem(&cephv1.MDSAutoscaleSpec{MaxActive: 3, ScaleUpRequestRate: 1000})))
+	assert.NoError(t, validateMDSAutoscale(newAutoscaleFilesystem(
+		&cephv1.MDSAutoscaleSpec{MinActive: 2, MaxActive: 2, ScaleUpRequestRate: 1000, ScaleDownRequestRate: 200, Cooldown: "1h"})))
+
+	invalid := []*cephv1.MDSAutoscaleSpec{
+		{MinActive: -1, MaxActive: 3, ScaleUpRequestRate: 1000},
+		{MinActive: 3, MaxActive: 2, ScaleUpRequestRate: 1000},
+		{MaxActive: 0, ScaleUpRequestRate: 1000},
+		{MaxActive: 11, ScaleUpRequestRate: 1000},
+		{MaxActive: 3},
+		{MaxActive: 3, ScaleUpRequestRate: 1000, ScaleDownRequestRate: 1000},
+		{MaxActive: 3, ScaleUpRequestRate: 1000, Cooldown: "10"},
+		{MaxActive: 3, ScaleUpRequestRate: 1000, Cooldown: "-1m"},
+	}
+	for _, spec := range invalid {
+		assert.Error(t, validateMDSAutoscale(newAutoscaleFilesystem(spec)), spec)
+	}
+}
+
+func TestDesiredActiveCount(t *testing.T) {
+	spec := &cephv1.MDSAutoscaleSpec{MinActive: 2, MaxActive: 4, ScaleUpRequestRate: 1000, ScaleDownRequestRate: 200}
+	assert.Equal(t, int32(3), desiredActiveCount(spec, 2, 1500))
+	assert.Equal(t, int32(4), desiredActiveCount(spec, 4, 1500))
+	assert.Equal(t, int32(3), desiredActiveCount(spec, 3, 500))
+	assert.Equal(t, int32(2), desiredActiveCount(spec, 3, 100))
+	assert.Equal(t, int32(2), desiredActiveCount(spec, 2, 100))
+
+	// the active mds are never removed without scale down rate
+	spec.ScaleDownRequestRate = 0
+	assert.Equal(t, int32(3), desiredActiveCount(spec, 3, 0))
+}
+
+func TestCheckFilesystemLoad(t *testing.T) {
+	maxMDS := 1
+	rate := 1500
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			if args[1] == "get" {
+				up := `"mds_0":4107`
+				if maxMDS == 2 {
+					up += `,"mds_1":4108`
+				}
+				return fmt.Sprintf(`{"id":1,"mdsmap":{"fs_name":"myfs","max_mds":%d,"in":[0],"up":{%s},"failed":[],"damaged":[]}}`, maxMDS, up), nil
+			}
+			if args[1] == "status" {
+				return fmt.Sprintf(`{"clients":[{"clients":2,"fs":"myfs"}],"mdsmap":[{"name":"myfs-a","rank":0,"rate":%d,"state":"active"},{"name":"myfs-b","rank":0,"state":"standby-replay"}]}`, rate), nil
+			}
+			return "", nil
+		},
+	}
+	clientset := testop.New(t, 1)
+	context := &clusterd.Context{Executor: executor, Clientset: clientset}
+	fs := newAutoscaleFilesystem(&cephv1.MDSAutoscaleSpec{MaxActive: 2, ScaleUpRequestRate: 1000, ScaleDownRequestRate: 100, Cooldown: "10m"})
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephFilesystem{}, &cephv1.CephFilesystemList{})
+	cl := fake.NewFakeClientWithScheme(s, fs)
+	a := newMDSAutoscaler(cl, context)
+	now := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	// the filesystem is not scaled when its new count cannot be saved
+	filesystems := listReadyFilesystems(t, cl)
+	setAutoscaleFilesystem(t, a, nil)
+	assert.Empty(t, a.checkFilesystems(filesystems, now))
+	events, err := clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, events.Items)
+	setAutoscaleFilesystem(t, a, fs)
+
+	// the load is above the scale up rate
+	scaled := a.checkFilesystems(listReadyFilesystems(t, cl), now)
+	assert.Equal(t, 1, len(scaled))
+	status := getAutoscaleStatus(t, a)
+	assert.Equal(t, cephv1.MDSAutoscaleStatus{ActiveCount: 2, RequestRate: 1500, LastScaleTime: "2020-06-01T10:00:00Z"}, status)
+	events, err = clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events.Items))
+	assert.Equal(t, mdsScaledReason, events.Items[0].Reason)
+
+	// nothing is scaled until the ranks are set to the new count
+	rate = 10
+	assert.Empty(t, a.checkFilesystems(listReadyFilesystems(t, cl), now.Add(time.Hour)))
+	assert.Equal(t, status, getAutoscaleStatus(t, a))
+
+	// the load is below the scale down rate, but the last scaling is too recent
+	maxMDS = 2
+	assert.Empty(t, a.checkFilesystems(listReadyFilesystems(t, cl), now.Add(5*time.Minute)))
+	status = getAutoscaleStatus(t, a)
+	assert.Equal(t, cephv1.MDSAutoscaleStatus{ActiveCount: 2, RequestRate: 10, LastScaleTime: "2020-06-01T10:00:00Z"}, status)
+
+	// the cooldown is over
+	scaled = a.checkFilesystems(listReadyFilesystems(t, cl), now.Add(15*time.Minute))
+	assert.Equal(t, 1, len(scaled))
+	status = getAutoscaleStatus(t, a)
+	assert.Equal(t, cephv1.MDSAutoscaleStatus{ActiveCount: 1, RequestRate: 10, LastScaleTime: "2020-06-01T10:15:00Z"}, status)
+}
+
+func TestCheckFilesystemsSkipped(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			assert.Fail(t, "no command expected", args)
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: testop.New(t, 1)}
+
+	// without autoscaling policy and before the filesystem is ready
+	noPolicy := newAutoscaleFilesystem(nil)
+	notReady := newAutoscaleFilesystem(&cephv1.MDSAutoscaleSpec{MaxActive: 2, ScaleUpRequestRate: 1000})
+	notReady.Name = "other"
+	notReady.Status.Phase = k8sutil.Created
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephFilesystem{}, &cephv1.CephFilesystemList{})
+	cl := fake.NewFakeClientWithScheme(s, noPolicy, notReady)
+	a := newMDSAutoscaler(cl, context)
+
+	assert.Empty(t, a.checkFilesystems(listReadyFilesystems(t, cl), time.Now()))
+}
+
+// setAutoscaleFilesystem creates the filesystem, or deletes it when nil
+func setAutoscaleFilesystem(t *testing.T, a *mdsAutoscaler, fs *cephv1.CephFilesystem) {
+	if fs == nil {
+		assert.NoError(t, a.client.Delete(context.TODO(), &cephv1.CephFilesystem{ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"}}))
+		return
+	}
+	assert.NoError(t, a.client.Create(context.TODO(), fs.DeepCopy()))
+}
+
+func getAutoscaleStatus(t *testing.T, a *mdsAutoscaler) cephv1.MDSAutoscaleStatus {
+	fs := &cephv1.CephFilesystem{}
+	err := a.client.Get(context.TODO(), types.NamespacedName{Name: "myfs", Namespace: "ns"}, fs)
+	assert.NoError(t, err)
+	if !assert.NotNil(t, fs.Status.Autoscale) {
+		return cephv1.MDSAutoscaleStatus{}
+	}
+	return *fs.Status.Autoscale
+}
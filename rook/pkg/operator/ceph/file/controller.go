@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/coreos/pkg/capnslog"
 	"github.com/pkg/errors"
@@ -31,16 +32,19 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/file/mds"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
+	opmetrics "github.com/rook/rook/pkg/operator/ceph/metrics"
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -79,7 +83,11 @@ type ReconcileCephFilesystem struct {
 // Add creates a new CephFilesystem Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager, context *clusterd.Context) error {
-	return add(mgr, newReconciler(mgr, context))
+	monitor := newFilesystemMonitor(mgr.GetClient(), context)
+	if err := mgr.Add(monitor); err != nil {
+		return errors.Wrap(err, "failed to add the monitoring of the filesystems")
+	}
+	return add(mgr, newReconciler(mgr, context), monitor.autoscaleEvents())
 }
 
 // newReconciler returns a new reconcile.Reconciler
@@ -95,7 +103,7 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Rec
 	}
 }
 
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, autoscaleEvents chan event.GenericEvent) error {
 	// Create a new controller
 	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
 	if err != nil {
@@ -108,6 +116,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for the filesystems scaled by the autoscaler of the metadata servers
+	err = c.Watch(&source.Channel{Source: autoscaleEvents}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
 	// Watch all other resources
 	for _, t := range objectsToWatch {
 		err = c.Watch(&source.Kind{Type: t}, &handler.EnqueueRequestForOwner{
@@ -127,11 +141,13 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileCephFilesystem) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	start := time.Now()
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
 	reconcileResponse, err := r.reconcile(request)
 	if err != nil {
 		logger.Errorf("failed to reconcile %v", err)
 	}
+	opmetrics.ObserveReconcile(controllerName, start, err)
 
 	return reconcileResponse, err
 }
@@ -151,7 +167,7 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 
 	// The CR was just created, initializing status fields
 	if cephFilesystem.Status == nil {
-		updateStatus(r.client, request.NamespacedName, k8sutil.Created)
+		updatePhase(r.client, request.NamespacedName, k8sutil.Created)
 	}
 
 	// Make sure a CephCluster is present otherwise do nothing
@@ -225,12 +241,62 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 	logger.Debug("reconciling ceph filesystem store deployments")
 	reconcileResponse, err = r.reconcileCreateFilesystem(cephFilesystem)
 	if err != nil {
-		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus)
+		updatePhase(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus)
 		return reconcileResponse, err
 	}
 
-	// Set Ready status, we are done reconciling
-	updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus)
+	ref, err := opcontroller.GetControllerObjectOwnerReference(cephFilesystem, r.scheme)
+	if err != nil || ref == nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to get controller %q owner reference", cephFilesystem.Name)
+	}
+
+	// Add and remove the data pools changed in the spec, the changes that cannot be applied are reported in a condition
+	dataPoolsCondition, err := reconcileDataPools(r.context, cephFilesystem, *ref)
+	if err != nil {
+		updatePhase(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus)
+		return reconcile.Result{}, errors.Wrapf(err, "failed to reconcile the data pools of filesystem %q", cephFilesystem.Name)
+	}
+
+	// Report the options derived from the resources of the mds, they were validated with the filesystem
+	derivedConfig, _ := mds.DerivedConfig(cephFilesystem)
+
+	// Report the filesystem served by each mds, it doesn't affect the filesystem
+	mdsStatus, mdsErr := mdsDaemonStatus(r.context, cephFilesystem)
+	if mdsErr != nil {
+		logger.Warningf("failed to get the filesystems served by the mds of filesystem %q. %v", cephFilesystem.Name, mdsErr)
+	}
+
+	// The snapshot schedules don't affect the filesystem, their errors are only reported in the status
+	snapshotSchedules := reconcileSnapshotSchedules(r.context, r.clusterInfo, cephFilesystem)
+
+	// The mirroring doesn't affect the filesystem, its errors are only reported in the status
+	mirroring := reconcileMirroring(r.context, r.clusterInfo, r.cephClusterSpec, cephFilesystem, *ref)
+
+	// Report the ranks, the standby mds, the pool usage and the clients, they are refreshed while the filesystem is ready
+	info, infoErr := filesystemInfo(r.context, cephFilesystem)
+	if infoErr != nil {
+		logger.Warningf("failed to get the status of filesystem %q. %v", cephFilesystem.Name, infoErr)
+	}
+
+	// Set Ready status with what was reconciled, we are done reconciling. The status that could not be retrieved is
+	// left as it was.
+	updateStatus(r.client, request.NamespacedName, func(status *cephv1.CephFilesystemStatus) {
+		status.Phase = k8sutil.ReadyStatus
+		if dataPoolsCondition == nil {
+			status.Conditions = removeCondition(status.Conditions, cephv1.ConditionDataPoolsPending)
+		} else {
+			status.Conditions = setCondition(status.Conditions, *dataPoolsCondition)
+		}
+		status.DerivedConfig = derivedConfig
+		if mdsErr == nil {
+			status.MetadataServers = mdsStatus
+		}
+		status.SnapshotSchedules = snapshotSchedules
+		status.Mirroring = mirroring
+		if infoErr == nil {
+			status.Info = info
+		}
+	})
 
 	// Return and do not requeue
 	logger.Debug("done reconciling")
@@ -259,6 +325,11 @@ func (r *ReconcileCephFilesystem) reconcileCreateFilesystem(cephFilesystem *ceph
 		return reconcile.Result{}, errors.Wrapf(err, "failed to create filesystem %q", cephFilesystem.Name)
 	}
 
+	err = reconcileDirectories(r.context, cephFilesystem, *ref)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to apply the directories of filesystem %q", cephFilesystem.Name)
+	}
+
 	return reconcile.Result{}, nil
 }
 
@@ -277,27 +348,42 @@ func (r *ReconcileCephFilesystem) reconcileDeleteFilesystem(cephFilesystem *ceph
 	return nil
 }
 
-// updateStatus updates an object with a given status
-func updateStatus(client client.Client, name types.NamespacedName, status string) {
+// updatePhase updates the phase of the status of a filesystem
+func updatePhase(client client.Client, name types.NamespacedName, phase string) {
+	updateStatus(client, name, func(status *cephv1.CephFilesystemStatus) {
+		status.Phase = phase
+	})
+}
+
+// updateStatus applies the changes to the status of a filesystem and writes it in a single update. The status is not
+// written if the changes leave it as it was. The failures are logged and returned for the callers that depend on the
+// status being written.
+func updateStatus(client client.Client, name types.NamespacedName, changes func(status *cephv1.CephFilesystemStatus)) error {
 	fs := &cephv1.CephFilesystem{}
 	err := client.Get(context.TODO(), name, fs)
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			logger.Debug("CephFilesystem resource not found. Ignoring since object must be deleted.")
-			return
+			return err
 		}
-		logger.Warningf("failed to retrieve filesystem %q to update status to %q. %v", name, status, err)
-		return
+		logger.Warningf("failed to retrieve filesystem %q to update its status. %v", name, err)
+		return err
 	}
 
-	if fs.Status == nil {
-		fs.Status = &cephv1.Status{}
+	status := &cephv1.CephFilesystemStatus{}
+	if fs.Status != nil {
+		status = fs.Status.DeepCopy()
+	}
+	changes(status)
+	if fs.Status != nil && reflect.DeepEqual(fs.Status, status) {
+		return nil
 	}
 
-	fs.Status.Phase = status
+	fs.Status = status
 	if err := opcontroller.UpdateStatus(client, fs); err != nil {
-		logger.Errorf("failed to set filesystem %q status to %q. %v", fs.Name, status, err)
-		return
+		logger.Errorf("failed to update the status of filesystem %q. %v", fs.Name, err)
+		return err
 	}
-	logger.Debugf("filesystem %q status updated to %q", name, status)
+	logger.Debugf("filesystem %q status updated to %q", name, status.Phase)
+	return nil
 }
@@ -309,3 +309,43 @@ func TestCephFilesystemController(t *testing.T) {
 	assert.Equal(t, "Ready", fs.Status.Phase, fs)
 	logger.Info("PHASE 3 DONE")
 }
+
+func TestUpdateStatus(t *testing.T) {
+	fs := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
+		Status: &cephv1.CephFilesystemStatus{
+			Phase:     k8sutil.ReadyStatus,
+			Autoscale: &cephv1.MDSAutoscaleStatus{ActiveCount: 2},
+		},
+	}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephFilesystem{}, &cephv1.CephFilesystemList{})
+	cl := fake.NewFakeClientWithScheme(s, fs)
+	name := types.NamespacedName{Name: "myfs", Namespace: "ns"}
+	get := func() *cephv1.CephFilesystem {
+		fs := &cephv1.CephFilesystem{}
+		assert.NoError(t, cl.Get(context.TODO(), name, fs))
+		return fs
+	}
+
+	// the changes are written at once and the rest of the status is kept
+	updateStatus(cl, name, func(status *cephv1.CephFilesystemStatus) {
+		status.DerivedConfig = map[string]string{"mds_cache_memory_limit": "1073741824"}
+		status.Info = &cephv1.FilesystemInfoStatus{Clients: 2}
+	})
+	updated := get()
+	assert.Equal(t, k8sutil.ReadyStatus, updated.Status.Phase)
+	assert.Equal(t, int32(2), updated.Status.Autoscale.ActiveCount)
+	assert.Equal(t, "1073741824", updated.Status.DerivedConfig["mds_cache_memory_limit"])
+	assert.Equal(t, 2, updated.Status.Info.Clients)
+
+	// the status is not written when it doesn't change
+	updateStatus(cl, name, func(status *cephv1.CephFilesystemStatus) {
+		status.Info = &cephv1.FilesystemInfoStatus{Clients: 2}
+	})
+	assert.Equal(t, updated.ResourceVersion, get().ResourceVersion)
+
+	updatePhase(cl, name, k8sutil.ReconcileFailedStatus)
+	assert.Equal(t, k8sutil.ReconcileFailedStatus, get().Status.Phase)
+	assert.NotEqual(t, updated.ResourceVersion, get().ResourceVersion)
+}
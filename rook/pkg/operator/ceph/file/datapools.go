@@ -0,0 +1,283 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	poolLayoutsAppName    = "rook-ceph-fs-pool-layouts"
+	poolLayoutsJobTimeout = 15 * time.Minute
+
+	// the reasons of the DataPoolsPending condition
+	dataPoolsAppliedReason = "DataPoolsApplied"
+	dataPoolSharedReason   = "DataPoolShared"
+	defaultDataPoolReason  = "DefaultDataPool"
+	dataPoolNotEmptyReason = "DataPoolNotEmpty"
+	dataPoolInLayoutReason = "DataPoolInLayout"
+)
+
+// findPoolLayouts returns by pool the directories of the filesystem whose layout uses one of the pools
+var findPoolLayouts = runPoolLayoutsJob
+
+// reconcileDataPools adds the data pools appended to the spec to the filesystem, and removes the data pools removed
+// from the spec once no file and no directory layout uses them. The changes that cannot be applied are reported by
+// the returned condition, which is nil if the filesystem was not created from data pools of the spec.
+func reconcileDataPools(context *clusterd.Context, fs *cephv1.CephFilesystem, ownerRef metav1.OwnerReference) (*cephv1.Condition, error) {
+	if len(fs.Spec.DataPools) == 0 {
+		return nil, nil
+	}
+
+	f := newFS(fs.Name, fs.Namespace)
+	details, err := client.GetFilesystem(context, fs.Namespace, fs.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get filesystem %q", fs.Name)
+	}
+	poolNames, err := client.GetPoolNamesByID(context, fs.Namespace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get pool names")
+	}
+	added, removed := dataPoolChanges(f, fs.Spec, details, poolNames)
+
+	pending := &pendingDataPools{}
+	if len(added) != 0 {
+		filesystems, err := client.ListFilesystems(context, fs.Namespace)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list filesystems")
+		}
+		for i, poolName := range generateDataPoolNames(f, fs.Spec) {
+			if !added[poolName] {
+				continue
+			}
+			if err := checkSharedPools(fs.Name, []string{poolName}, filesystems); err != nil {
+				pending.add(dataPoolSharedReason, fmt.Sprintf("data pool %q cannot be added. %v", poolName, err))
+				continue
+			}
+			if err := createDataPool(context, fs.Namespace, poolName, fs.Spec.DataPools[i]); err != nil {
+				return nil, err
+			}
+			if err := client.AddDataPoolToFilesystem(context, fs.Namespace, fs.Name, poolName); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(removed) != 0 {
+		candidates, err := removableDataPools(context, fs, details, poolNames, removed, pending)
+		if err != nil {
+			return nil, err
+		}
+		if len(candidates) != 0 {
+			layouts, err := findPoolLayouts(context, fs, ownerRef, candidates)
+			if err != nil {
+				return nil, err
+			}
+			for _, poolName := range candidates {
+				if dirs := layouts[poolName]; len(dirs) != 0 {
+					pending.add(dataPoolInLayoutReason, fmt.Sprintf("data pool %q cannot be removed, it is in the layout of the directories %s",
+						poolName, strings.Join(dirs, ", ")))
+					continue
+				}
+				if err := removeDataPool(context, fs, poolName); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return pending.condition(fs.Name), nil
+}
+
+// dataPoolChanges compares the data pools of the spec with the data pools of the filesystem in Ceph. The data pools
+// that are not named after the filesystem were not added from the spec and are never removed.
+func dataPoolChanges(f *Filesystem, spec cephv1.FilesystemSpec, details *client.CephFilesystemDetails, poolNames map[int]string) (map[string]bool, []string) {
+	current := map[string]bool{}
+	for _, id := range details.MDSMap.DataPools {
+		current[poolNames[id]] = true
+	}
+
+	desired := map[string]bool{}
+	added := map[string]bool{}
+	for _, poolName := range generateDataPoolNames(f, spec) {
+		desired[poolName] = true
+		if !current[poolName] {
+			added[poolName] = true
+		}
+	}
+
+	removed := []string{}
+	dataPoolName := regexp.MustCompile(fmt.Sprintf(`^%s-%s[0-9]+$`, regexp.QuoteMeta(f.Name), dataPoolSuffix))
+	for _, id := range details.MDSMap.DataPools {
+		poolName := poolNames[id]
+		if desired[poolName] {
+			continue
+		}
+		if !dataPoolName.MatchString(poolName) {
+			logger.Debugf("data pool %q of filesystem %q was not added from the spec", poolName, f.Name)
+			continue
+		}
+		removed = append(removed, poolName)
+	}
+	return added, removed
+}
+
+// removableDataPools returns the removed data pools that are not the default data pool of the filesystem and that
+// have no objects, the others are reported as pending
+func removableDataPools(context *clusterd.Context, fs *cephv1.CephFilesystem, details *client.CephFilesystemDetails, poolNames map[int]string, removed []string, pending *pendingDataPools) ([]string, error) {
+	stats, err := client.GetPoolStats(context, fs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	objects := map[string]float64{}
+	for _, pool := range stats.Pools {
+		objects[pool.Name] = pool.Stats.Objects
+	}
+
+	candidates := []string{}
+	for _, poolName := range removed {
+		// the inode backtraces are stored in the first data pool, ceph does not allow removing it
+		if len(details.MDSMap.DataPools) != 0 && poolNames[details.MDSMap.DataPools[0]] == poolName {
+			pending.add(defaultDataPoolReason, fmt.Sprintf("data pool %q cannot be removed, it is the default data pool of the filesystem", poolName))
+			continue
+		}
+		if objects[poolName] > 0 {
+			pending.add(dataPoolNotEmptyReason, fmt.Sprintf("data pool %q cannot be removed, it still has %.0f objects", poolName, objects[poolName]))
+			continue
+		}
+		candidates = append(candidates, poolName)
+	}
+	return candidates, nil
+}
+
+// removeDataPool removes an unused data pool from the filesystem, and deletes it unless the pools are preserved
+func removeDataPool(context *clusterd.Context, fs *cephv1.CephFilesystem, poolName string) error {
+	if err := client.RemoveDataPoolFromFilesystem(context, fs.Namespace, fs.Name, poolName); err != nil {
+		return err
+	}
+	if fs.Spec.PreservePoolsOnDelete {
+		logger.Infof("PreservePoolsOnDelete is set in filesystem %q. data pool %q not deleted", fs.Name, poolName)
+		return nil
+	}
+	return client.DeletePool(context, fs.Namespace, poolName)
+}
+
+// pendingDataPools collects the changes of the data pools that cannot be applied
+type pendingDataPools struct {
+	reason   string
+	messages []string
+}
+
+func (p *pendingDataPools) add(reason, message string) {
+	logger.Warningf("%s", message)
+	if p.reason == "" {
+		p.reason = reason
+	}
+	p.messages = append(p.messages, message)
+}
+
+// condition returns the DataPoolsPending condition, with the reason of the first change that cannot be applied
+func (p *pendingDataPools) condition(fsName string) *cephv1.Condition {
+	if len(p.messages) == 0 {
+		return &cephv1.Condition{
+			Type:    cephv1.ConditionDataPoolsPending,
+			Status:  v1.ConditionFalse,
+			Reason:  dataPoolsAppliedReason,
+			Message: fmt.Sprintf("the data pools of filesystem %q match the spec", fsName),
+		}
+	}
+	return &cephv1.Condition{
+		Type:    cephv1.ConditionDataPoolsPending,
+		Status:  v1.ConditionTrue,
+		Reason:  p.reason,
+		Message: strings.Join(p.messages, "; "),
+	}
+}
+
+// setCondition replaces the condition of the same type in the conditions, the transition time changes with the
+// status, the reason or the message of the condition
+func setCondition(conditions []cephv1.Condition, condition cephv1.Condition) []cephv1.Condition {
+	now := metav1.NewTime(time.Now())
+	for i, existing := range conditions {
+		if existing.Type != condition.Type {
+			continue
+		}
+		if existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+			return conditions
+		}
+		condition.LastTransitionTime = now
+		condition.LastHeartbeatTime = now
+		updated := append([]cephv1.Condition{}, conditions...)
+		updated[i] = condition
+		return updated
+	}
+	condition.LastTransitionTime = now
+	condition.LastHeartbeatTime = now
+	return append(append([]cephv1.Condition{}, conditions...), condition)
+}
+
+// removeCondition returns the conditions without the conditions of the type
+func removeCondition(conditions []cephv1.Condition, conditionType cephv1.ConditionType) []cephv1.Condition {
+	var remaining []cephv1.Condition
+	for _, c := range conditions {
+		if c.Type != conditionType {
+			remaining = append(remaining, c)
+		}
+	}
+	return remaining
+}
+
+// runPoolLayoutsJob runs the job mounting the filesystem to find the directories whose layout uses one of the pools
+func runPoolLayoutsJob(context *clusterd.Context, fs *cephv1.CephFilesystem, ownerRef metav1.OwnerReference, pools []string) (map[string][]string, error) {
+	rookImage, err := operatorImage(context)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"ceph", "fs-pool-layouts", "--filesystem-name", fs.Name, "--pools", strings.Join(pools, ",")}
+	reporter, err := newMountJobReporter(context, fs, ownerRef, rookImage, poolLayoutsAppName, mountJobName(poolLayoutsAppName, fs.Name), args)
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, stderr, retcode, err := reporter.Run(poolLayoutsJobTimeout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to complete the pool layouts job of filesystem %q", fs.Name)
+	}
+	if retcode != 0 {
+		return nil, errors.Errorf(`pool layouts job of filesystem %q returned failure with retcode %d.
+  stdout: %s
+  stderr: %s`, fs.Name, retcode, stdout, stderr)
+	}
+
+	layouts := map[string][]string{}
+	if err := json.Unmarshal([]byte(stdout), &layouts); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the output %q of the pool layouts job", stdout)
+	}
+	return layouts, nil
+}
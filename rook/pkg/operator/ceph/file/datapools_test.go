@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newDataPoolsFilesystem(count int) *cephv1.CephFilesystem {
+	p := cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1, RequireSafeReplicaSize: false}}
+	fs := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
+		Spec:       cephv1.FilesystemSpec{MetadataPool: p, PreservePoolsOnDelete: true},
+	}
+	for i := 0; i < count; i++ {
+		fs.Spec.DataPools = append(fs.Spec.DataPools, p)
+	}
+	return fs
+}
+
+func TestDataPoolChanges(t *testing.T) {
+	poolNames := map[int]string{1: "myfs-metadata", 2: "myfs-data0", 3: "myfs-data1", 4: "custom", 5: "myfs-data2"}
+	details := &client.CephFilesystemDetails{MDSMap: client.MDSMap{MetadataPool: 1, DataPools: []int{2, 3, 4}}}
+
+	// a pool appended to the spec is added
+	added, removed := dataPoolChanges(newFS("myfs", "ns"), newDataPoolsFilesystem(3).Spec, details, poolNames)
+	assert.Equal(t, map[string]bool{"myfs-data2": true}, added)
+	assert.Empty(t, removed)
+
+	// the pools that were not added from the spec are not removed
+	added, removed = dataPoolChanges(newFS("myfs", "ns"), newDataPoolsFilesystem(1).Spec, details, poolNames)
+	assert.Empty(t, added)
+	assert.Equal(t, []string{"myfs-data1"}, removed)
+}
+
+func TestReconcileDataPools(t *testing.T) {
+	fsGet := `{"mdsmap":{"fs_name":"myfs","metadata_pool":1,"data_pools":[2,3,4,5]}}`
+	calls := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			for i, arg := range args {
+				if strings.HasPrefix(arg, "--connect-timeout") {
+					args = args[:i]
+					break
+				}
+			}
+			switch {
+			case args[0] == "fs" && args[1] == "get":
+				return fsGet, nil
+			case args[0] == "fs" && args[1] == "ls":
+				return `[{"name":"otherfs","metadata_pool":"otherfs-metadata","data_pools":["otherfs-data0","myfs-data2"]}]`, nil
+			case args[0] == "fs":
+				calls = append(calls, strings.Join(args, " "))
+			case args[0] == "osd" && args[1] == "lspools":
+				return `[{"poolnum":1,"poolname":"myfs-metadata"},{"poolnum":2,"poolname":"myfs-data0"},{"poolnum":3,"poolname":"myfs-data1"},
+{"poolnum":4,"poolname":"myfs-data2"},{"poolnum":5,"poolname":"myfs-data3"}]`, nil
+			case args[0] == "df":
+				return `{"pools":[{"name":"myfs-data0","id":2,"stats":{"objects":100}},{"name":"myfs-data1","id":3,"stats":{"objects":12}}]}`, nil
+			}
+			return "{}", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	layoutPools := []string{}
+	findPoolLayouts = func(context *clusterd.Context, fs *cephv1.CephFilesystem, ownerRef metav1.OwnerReference, pools []string) (map[string][]string, error) {
+		layoutPools = pools
+		return map[string][]string{"myfs-data2": {"/projects", "/archive"}}, nil
+	}
+	defer func() { findPoolLayouts = runPoolLayoutsJob }()
+
+	// only the empty pool without layout is removed
+	condition, err := reconcileDataPools(context, newDataPoolsFilesystem(1), metav1.OwnerReference{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"myfs-data2", "myfs-data3"}, layoutPools)
+	assert.Equal(t, []string{"fs rm_data_pool myfs myfs-data3"}, calls)
+	assert.Equal(t, v1.ConditionTrue, condition.Status)
+	assert.Equal(t, dataPoolNotEmptyReason, condition.Reason)
+	assert.Contains(t, condition.Message, `data pool "myfs-data1" cannot be removed, it still has 12 objects`)
+	assert.Contains(t, condition.Message, `data pool "myfs-data2" cannot be removed, it is in the layout of the directories /projects, /archive`)
+
+	// a new pool is added, unless it is used by another filesystem
+	calls = []string{}
+	fsGet = `{"mdsmap":{"fs_name":"myfs","metadata_pool":1,"data_pools":[2]}}`
+	condition, err = reconcileDataPools(context, newDataPoolsFilesystem(3), metav1.OwnerReference{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fs add_data_pool myfs myfs-data1"}, calls)
+	assert.Equal(t, dataPoolSharedReason, condition.Reason)
+
+	// the default data pool is never removed
+	calls = []string{}
+	fsGet = `{"mdsmap":{"fs_name":"myfs","metadata_pool":1,"data_pools":[3,2]}}`
+	condition, err = reconcileDataPools(context, newDataPoolsFilesystem(1), metav1.OwnerReference{})
+	assert.NoError(t, err)
+	assert.Empty(t, calls)
+	assert.Equal(t, defaultDataPoolReason, condition.Reason)
+
+	// the data pools match the spec
+	fsGet = `{"mdsmap":{"fs_name":"myfs","metadata_pool":1,"data_pools":[2]}}`
+	condition, err = reconcileDataPools(context, newDataPoolsFilesystem(1), metav1.OwnerReference{})
+	assert.NoError(t, err)
+	assert.Equal(t, v1.ConditionFalse, condition.Status)
+	assert.Equal(t, dataPoolsAppliedReason, condition.Reason)
+
+	// the data pools of a filesystem created outside of the spec are not reconciled
+	condition, err = reconcileDataPools(context, newDataPoolsFilesystem(0), metav1.OwnerReference{})
+	assert.NoError(t, err)
+	assert.Nil(t, condition)
+}
+
+func TestSetCondition(t *testing.T) {
+	pending := cephv1.Condition{Type: cephv1.ConditionDataPoolsPending, Status: v1.ConditionTrue, Reason: dataPoolNotEmptyReason, Message: "a"}
+	other := cephv1.Condition{Type: cephv1.ConditionReady, Status: v1.ConditionTrue}
+
+	conditions := setCondition([]cephv1.Condition{other}, pending)
+	assert.Equal(t, 2, len(conditions))
+	assert.False(t, conditions[1].LastTransitionTime.IsZero())
+
+	// the same condition is not changed
+	same := setCondition(conditions, pending)
+	assert.Equal(t, conditions, same)
+
+	applied := cephv1.Condition{Type: cephv1.ConditionDataPoolsPending, Status: v1.ConditionFalse, Reason: dataPoolsAppliedReason}
+	conditions = setCondition(conditions, applied)
+	assert.Equal(t, 2, len(conditions))
+	assert.Equal(t, v1.ConditionFalse, conditions[1].Status)
+
+	assert.Equal(t, []cephv1.Condition{other}, removeCondition(conditions, cephv1.ConditionDataPoolsPending))
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/filesystem"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/file/mds"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/operator/k8sutil/cmdreporter"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	directoriesAppName    = "rook-ceph-fs-directories"
+	directoriesJobTimeout = 5 * time.Minute
+	// the key of the directory settings last applied in the store of the filesystem
+	appliedDirectoriesKey = "applied"
+	// the config dir of the job where the admin config is generated and the filesystem is mounted
+	directoriesConfigDir = "/var/lib/rook"
+	directoryDriftReason = "DirectoryDrifted"
+)
+
+// desiredDirectories returns the state of the directories expected from the filesystem spec
+func desiredDirectories(fs *cephv1.CephFilesystem) []filesystem.DirectoryState {
+	dirs := []filesystem.DirectoryState{}
+	for _, d := range fs.Spec.Directories {
+		dir := filesystem.DirectoryState{Path: path.Clean(d.Path), MaxFiles: d.MaxFiles, ExportPin: filesystem.NoExportPin}
+		if d.MaxBytes != nil {
+			dir.MaxBytes = d.MaxBytes.Value()
+		}
+		if d.ExportPin != nil {
+			dir.ExportPin = *d.ExportPin
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// validateDirectories checks the directories of the filesystem spec
+func validateDirectories(fs *cephv1.CephFilesystem) error {
+	// the directories may be pinned to the ranks the autoscaler would add
+	maxActive := fs.Spec.MetadataServer.ActiveCount
+	if fs.Spec.MetadataServer.Autoscale != nil {
+		_, maxActive = mds.AutoscaleBounds(fs.Spec.MetadataServer.Autoscale)
+	}
+	paths := map[string]bool{}
+	for _, d := range fs.Spec.Directories {
+		if !path.IsAbs(d.Path) || strings.Contains(d.Path, "..") {
+			return errors.Errorf("invalid directory path %q, it must be an absolute path", d.Path)
+		}
+		p := path.Clean(d.Path)
+		if paths[p] {
+			return errors.Errorf("directory %q is specified more than once", p)
+		}
+		paths[p] = true
+
+		if d.MaxBytes != nil && d.MaxBytes.Sign() < 0 {
+			return errors.Errorf("invalid max bytes %q of directory %q", d.MaxBytes.String(), p)
+		}
+		if d.MaxFiles < 0 {
+			return errors.Errorf("invalid max files %d of directory %q", d.MaxFiles, p)
+		}
+		if d.ExportPin != nil && (*d.ExportPin < filesystem.NoExportPin || *d.ExportPin >= int(maxActive)) {
+			return errors.Errorf("invalid export pin %d of directory %q, it must be -1 or the rank of one of the %d active MDS",
+				*d.ExportPin, p, maxActive)
+		}
+	}
+	return nil
+}
+
+// reconcileDirectories applies the quotas and the export pins of the directories of the filesystem with
+// a job, and reports the directories whose settings were changed outside of the filesystem spec since
+// they were last applied.
+func reconcileDirectories(context *clusterd.Context, fs *cephv1.CephFilesystem, ownerRef metav1.OwnerReference) error {
+	desired := desiredDirectories(fs)
+	store := k8sutil.NewConfigMapKVStore(fs.Namespace, context.Clientset, ownerRef)
+	storeName := appliedDirectoriesStoreName(fs.Name)
+
+	applied := []filesystem.DirectoryState{}
+	value, err := store.GetValue(storeName, appliedDirectoriesKey)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get the directories applied to filesystem %q", fs.Name)
+	}
+	if value != "" {
+		if err := json.Unmarshal([]byte(value), &applied); err != nil {
+			return errors.Wrapf(err, "failed to parse the directories applied to filesystem %q", fs.Name)
+		}
+	}
+
+	if len(desired) == 0 && len(applied) == 0 {
+		return nil
+	}
+
+	if len(desired) != 0 {
+		rookImage, err := operatorImage(context)
+		if err != nil {
+			return err
+		}
+
+		observed, err := runDirectoriesJob(context, fs, ownerRef, rookImage, desired)
+		if err != nil {
+			return err
+		}
+
+		for _, drift := range directoryDrift(applied, observed) {
+			message := fmt.Sprintf("directory %s of filesystem %q", drift, fs.Name)
+			logger.Warningf("%s, restoring the settings of the filesystem spec", message)
+			object := v1.ObjectReference{
+				APIVersion: cephv1.SchemeGroupVersion.String(),
+				Kind:       cephFilesystemKind,
+				Name:       fs.Name,
+				Namespace:  fs.Namespace,
+				UID:        fs.UID,
+			}
+			if err := k8sutil.CreateEvent(context.Clientset, object, v1.EventTypeWarning, directoryDriftReason, message); err != nil {
+				logger.Errorf("failed to report the drift of the directory. %v", err)
+			}
+		}
+	}
+
+	output, err := json.Marshal(desired)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the directories of filesystem %q", fs.Name)
+	}
+	if err := store.SetValue(storeName, appliedDirectoriesKey, string(output)); err != nil {
+		return errors.Wrapf(err, "failed to save the directories applied to filesystem %q", fs.Name)
+	}
+	logger.Infof("applied the settings of %d directories of filesystem %q", len(desired), fs.Name)
+	return nil
+}
+
+// runDirectoriesJob runs the job applying the directories and returns the state of the directories
+// found before they were changed
+func runDirectoriesJob(context *clusterd.Context, fs *cephv1.CephFilesystem, ownerRef metav1.OwnerReference, rookImage string, dirs []filesystem.DirectoryState) ([]filesystem.DirectoryState, error) {
+	reporter, err := newDirectoriesReporter(context, fs, ownerRef, rookImage, dirs)
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, stderr, retcode, err := reporter.Run(directoriesJobTimeout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to complete the directories job of filesystem %q", fs.Name)
+	}
+	if retcode != 0 {
+		return nil, errors.Errorf(`directories job of filesystem %q returned failure with retcode %d.
+  stdout: %s
+  stderr: %s`, fs.Name, retcode, stdout, stderr)
+	}
+
+	var observed []filesystem.DirectoryState
+	if err := json.Unmarshal([]byte(stdout), &observed); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the output %q of the directories job", stdout)
+	}
+	return observed, nil
+}
+
+// newDirectoriesReporter builds the job mounting the filesystem with the admin credentials to apply the
+// directories
+func newDirectoriesReporter(context *clusterd.Context, fs *cephv1.CephFilesystem, ownerRef metav1.OwnerReference, rookImage string, dirs []filesystem.DirectoryState) (*cmdreporter.CmdReporter, error) {
+	input, err := json.Marshal(dirs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal the directories of filesystem %q", fs.Name)
+	}
+
+	args := []string{"ceph", "fs-directories", "--filesystem-name", fs.Name, "--directories", string(input)}
+	return newMountJobReporter(context, fs, ownerRef, rookImage, directoriesAppName, mountJobName(directoriesAppName, fs.Name), args)
+}
+
+// operatorImage returns the image of the operator, which runs the rook commands of the jobs mounting the filesystem
+func operatorImage(context *clusterd.Context) (string, error) {
+	pod, err := k8sutil.GetRunningPod(context.Clientset)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the operator pod")
+	}
+	rookImage, err := k8sutil.GetContainerImage(pod, "")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the operator image")
+	}
+	return rookImage, nil
+}
+
+// newMountJobReporter builds a job running a rook command that mounts the filesystem with the admin credentials
+func newMountJobReporter(context *clusterd.Context, fs *cephv1.CephFilesystem, ownerRef metav1.OwnerReference, rookImage, appName, jobName string, args []string) (*cmdreporter.CmdReporter, error) {
+	reporter, err := cmdreporter.New(
+		context.Clientset, &ownerRef,
+		appName, jobName, fs.Namespace,
+		[]string{"rook"}, args,
+		rookImage, rookImage)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to set up the %s job of filesystem %q", appName, fs.Name)
+	}
+
+	job := reporter.Job()
+	job.Spec.Template.Spec.ServiceAccountName = "rook-ceph-cmd-reporter"
+	fs.Spec.MetadataServer.Placement.ApplyToPodSpec(&job.Spec.Template.Spec)
+
+	configVolume := v1.Volume{Name: "rook-config", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}}
+	job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, configVolume)
+
+	// ceph-fuse needs the fuse device to mount the filesystem
+	privileged := true
+	container := &job.Spec.Template.Spec.Containers[0]
+	container.SecurityContext = &v1.SecurityContext{Privileged: &privileged}
+	container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{Name: configVolume.Name, MountPath: directoriesConfigDir})
+	container.Env = append(container.Env,
+		mon.PodNamespaceEnvVar(fs.Namespace),
+		mon.ClusterNameEnvVar(fs.Namespace),
+		mon.EndpointEnvVar(),
+		mon.AdminSecretEnvVar(),
+		k8sutil.ConfigDirEnvVar(directoriesConfigDir),
+		v1.EnvVar{Name: "ROOK_FSID", ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{Name: mon.AppName},
+				Key:                  "fsid",
+			},
+		}},
+	)
+
+	return reporter, nil
+}
+
+// directoryDrift compares the directories found in the filesystem with the settings applied previously,
+// and describes the differences
+func directoryDrift(applied, observed []filesystem.DirectoryState) []string {
+	drifts := []string{}
+	for _, found := range observed {
+		var last *filesystem.DirectoryState
+		for i := range applied {
+			if applied[i].Path == found.Path {
+				last = &applied[i]
+				break
+			}
+		}
+		if last == nil {
+			// the directory was not applied before
+			continue
+		}
+
+		if found.Missing {
+			drifts = append(drifts, fmt.Sprintf("%q was removed", found.Path))
+			continue
+		}
+		if found.MaxBytes != last.MaxBytes {
+			drifts = append(drifts, fmt.Sprintf("%q has max bytes %d instead of %d", found.Path, found.MaxBytes, last.MaxBytes))
+		}
+		if found.MaxFiles != last.MaxFiles {
+			drifts = append(drifts, fmt.Sprintf("%q has max files %d instead of %d", found.Path, found.MaxFiles, last.MaxFiles))
+		}
+		if found.ExportPin != last.ExportPin {
+			drifts = append(drifts, fmt.Sprintf("%q has export pin %d instead of %d", found.Path, found.ExportPin, last.ExportPin))
+		}
+	}
+	return drifts
+}
+
+func mountJobName(appName, fsName string) string {
+	return fmt.Sprintf("%s-%s", appName, fsName)
+}
+
+func appliedDirectoriesStoreName(fsName string) string {
+	return fmt.Sprintf("rook-ceph-fs-%s-applied-directories", fsName)
+}
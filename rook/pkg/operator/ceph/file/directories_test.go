@@ -0,0 +1,166 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"encoding/json"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/filesystem"
+	daemonutil "github.com/rook/rook/pkg/daemon/util"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/rook/rook/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newDirectoriesFilesystem(dirs ...cephv1.FilesystemDirectorySpec) *cephv1.CephFilesystem {
+	return &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
+		Spec: cephv1.FilesystemSpec{
+			MetadataServer: cephv1.MetadataServerSpec{ActiveCount: 2},
+			Directories:    dirs,
+		},
+	}
+}
+
+func TestDesiredDirectories(t *testing.T) {
+	maxBytes := resource.MustParse("10Gi")
+	pin := 1
+	fs := newDirectoriesFilesystem(
+		cephv1.FilesystemDirectorySpec{Path: "/projects/a/", MaxBytes: &maxBytes, MaxFiles: 1000, ExportPin: &pin},
+		cephv1.FilesystemDirectorySpec{Path: "/projects/b"},
+	)
+
+	assert.Equal(t, []filesystem.DirectoryState{
+		{Path: "/projects/a", MaxBytes: 10 * 1024 * 1024 * 1024, MaxFiles: 1000, ExportPin: 1},
+		{Path: "/projects/b", ExportPin: filesystem.NoExportPin},
+	}, desiredDirectories(fs))
+	assert.Empty(t, desiredDirectories(newDirectoriesFilesystem()))
+}
+
+func TestValidateDirectories(t *testing.T) {
+	pin := 1
+	assert.NoError(t, validateDirectories(newDirectoriesFilesystem(
+		cephv1.FilesystemDirectorySpec{Path: "/projects/a", ExportPin: &pin},
+		cephv1.FilesystemDirectorySpec{Path: "/projects/b"},
+	)))
+
+	// relative paths
+	assert.Error(t, validateDirectories(newDirectoriesFilesystem(cephv1.FilesystemDirectorySpec{Path: "projects"})))
+	assert.Error(t, validateDirectories(newDirectoriesFilesystem(cephv1.FilesystemDirectorySpec{Path: "/projects/../a"})))
+
+	// duplicate paths
+	assert.Error(t, validateDirectories(newDirectoriesFilesystem(
+		cephv1.FilesystemDirectorySpec{Path: "/projects/a"},
+		cephv1.FilesystemDirectorySpec{Path: "/projects/a/"},
+	)))
+
+	// negative quotas
+	maxBytes := resource.MustParse("-1")
+	assert.Error(t, validateDirectories(newDirectoriesFilesystem(cephv1.FilesystemDirectorySpec{Path: "/a", MaxBytes: &maxBytes})))
+	assert.Error(t, validateDirectories(newDirectoriesFilesystem(cephv1.FilesystemDirectorySpec{Path: "/a", MaxFiles: -1})))
+
+	// the pin must be an active rank
+	pin = 2
+	assert.Error(t, validateDirectories(newDirectoriesFilesystem(cephv1.FilesystemDirectorySpec{Path: "/a", ExportPin: &pin})))
+	pin = -2
+	assert.Error(t, validateDirectories(newDirectoriesFilesystem(cephv1.FilesystemDirectorySpec{Path: "/a", ExportPin: &pin})))
+	pin = -1
+	assert.NoError(t, validateDirectories(newDirectoriesFilesystem(cephv1.FilesystemDirectorySpec{Path: "/a", ExportPin: &pin})))
+
+	// the pin may be a rank the autoscaler would add
+	pin = 2
+	fs := newDirectoriesFilesystem(cephv1.FilesystemDirectorySpec{Path: "/a", ExportPin: &pin})
+	fs.Spec.MetadataServer.Autoscale = &cephv1.MDSAutoscaleSpec{MaxActive: 3}
+	assert.NoError(t, validateDirectories(fs))
+	pin = 3
+	assert.Error(t, validateDirectories(fs))
+}
+
+func TestDirectoryDrift(t *testing.T) {
+	applied := []filesystem.DirectoryState{
+		{Path: "/a", MaxBytes: 1024, ExportPin: 0},
+		{Path: "/b", MaxFiles: 10, ExportPin: filesystem.NoExportPin},
+		{Path: "/c", ExportPin: filesystem.NoExportPin},
+	}
+
+	// no drift when the directories are as applied, and new directories are not reported
+	observed := []filesystem.DirectoryState{
+		{Path: "/a", MaxBytes: 1024, ExportPin: 0},
+		{Path: "/b", MaxFiles: 10, ExportPin: filesystem.NoExportPin},
+		{Path: "/d", ExportPin: filesystem.NoExportPin, Missing: true},
+	}
+	assert.Empty(t, directoryDrift(applied, observed))
+
+	// changed settings and removed directories are reported
+	observed = []filesystem.DirectoryState{
+		{Path: "/a", MaxBytes: 0, ExportPin: 1},
+		{Path: "/b", MaxFiles: 20, ExportPin: filesystem.NoExportPin},
+		{Path: "/c", ExportPin: filesystem.NoExportPin, Missing: true},
+	}
+	assert.Equal(t, []string{
+		`"/a" has max bytes 0 instead of 1024`,
+		`"/a" has export pin 1 instead of 0`,
+		`"/b" has max files 20 instead of 10`,
+		`"/c" was removed`,
+	}, directoryDrift(applied, observed))
+}
+
+func TestDirectoriesJob(t *testing.T) {
+	context := &clusterd.Context{Clientset: testop.New(t, 1)}
+	fs := newDirectoriesFilesystem(cephv1.FilesystemDirectorySpec{Path: "/a"})
+	dirs := desiredDirectories(fs)
+
+	reporter, err := newDirectoriesReporter(context, fs, metav1.OwnerReference{Name: "myfs"}, "rook/ceph:master", dirs)
+	assert.NoError(t, err)
+	job := reporter.Job()
+	assert.Equal(t, "rook-ceph-fs-directories-myfs", job.Name)
+	assert.Equal(t, "ns", job.Namespace)
+
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "rook/ceph:master", container.Image)
+	assert.True(t, *container.SecurityContext.Privileged)
+	cmd, args, err := daemonutil.CmdReporterFlagArgumentToCommand(container.Args[2])
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rook"}, cmd)
+	input, _ := json.Marshal(dirs)
+	assert.Equal(t, []string{"ceph", "fs-directories", "--filesystem-name", "myfs", "--directories", string(input)}, args)
+
+	env := util.NewSet()
+	for _, e := range container.Env {
+		env.Add(e.Name)
+	}
+	for _, name := range []string{"ROOK_MON_ENDPOINTS", "ROOK_ADMIN_SECRET", "ROOK_FSID", "ROOK_CLUSTER_NAME", "ROOK_CONFIG_DIR", k8sutil.PodNamespaceEnvVar} {
+		assert.True(t, env.Contains(name), name)
+	}
+}
+
+func TestReconcileNoDirectories(t *testing.T) {
+	clientset := testop.New(t, 1)
+	context := &clusterd.Context{Clientset: clientset}
+
+	// nothing is run nor stored for a filesystem without directories
+	err := reconcileDirectories(context, newDirectoriesFilesystem(), metav1.OwnerReference{Name: "myfs"})
+	assert.NoError(t, err)
+	_, err = clientset.CoreV1().ConfigMaps("ns").Get(appliedDirectoriesStoreName("myfs"), metav1.GetOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	clientEvictedReason = "ClientEvicted"
+	// the label of the pods of the cephfs csi nodeplugin, they run on the host network of each node mounting cephfs
+	csiCephFSPluginLabel = "app=csi-cephfsplugin"
+	// the default time a node must be not ready before the sessions of its clients are evicted
+	defaultNodeNotReadyGracePeriod = 5 * time.Minute
+)
+
+// clientEvictor evicts the client sessions of the filesystems on the nodes that have not been ready for longer than
+// the grace period of the filesystem, so that their caps are released for the clients on the other nodes. The
+// clients are blacklisted first so that they cannot write once the node is back. It is run by the filesystem monitor.
+type clientEvictor struct {
+	client  client.Client
+	context *clusterd.Context
+}
+
+func newClientEvictor(client client.Client, context *clusterd.Context) *clientEvictor {
+	return &clientEvictor{client: client, context: context}
+}
+
+// checkFilesystems evicts the stale client sessions of the ready filesystems having a client eviction policy
+func (e *clientEvictor) checkFilesystems(filesystems []*cephv1.CephFilesystem, now time.Time) {
+	evicting := []*cephv1.CephFilesystem{}
+	for _, fs := range filesystems {
+		if fs.Spec.ClientEviction != nil {
+			evicting = append(evicting, fs)
+		}
+	}
+	if len(evicting) == 0 {
+		return
+	}
+
+	notReadyNodes, err := e.notReadyNodes()
+	if err != nil {
+		logger.Warningf("failed to get the nodes that are not ready. %v", err)
+		return
+	}
+	if len(notReadyNodes) == 0 {
+		return
+	}
+	clientNodes, err := e.clientNodes()
+	if err != nil {
+		logger.Warningf("failed to get the nodes of the filesystem clients. %v", err)
+		return
+	}
+
+	for _, fs := range evicting {
+		if err := e.evictStaleClients(fs, clientNodes, notReadyNodes, now); err != nil {
+			logger.Warningf("failed to evict the stale clients of filesystem %q. %v", fs.Name, err)
+		}
+	}
+}
+
+// notReadyNodes returns the time since which each node that is not ready has been in that state
+func (e *clientEvictor) notReadyNodes() (map[string]time.Time, error) {
+	nodes, err := e.context.Clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the nodes")
+	}
+	notReady := map[string]time.Time{}
+	for _, node := range nodes.Items {
+		for _, c := range node.Status.Conditions {
+			if c.Type == v1.NodeReady && c.Status != v1.ConditionTrue {
+				notReady[node.Name] = c.LastTransitionTime.Time
+			}
+		}
+	}
+	return notReady, nil
+}
+
+// clientNodes returns the node of each ip address from which the cephfs volumes are mounted, from the csi
+// nodeplugin pods
+func (e *clientEvictor) clientNodes() (map[string]string, error) {
+	// the csi drivers run in the namespace of the operator
+	namespace := os.Getenv(k8sutil.PodNamespaceEnvVar)
+	pods, err := e.context.Clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: csiCephFSPluginLabel})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the cephfs csi plugin pods")
+	}
+	nodes := map[string]string{}
+	for _, pod := range pods.Items {
+		if pod.Status.HostIP != "" && pod.Spec.NodeName != "" {
+			nodes[pod.Status.HostIP] = pod.Spec.NodeName
+		}
+	}
+	return nodes, nil
+}
+
+// evictStaleClients blacklists and evicts the sessions of the clients of the filesystem on the nodes that have not
+// been ready for longer than the grace period
+func (e *clientEvictor) evictStaleClients(fs *cephv1.CephFilesystem, clientNodes map[string]string, notReadyNodes map[string]time.Time, now time.Time) error {
+	gracePeriod, err := nodeNotReadyGracePeriod(fs.Spec.ClientEviction)
+	if err != nil {
+		return err
+	}
+
+	details, err := cephclient.GetFilesystem(e.context, fs.Namespace, fs.Name)
+	if err != nil {
+		return err
+	}
+
+	// the sessions are open on each active mds
+	blacklisted := map[string]bool{}
+	for _, info := range details.MDSMap.Info {
+		if info.State != "up:active" {
+			continue
+		}
+		sessions, err := cephclient.ListMDSSessions(e.context, fs.Namespace, info.Name)
+		if err != nil {
+			return err
+		}
+		for _, session := range sessions {
+			node, ok := clientNodes[session.IP()]
+			if !ok {
+				continue
+			}
+			notReadySince, ok := notReadyNodes[node]
+			if !ok || now.Sub(notReadySince) < gracePeriod {
+				continue
+			}
+
+			addr := session.ClientAddr()
+			if !blacklisted[addr] {
+				if err := cephclient.BlacklistClient(e.context, fs.Namespace, addr); err != nil {
+					logger.Warningf("%v", err)
+					continue
+				}
+				blacklisted[addr] = true
+			}
+			if err := cephclient.EvictMDSClient(e.context, fs.Namespace, info.Name, session.ID); err != nil {
+				logger.Warningf("%v", err)
+				continue
+			}
+
+			message := fmt.Sprintf("evicted client %d at %s of filesystem %q from mds %q, node %q has not been ready since %s",
+				session.ID, addr, fs.Name, info.Name, node, notReadySince.UTC().Format(time.RFC3339))
+			logger.Infof("%s", message)
+			object := v1.ObjectReference{
+				APIVersion: cephv1.SchemeGroupVersion.String(),
+				Kind:       cephFilesystemKind,
+				Name:       fs.Name,
+				Namespace:  fs.Namespace,
+				UID:        fs.UID,
+			}
+			if err := k8sutil.CreateEvent(e.context.Clientset, object, v1.EventTypeWarning, clientEvictedReason, message); err != nil {
+				logger.Errorf("failed to report the eviction of the client. %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+func nodeNotReadyGracePeriod(spec *cephv1.ClientEvictionSpec) (time.Duration, error) {
+	if spec.NodeNotReadyGracePeriod == "" {
+		return defaultNodeNotReadyGracePeriod, nil
+	}
+	gracePeriod, err := time.ParseDuration(spec.NodeNotReadyGracePeriod)
+	if err != nil || gracePeriod <= 0 {
+		return 0, errors.Errorf("invalid node not ready grace period %q", spec.NodeNotReadyGracePeriod)
+	}
+	return gracePeriod, nil
+}
+
+// validateClientEviction checks the client eviction policy of the filesystem spec
+func validateClientEviction(fs *cephv1.CephFilesystem) error {
+	if fs.Spec.ClientEviction == nil {
+		return nil
+	}
+	_, err := nodeNotReadyGracePeriod(fs.Spec.ClientEviction)
+	return err
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateClientEviction(t *testing.T) {
+	fs := &cephv1.CephFilesystem{}
+	assert.NoError(t, validateClientEviction(fs))
+	fs.Spec.ClientEviction = &cephv1.ClientEvictionSpec{}
+	assert.NoError(t, validateClientEviction(fs))
+	fs.Spec.ClientEviction.NodeNotReadyGracePeriod = "30m"
+	assert.NoError(t, validateClientEviction(fs))
+
+	for _, gracePeriod := range []string{"30", "-1m", "0s"} {
+		fs.Spec.ClientEviction.NodeNotReadyGracePeriod = gracePeriod
+		assert.Error(t, validateClientEviction(fs), gracePeriod)
+	}
+}
+
+func TestEvictStaleClients(t *testing.T) {
+	os.Setenv(k8sutil.PodNamespaceEnvVar, "rook-ceph")
+	defer os.Unsetenv(k8sutil.PodNamespaceEnvVar)
+
+	blacklisted := []string{}
+	evicted := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			switch {
+			case args[0] == "fs" && args[1] == "get":
+				return `{"id":1,"mdsmap":{"fs_name":"myfs","max_mds":1,"info":{
+"gid_4107":{"gid":4107,"name":"myfs-a","rank":0,"state":"up:active"},
+"gid_4108":{"gid":4108,"name":"myfs-b","rank":0,"state":"up:standby-replay"}}}}`, nil
+			case args[0] == "tell" && args[2] == "session":
+				assert.Equal(t, "mds.myfs-a", args[1])
+				return `[{"id":4305,"state":"open","entity":{"name":{"type":"client","num":4305},"addr":{"type":"v1","addr":"10.0.0.2:0","nonce":123}},"client_metadata":{"hostname":"node1","entity_id":"csi-cephfs-node"}},
+{"id":4306,"state":"open","entity":{"name":{"type":"client","num":4306},"addr":{"type":"v1","addr":"10.0.0.1:0","nonce":456}},"client_metadata":{"hostname":"node0"}},
+{"id":4307,"state":"open","entity":{"name":{"type":"client","num":4307},"addr":{"type":"v1","addr":"10.0.0.9:0","nonce":789}},"client_metadata":{"hostname":"other"}}]`, nil
+			case args[0] == "osd" && args[1] == "blacklist":
+				blacklisted = append(blacklisted, args[3])
+				return "", nil
+			case args[0] == "tell" && args[2] == "client":
+				evicted = append(evicted, args[1]+" "+args[4])
+				return "", nil
+			}
+			assert.Fail(t, "unexpected command", args)
+			return "", nil
+		},
+	}
+	clientset := testop.New(t, 2)
+	now := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	setNodeNotReady(t, clientset, "node1", now.Add(-10*time.Minute))
+	addCSIPluginPod(t, clientset, "csi-cephfsplugin-a", "node0", "10.0.0.1")
+	addCSIPluginPod(t, clientset, "csi-cephfsplugin-b", "node1", "10.0.0.2")
+	context := &clusterd.Context{Executor: executor, Clientset: clientset}
+
+	fs := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
+		Spec:       cephv1.FilesystemSpec{ClientEviction: &cephv1.ClientEvictionSpec{NodeNotReadyGracePeriod: "15m"}},
+		Status:     &cephv1.CephFilesystemStatus{Phase: k8sutil.ReadyStatus},
+	}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephFilesystem{}, &cephv1.CephFilesystemList{})
+	cl := fakeclient.NewFakeClientWithScheme(s, fs)
+	e := newClientEvictor(cl, context)
+
+	// the node is not ready for less than the grace period
+	e.checkFilesystems(listReadyFilesystems(t, cl), now)
+	assert.Empty(t, blacklisted)
+	assert.Empty(t, evicted)
+
+	// only the client of the node that is not ready is evicted
+	e.checkFilesystems(listReadyFilesystems(t, cl), now.Add(10*time.Minute))
+	assert.Equal(t, []string{"10.0.0.2:0/123"}, blacklisted)
+	assert.Equal(t, []string{"mds.myfs-a id=4305"}, evicted)
+	events, err := clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events.Items))
+	assert.Equal(t, clientEvictedReason, events.Items[0].Reason)
+}
+
+func TestEvictStaleClientsSkipped(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			assert.Fail(t, "no command expected", args)
+			return "", nil
+		},
+	}
+	clientset := testop.New(t, 1)
+	now := time.Now()
+	context := &clusterd.Context{Executor: executor, Clientset: clientset}
+
+	// without eviction policy and before the filesystem is ready
+	noPolicy := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
+		Status:     &cephv1.CephFilesystemStatus{Phase: k8sutil.ReadyStatus},
+	}
+	notReady := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "ns"},
+		Spec:       cephv1.FilesystemSpec{ClientEviction: &cephv1.ClientEvictionSpec{}},
+		Status:     &cephv1.CephFilesystemStatus{Phase: k8sutil.Created},
+	}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephFilesystem{}, &cephv1.CephFilesystemList{})
+	setNodeNotReady(t, clientset, "node0", now.Add(-time.Hour))
+	cl := fakeclient.NewFakeClientWithScheme(s, noPolicy, notReady)
+	e := newClientEvictor(cl, context)
+	e.checkFilesystems(listReadyFilesystems(t, cl), now)
+
+	// all the nodes are ready
+	notReady.Status.Phase = k8sutil.ReadyStatus
+	clientset = testop.New(t, 1)
+	context.Clientset = clientset
+	cl = fakeclient.NewFakeClientWithScheme(s, notReady)
+	e = newClientEvictor(cl, context)
+	e.checkFilesystems(listReadyFilesystems(t, cl), now)
+}
+
+func setNodeNotReady(t *testing.T, clientset *fake.Clientset, name string, since time.Time) {
+	node, err := clientset.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	node.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionUnknown, LastTransitionTime: metav1.NewTime(since)}}
+	_, err = clientset.CoreV1().Nodes().Update(node)
+	assert.NoError(t, err)
+}
+
+func addCSIPluginPod(t *testing.T, clientset *fake.Clientset, name, node, ip string) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "rook-ceph", Labels: map[string]string{"app": "csi-cephfsplugin"}},
+		Spec:       v1.PodSpec{NodeName: node, HostNetwork: true},
+		Status:     v1.PodStatus{HostIP: ip, PodIP: ip},
+	}
+	_, err := clientset.CoreV1().Pods("rook-ceph").Create(pod)
+	assert.NoError(t, err)
+}
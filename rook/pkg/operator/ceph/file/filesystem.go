@@ -26,6 +26,7 @@ import (
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/file/mds"
+	"github.com/rook/rook/pkg/operator/ceph/file/mirror"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -54,9 +55,10 @@ func createFilesystem(
 	scheme *runtime.Scheme,
 ) error {
 
+	activeCount := mds.ActiveCount(&fs)
 	if len(fs.Spec.DataPools) != 0 {
 		f := newFS(fs.Name, fs.Namespace)
-		if err := f.doFilesystemCreate(context, clusterInfo.CephVersion, fs.Spec); err != nil {
+		if err := f.doFilesystemCreate(context, clusterInfo.CephVersion, fs.Spec, activeCount, multipleFilesystemsAllowed(clusterSpec)); err != nil {
 			return errors.Wrapf(err, "failed to create filesystem %q", fs.Name)
 		}
 	}
@@ -72,10 +74,10 @@ func createFilesystem(
 		}
 	}
 
-	// set the number of active mds instances
-	if fs.Spec.MetadataServer.ActiveCount > 1 {
-		if err = client.SetNumMDSRanks(context, fs.Namespace, fs.Name, fs.Spec.MetadataServer.ActiveCount); err != nil {
-			logger.Warningf("failed setting active mds count to %d. %v", fs.Spec.MetadataServer.ActiveCount, err)
+	// set the number of active mds instances, the autoscaler may also lower it to a single one
+	if activeCount > 1 || fs.Spec.MetadataServer.Autoscale != nil {
+		if err = client.SetNumMDSRanks(context, fs.Namespace, fs.Name, activeCount); err != nil {
+			logger.Warningf("failed setting active mds count to %d. %v", activeCount, err)
 		}
 	}
 
@@ -105,7 +107,7 @@ func deleteFilesystem(
 	c := mds.NewCluster(clusterInfo, context, clusterSpec, fs, filesystem, ownerRefs, dataDirHostPath, scheme)
 
 	// Delete mds CephX keys and configuration in centralized mon database
-	replicas := fs.Spec.MetadataServer.ActiveCount * 2
+	replicas := mds.ActiveCount(&fs) * 2
 	for i := 0; i < int(replicas); i++ {
 		daemonLetterID := k8sutil.IndexToName(i)
 		daemonName := fmt.Sprintf("%s-%s", fs.Name, daemonLetterID)
@@ -116,6 +118,13 @@ func deleteFilesystem(
 		}
 	}
 
+	// Remove the cephfs-mirror daemon and its key, the peers and directories go away with the filesystem
+	if fs.Spec.Mirroring != nil {
+		if err := mirror.New(clusterInfo, context, clusterSpec, fs, ownerRefs, dataDirHostPath).Remove(); err != nil {
+			logger.Warningf("failed to remove the cephfs-mirror daemon of filesystem %q. %v", fs.Name, err)
+		}
+	}
+
 	// The most important part of deletion is that the filesystem gets removed from Ceph
 	// The K8s resources will already be removed with the K8s owner references
 	if err := downFilesystem(context, fs.Namespace, fs.Name); err != nil {
@@ -142,6 +151,24 @@ func validateFilesystem(context *clusterd.Context, f *cephv1.CephFilesystem) err
 	if f.Spec.MetadataServer.ActiveCount < 1 {
 		return errors.New("MetadataServer.ActiveCount must be at least 1")
 	}
+	if _, err := mds.DerivedConfig(f); err != nil {
+		return err
+	}
+	if err := validateMDSAutoscale(f); err != nil {
+		return errors.Wrapf(err, "invalid mds autoscaling")
+	}
+	if err := validateClientEviction(f); err != nil {
+		return errors.Wrapf(err, "invalid client eviction")
+	}
+	if err := validateDirectories(f); err != nil {
+		return errors.Wrapf(err, "invalid directories")
+	}
+	if err := validateSnapshotSchedules(f); err != nil {
+		return errors.Wrapf(err, "invalid snapshot schedules")
+	}
+	if err := validateMirroring(f); err != nil {
+		return errors.Wrapf(err, "invalid mirroring")
+	}
 	// No data pool means that we expect the fs to exist already
 	if len(f.Spec.DataPools) == 0 {
 		return nil
@@ -187,18 +214,18 @@ func SetPoolSize(f *Filesystem, context *clusterd.Context, spec cephv1.Filesyste
 }
 
 // doFilesystemCreate starts the Ceph file daemons and creates the filesystem in Ceph.
-func (f *Filesystem) doFilesystemCreate(context *clusterd.Context, cephVersion cephver.CephVersion, spec cephv1.FilesystemSpec) error {
+func (f *Filesystem) doFilesystemCreate(context *clusterd.Context, cephVersion cephver.CephVersion, spec cephv1.FilesystemSpec, activeCount int32, allowMultiple bool) error {
 
 	_, err := client.GetFilesystem(context, f.Namespace, f.Name)
 	if err == nil {
 		logger.Infof("filesystem %s already exists", f.Name)
 		// Even if the fs already exists, the num active mdses may have changed
 
-		if err := client.SetNumMDSRanks(context, f.Namespace, f.Name, spec.MetadataServer.ActiveCount); err != nil {
+		if err := client.SetNumMDSRanks(context, f.Namespace, f.Name, activeCount); err != nil {
 			logger.Errorf(
-				fmt.Sprintf("failed to set num mds ranks (max_mds) to %d for filesystem %s, still continuing. ", spec.MetadataServer.ActiveCount, f.Name) +
+				fmt.Sprintf("failed to set num mds ranks (max_mds) to %d for filesystem %s, still continuing. ", activeCount, f.Name) +
 					"this error is not critical, but mdses may not be as failure tolerant as desired. " +
-					fmt.Sprintf("USER should verify that the number of active mdses is %d with 'ceph fs get %s'", spec.MetadataServer.ActiveCount, f.Name) +
+					fmt.Sprintf("USER should verify that the number of active mdses is %d with 'ceph fs get %s'", activeCount, f.Name) +
 					fmt.Sprintf(". %v", err),
 			)
 		}
@@ -215,8 +242,18 @@ func (f *Filesystem) doFilesystemCreate(context *clusterd.Context, cephVersion c
 	if err != nil {
 		return errors.Wrapf(err, "Unable to list existing filesystem")
 	}
-	if len(fslist) > 0 && !client.IsMultiFSEnabled() {
-		return errors.Errorf("cannot create multiple filesystems. enable %s env variable to create more than one", client.MultiFsEnv)
+	if len(fslist) > 0 {
+		if !allowMultiple {
+			return errors.New("cannot create multiple filesystems. set allowMultipleFilesystems in the CephCluster spec to create more than one")
+		}
+		// the pools of the filesystem are reused if they exist, they must not already belong to another filesystem
+		pools := append([]string{generateMetaDataPoolName(f)}, generateDataPoolNames(f, spec)...)
+		if err := checkSharedPools(f.Name, pools, fslist); err != nil {
+			return err
+		}
+		if err := client.EnableMultipleFilesystems(context, f.Namespace); err != nil {
+			return err
+		}
 	}
 
 	poolNames, err := client.GetPoolNamesByID(context, f.Namespace)
@@ -247,15 +284,8 @@ func (f *Filesystem) doFilesystemCreate(context *clusterd.Context, cephVersion c
 		poolName := dataPoolNames[i]
 		if _, poolFound := reversedPoolMap[poolName]; !poolFound {
 			poolsCreated = true
-			err = client.CreatePoolWithProfile(context, f.Namespace, poolName, pool, "")
-			if err != nil {
-				return errors.Wrapf(err, "failed to create data pool %q", poolName)
-			}
-			if pool.IsErasureCoded() {
-				// An erasure coded data pool used for a filesystem must allow overwrites
-				if err := client.SetPoolProperty(context, f.Namespace, poolName, "allow_ec_overwrites", "true"); err != nil {
-					logger.Warningf("failed to set ec pool property. %v", err)
-				}
+			if err := createDataPool(context, f.Namespace, poolName, pool); err != nil {
+				return err
 			}
 		}
 	}
@@ -270,6 +300,88 @@ func (f *Filesystem) doFilesystemCreate(context *clusterd.Context, cephVersion c
 	return nil
 }
 
+// createDataPool creates a data pool of a filesystem, or updates it if it exists
+func createDataPool(context *clusterd.Context, namespace, poolName string, pool cephv1.PoolSpec) error {
+	if err := client.CreatePoolWithProfile(context, namespace, poolName, pool, ""); err != nil {
+		return errors.Wrapf(err, "failed to create data pool %q", poolName)
+	}
+	if pool.IsErasureCoded() {
+		// An erasure coded data pool used for a filesystem must allow overwrites
+		if err := client.SetPoolProperty(context, namespace, poolName, "allow_ec_overwrites", "true"); err != nil {
+			logger.Warningf("failed to set ec pool property. %v", err)
+		}
+	}
+	return nil
+}
+
+// mdsDaemonStatus returns the filesystem served by each mds of the filesystem that is known to Ceph
+func mdsDaemonStatus(context *clusterd.Context, fs *cephv1.CephFilesystem) ([]cephv1.MDSDaemonStatus, error) {
+	dump, err := client.GetFilesystemDump(context, fs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	daemons := map[string]cephv1.MDSDaemonStatus{}
+	for _, filesystem := range dump.Filesystems {
+		for _, info := range filesystem.MDSMap.Info {
+			daemons[info.Name] = cephv1.MDSDaemonStatus{
+				Name:           info.Name,
+				Filesystem:     filesystem.MDSMap.FilesystemName,
+				JoinFilesystem: dump.FilesystemName(info.JoinFSCID),
+				Rank:           info.Rank,
+				State:          info.State,
+			}
+		}
+	}
+	for _, info := range dump.Standbys {
+		daemons[info.Name] = cephv1.MDSDaemonStatus{
+			Name:           info.Name,
+			JoinFilesystem: dump.FilesystemName(info.JoinFSCID),
+			Rank:           info.Rank,
+			State:          info.State,
+		}
+	}
+
+	status := []cephv1.MDSDaemonStatus{}
+	replicas := mds.ActiveCount(fs) * 2
+	for i := 0; i < int(replicas); i++ {
+		daemonName := fmt.Sprintf("%s-%s", fs.Name, k8sutil.IndexToName(i))
+		daemon, ok := daemons[daemonName]
+		if !ok {
+			continue
+		}
+		if daemon.Filesystem != "" && daemon.Filesystem != fs.Name {
+			logger.Warningf("mds %q of filesystem %q serves filesystem %q", daemonName, fs.Name, daemon.Filesystem)
+		}
+		status = append(status, daemon)
+	}
+	return status, nil
+}
+
+// multipleFilesystemsAllowed returns whether more than one filesystem may be created in the cluster, with the
+// deprecated operator setting as a fallback
+func multipleFilesystemsAllowed(clusterSpec *cephv1.ClusterSpec) bool {
+	return clusterSpec.AllowMultipleFilesystems || client.IsMultiFSEnabled()
+}
+
+// checkSharedPools returns an error if one of the pools is used by another filesystem than the named one
+func checkSharedPools(name string, pools []string, filesystems []client.CephFilesystem) error {
+	for _, fs := range filesystems {
+		if fs.Name == name {
+			continue
+		}
+		used := append([]string{fs.MetadataPool}, fs.DataPools...)
+		for _, pool := range pools {
+			for _, usedPool := range used {
+				if pool == usedPool {
+					return errors.Errorf("pool %q is already used by filesystem %q", pool, fs.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // downFilesystem marks the filesystem as down and the MDS' as failed
 func downFilesystem(context *clusterd.Context, namespace, filesystemName string) error {
 	logger.Infof("Downing filesystem %s", filesystemName)
@@ -291,6 +403,11 @@ func generateDataPoolNames(f *Filesystem, spec cephv1.FilesystemSpec) []string {
 	return dataPoolNames
 }
 
+// DataPoolNames returns the names of the data pools of a filesystem, in the order of the spec
+func DataPoolNames(fs *cephv1.CephFilesystem) []string {
+	return generateDataPoolNames(&Filesystem{Name: fs.Name, Namespace: fs.Namespace}, fs.Spec)
+}
+
 // generateMetaDataPoolName generates MetaDataPool name by prefixing the filesystem name to the constant metaDataPoolSuffix
 func generateMetaDataPoolName(f *Filesystem) string {
 	return fmt.Sprintf("%s-%s", f.Name, metaDataPoolSuffix)
@@ -27,9 +27,11 @@ import (
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	cephtest "github.com/rook/rook/pkg/daemon/ceph/test"
 	"github.com/rook/rook/pkg/operator/ceph/file/mds"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	testopk8s "github.com/rook/rook/pkg/operator/k8sutil/test"
 	testop "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
@@ -146,7 +148,92 @@ func TestCreateFilesystem(t *testing.T) {
 
 	//Create another filesystem which should fail
 	err = createFilesystem(clusterInfo, context, fs, &cephv1.ClusterSpec{}, metav1.OwnerReference{}, "/var/lib/rook/", scheme.Scheme)
-	assert.Equal(t, "failed to create filesystem \"myfs\": cannot create multiple filesystems. enable ROOK_ALLOW_MULTIPLE_FILESYSTEMS env variable to create more than one", err.Error())
+	assert.Equal(t, "failed to create filesystem \"myfs\": cannot create multiple filesystems. set allowMultipleFilesystems in the CephCluster spec to create more than one", err.Error())
+}
+
+func TestCreateMultipleFilesystems(t *testing.T) {
+	fses := `[{"name":"otherfs","metadata_pool":"otherfs-metadata","metadata_pool_id":1,"data_pool_ids":[2],"data_pools":["otherfs-data0"]}]`
+	enabled := false
+	created := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "fs" {
+				switch args[1] {
+				case "get":
+					return "", errors.New("not found")
+				case "ls":
+					return fses, nil
+				case "flag":
+					assert.Equal(t, []string{"fs", "flag", "set", "enable_multiple", "true", "--yes-i-really-mean-it"}, args[:6])
+					enabled = true
+				case "new":
+					assert.True(t, enabled)
+					created = true
+				}
+				return "", nil
+			}
+			if args[0] == "osd" && args[1] == "lspools" {
+				return `[{"poolnum":1,"poolname":"otherfs-metadata"},{"poolnum":2,"poolname":"otherfs-data0"}]`, nil
+			}
+			return "{}", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: testop.New(t, 1)}
+	f := newFS("myfs", "ns")
+	p := cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1, RequireSafeReplicaSize: false}}
+	spec := cephv1.FilesystemSpec{MetadataPool: p, DataPools: []cephv1.PoolSpec{p}}
+
+	// multiple filesystems are not allowed
+	err := f.doFilesystemCreate(context, cephver.Octopus, spec, 1, false)
+	assert.Error(t, err)
+	assert.False(t, enabled)
+
+	err = f.doFilesystemCreate(context, cephver.Octopus, spec, 1, true)
+	assert.NoError(t, err)
+	assert.True(t, created)
+
+	// the pools of another filesystem are not reused
+	fses = `[{"name":"otherfs","metadata_pool":"otherfs-metadata","metadata_pool_id":1,"data_pool_ids":[2,3],"data_pools":["otherfs-data0","myfs-data0"]}]`
+	enabled, created = false, false
+	err = f.doFilesystemCreate(context, cephver.Octopus, spec, 1, true)
+	assert.Error(t, err)
+	assert.False(t, created)
+}
+
+func TestCheckSharedPools(t *testing.T) {
+	filesystems := []client.CephFilesystem{
+		{Name: "myfs", MetadataPool: "myfs-metadata", DataPools: []string{"myfs-data0"}},
+		{Name: "otherfs", MetadataPool: "otherfs-metadata", DataPools: []string{"otherfs-data0", "shared"}},
+	}
+	assert.NoError(t, checkSharedPools("myfs", []string{"myfs-metadata", "myfs-data0"}, filesystems))
+	assert.NoError(t, checkSharedPools("newfs", []string{"newfs-metadata", "newfs-data0"}, filesystems))
+	assert.Error(t, checkSharedPools("newfs", []string{"newfs-metadata", "shared"}, filesystems))
+	assert.Error(t, checkSharedPools("newfs", []string{"myfs-metadata"}, filesystems))
+}
+
+func TestMDSDaemonStatus(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			return `{"standbys":[{"gid":4109,"name":"myfs-b","rank":-1,"state":"up:standby","join_fscid":1},
+{"gid":4110,"name":"otherfs-a","rank":-1,"state":"up:standby","join_fscid":2}],
+"filesystems":[{"id":1,"mdsmap":{"fs_name":"myfs","info":{"gid_4107":{"gid":4107,"name":"myfs-a","rank":0,"state":"up:active","join_fscid":1}}}},
+{"id":2,"mdsmap":{"fs_name":"otherfs","info":{"gid_4108":{"gid":4108,"name":"myfs-c","rank":0,"state":"up:active","join_fscid":-1}}}}]}`, nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	fs := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
+		Spec:       cephv1.FilesystemSpec{MetadataServer: cephv1.MetadataServerSpec{ActiveCount: 2}},
+	}
+
+	// myfs-d is not running and otherfs-a belongs to another filesystem
+	status, err := mdsDaemonStatus(context, fs)
+	assert.NoError(t, err)
+	assert.Equal(t, []cephv1.MDSDaemonStatus{
+		{Name: "myfs-a", Filesystem: "myfs", JoinFilesystem: "myfs", Rank: 0, State: "up:active"},
+		{Name: "myfs-b", JoinFilesystem: "myfs", Rank: -1, State: "up:standby"},
+		{Name: "myfs-c", Filesystem: "otherfs", Rank: 0, State: "up:active"},
+	}, status)
 }
 
 func TestCreateNopoolFilesystem(t *testing.T) {
@@ -21,6 +21,7 @@ import (
 	"strconv"
 
 	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -63,17 +64,36 @@ func (c *Cluster) generateKeyring(m *mdsConfig) (string, error) {
 	return keyring, s.CreateOrUpdate(m.ResourceName, keyring)
 }
 
+// DerivedConfig returns the Ceph config options derived from the resources of the mds of the filesystem. The mds
+// cache memory limit is the ratio of the memory limit of the mds containers.
+func DerivedConfig(fs *cephv1.CephFilesystem) (map[string]string, error) {
+	ratio, err := config.ParseMemoryRatio(fs.Spec.MetadataServer.CacheMemoryLimitRatio, mdsCacheMemoryLimitFactor)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid cache memory limit ratio")
+	}
+	limit := config.MemoryFromLimit(fs.Spec.MetadataServer.Resources, ratio)
+	if limit == 0 {
+		return nil, nil
+	}
+	return map[string]string{"mds_cache_memory_limit": strconv.FormatInt(limit, 10)}, nil
+}
+
+// setDerivedConfig sets the options derived from the resources of the mds in the mon config store, and removes the
+// options previously derived as reported in the filesystem status
+func (c *Cluster) setDerivedConfig(mdsID string, derived map[string]string) error {
+	var previous map[string]string
+	if c.fs.Status != nil {
+		previous = c.fs.Status.DerivedConfig
+	}
+	monStore := config.GetMonStore(c.context, c.fs.Namespace)
+	return monStore.SetDerived(fmt.Sprintf("mds.%s", mdsID), derived, previous)
+}
+
 func (c *Cluster) setDefaultFlagsMonConfigStore(mdsID string) error {
 	monStore := config.GetMonStore(c.context, c.fs.Namespace)
 	who := fmt.Sprintf("mds.%s", mdsID)
 	configOptions := make(map[string]string)
 
-	// Set mds cache memory limit to the best appropriate value
-	if !c.fs.Spec.MetadataServer.Resources.Limits.Memory().IsZero() {
-		mdsCacheMemoryLimit := float64(c.fs.Spec.MetadataServer.Resources.Limits.Memory().Value()) * mdsCacheMemoryLimitFactor
-		configOptions["mds_cache_memory_limit"] = strconv.Itoa(int(mdsCacheMemoryLimit))
-	}
-
 	// Set mds_join_fs flag to force mds daemon to join a specific fs
 	if c.clusterInfo.CephVersion.IsAtLeastOctopus() {
 		configOptions["mds_join_fs"] = c.fs.Name
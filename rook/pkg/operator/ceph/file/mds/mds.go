@@ -97,6 +97,36 @@ func NewCluster(
 	}
 }
 
+// ActiveCount returns the number of active mds of the filesystem: the number chosen by the autoscaler within the
+// bounds of the autoscaling policy if it is set, or the active count of the spec
+func ActiveCount(fs *cephv1.CephFilesystem) int32 {
+	spec := fs.Spec.MetadataServer
+	if spec.Autoscale == nil {
+		return spec.ActiveCount
+	}
+	count := spec.ActiveCount
+	if fs.Status != nil && fs.Status.Autoscale != nil && fs.Status.Autoscale.ActiveCount > 0 {
+		count = fs.Status.Autoscale.ActiveCount
+	}
+	min, max := AutoscaleBounds(spec.Autoscale)
+	if count < min {
+		return min
+	}
+	if count > max {
+		return max
+	}
+	return count
+}
+
+// AutoscaleBounds returns the minimum and maximum number of active mds of an autoscaling policy
+func AutoscaleBounds(autoscale *cephv1.MDSAutoscaleSpec) (int32, int32) {
+	min := autoscale.MinActive
+	if min < 1 {
+		min = 1
+	}
+	return min, autoscale.MaxActive
+}
+
 // UpdateDeploymentAndWait can be overridden for unit tests. Do not alter this for runtime operation.
 var UpdateDeploymentAndWait = mon.UpdateCephDeploymentAndWait
 
@@ -110,18 +140,24 @@ func (c *Cluster) Start() error {
 
 	// If attempt was made to prepare daemons for upgrade, make sure that an attempt is made to
 	// bring fs state back to desired when this method returns with any error or success.
+	derived, err := DerivedConfig(&c.fs)
+	if err != nil {
+		return err
+	}
+
+	activeCount := ActiveCount(&c.fs)
 	var fsPreparedForUpgrade = false
 	defer func() {
 		if fsPreparedForUpgrade {
-			if err := finishedWithDaemonUpgrade(c.context, c.clusterInfo.CephVersion, c.fs.Namespace, c.fs.Name, c.fs.Spec.MetadataServer.ActiveCount); err != nil {
+			if err := finishedWithDaemonUpgrade(c.context, c.clusterInfo.CephVersion, c.fs.Namespace, c.fs.Name, activeCount); err != nil {
 				logger.Errorf("for filesystem %q, USER should make sure the Ceph fs max_mds property is set to %d. %v",
-					c.fs.Name, c.fs.Spec.MetadataServer.ActiveCount, err)
+					c.fs.Name, activeCount, err)
 			}
 		}
 	}()
 
 	// Always create double the number of metadata servers to have standby mdses available
-	replicas := c.fs.Spec.MetadataServer.ActiveCount * 2
+	replicas := activeCount * 2
 
 	// keep list of deployments we want so unwanted ones can be deleted later
 	desiredDeployments := map[string]bool{} // improvised set
@@ -145,18 +181,15 @@ func (c *Cluster) Start() error {
 			return errors.Wrapf(err, "failed to generate keyring for %q", resourceName)
 		}
 
-		// Check for existing deployment and set the daemon config flags
-		_, err = c.context.Clientset.AppsV1().Deployments(c.fs.Namespace).Get(mdsConfig.ResourceName, metav1.GetOptions{})
-		// We don't need to handle any error here
-		if err != nil {
-			// Apply the flag only when the deployment is not found
-			if kerrors.IsNotFound(err) {
-				logger.Info("setting mds config flags")
-				err = c.setDefaultFlagsMonConfigStore(mdsConfig.DaemonID)
-				if err != nil {
-					return errors.Wrapf(err, "failed to set default mds config options")
-				}
-			}
+		// Set the daemon config flags on every reconcile so the existing mds are also bound to the filesystem
+		// once the cluster runs Octopus
+		if err := c.setDefaultFlagsMonConfigStore(mdsConfig.DaemonID); err != nil {
+			return errors.Wrapf(err, "failed to set default mds config options")
+		}
+
+		// Set the options derived from the resources on every reconcile so they follow the changes of the resources
+		if err := c.setDerivedConfig(mdsConfig.DaemonID, derived); err != nil {
+			return errors.Wrapf(err, "failed to set derived mds config options")
 		}
 
 		// start the deployment
@@ -195,14 +228,14 @@ func (c *Cluster) Start() error {
 
 	}
 
-	if err := c.scaleDownDeployments(replicas, desiredDeployments); err != nil {
+	if err := c.scaleDownDeployments(replicas, activeCount, desiredDeployments); err != nil {
 		return errors.Wrap(err, "failed to scale down mds deployments")
 	}
 
 	return nil
 }
 
-func (c *Cluster) scaleDownDeployments(replicas int32, desiredDeployments map[string]bool) error {
+func (c *Cluster) scaleDownDeployments(replicas, activeCount int32, desiredDeployments map[string]bool) error {
 	// Remove extraneous mds deployments if they exist
 	deps, err := getMdsDeployments(c.context, c.fs.Namespace, c.fs.Name)
 	if err != nil {
@@ -226,7 +259,7 @@ func (c *Cluster) scaleDownDeployments(replicas int32, desiredDeployments map[st
 			// if the extraneous mdses are the only ones active, Ceph may experience fs downtime
 			// if deleting them too quickly; therefore, wait until number of active mdses is desired
 			if err := client.WaitForActiveRanks(c.context, c.fs.Namespace, c.fs.Name,
-				c.fs.Spec.MetadataServer.ActiveCount, true, fsWaitForActiveTimeout); err != nil {
+				activeCount, true, fsWaitForActiveTimeout); err != nil {
 				errCount++
 				logger.Errorf(
 					"number of active mds ranks is not as desired. it is potentially unsafe to continue with extraneous mds deletion, so stopping. " +
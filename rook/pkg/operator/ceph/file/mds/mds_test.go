@@ -0,0 +1,75 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mds
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestActiveCount(t *testing.T) {
+	fs := &cephv1.CephFilesystem{
+		Spec: cephv1.FilesystemSpec{MetadataServer: cephv1.MetadataServerSpec{ActiveCount: 2}},
+	}
+	assert.Equal(t, int32(2), ActiveCount(fs))
+
+	// the count chosen by the autoscaler is ignored without autoscaling policy
+	fs.Status = &cephv1.CephFilesystemStatus{Autoscale: &cephv1.MDSAutoscaleStatus{ActiveCount: 3}}
+	assert.Equal(t, int32(2), ActiveCount(fs))
+
+	fs.Spec.MetadataServer.Autoscale = &cephv1.MDSAutoscaleSpec{MaxActive: 4}
+	assert.Equal(t, int32(3), ActiveCount(fs))
+
+	// the count is kept within the bounds of the policy
+	fs.Spec.MetadataServer.Autoscale.MaxActive = 2
+	assert.Equal(t, int32(2), ActiveCount(fs))
+	fs.Spec.MetadataServer.Autoscale = &cephv1.MDSAutoscaleSpec{MinActive: 4, MaxActive: 5}
+	assert.Equal(t, int32(4), ActiveCount(fs))
+
+	// the active count of the spec is the initial count
+	fs.Status = nil
+	fs.Spec.MetadataServer.Autoscale = &cephv1.MDSAutoscaleSpec{MaxActive: 4}
+	assert.Equal(t, int32(2), ActiveCount(fs))
+}
+
+func TestDerivedConfig(t *testing.T) {
+	fs := &cephv1.CephFilesystem{}
+	derived, err := DerivedConfig(fs)
+	assert.NoError(t, err)
+	assert.Nil(t, derived)
+
+	// half of the memory limit by default
+	fs.Spec.MetadataServer.Resources = v1.ResourceRequirements{
+		Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("4Gi")},
+	}
+	derived, err = DerivedConfig(fs)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"mds_cache_memory_limit": "2147483648"}, derived)
+
+	fs.Spec.MetadataServer.CacheMemoryLimitRatio = "0.25"
+	derived, err = DerivedConfig(fs)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"mds_cache_memory_limit": "1073741824"}, derived)
+
+	fs.Spec.MetadataServer.CacheMemoryLimitRatio = "2"
+	_, err = DerivedConfig(fs)
+	assert.Error(t, err)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirror
+
+import (
+	"fmt"
+
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
+	apps "k8s.io/api/apps/v1"
+)
+
+const (
+	keyringTemplate = `
+[client.fs-mirror.%s]
+	key = %s
+	caps mds = "allow r"
+	caps mgr = "allow r"
+	caps mon = "profile cephfs-mirror"
+	caps osd = "allow rw tag cephfs metadata=*, allow r tag cephfs data=*"
+`
+)
+
+// daemonConfig for the cephfs-mirror of a filesystem
+type daemonConfig struct {
+	ResourceName string              // the name rook gives to mirror resources in k8s metadata
+	DaemonID     string              // the ID of the Ceph daemon, the name of the filesystem
+	DataPathMap  *config.DataPathMap // location to store data in container
+}
+
+func (m *Mirror) generateKeyring(daemonConfig *daemonConfig) (string, error) {
+	user := fullDaemonName(daemonConfig.DaemonID)
+	access := []string{
+		"mds", "allow r",
+		"mgr", "allow r",
+		"mon", "profile cephfs-mirror",
+		"osd", "allow rw tag cephfs metadata=*, allow r tag cephfs data=*",
+	}
+	s := keyring.GetSecretStore(m.context, m.fs.Namespace, &m.ownerRef)
+
+	key, err := s.GenerateKey(user, access)
+	if err != nil {
+		return "", err
+	}
+
+	keyring := fmt.Sprintf(keyringTemplate, daemonConfig.DaemonID, key)
+	return keyring, s.CreateOrUpdate(daemonConfig.ResourceName, keyring)
+}
+
+func (m *Mirror) associateKeyring(existingKeyring string, d *apps.Deployment) error {
+	s := keyring.GetSecretStoreForDeployment(m.context, d)
+	return s.CreateOrUpdate(d.GetName(), existingKeyring)
+}
+
+func fullDaemonName(daemonID string) string {
+	return fmt.Sprintf("client.fs-mirror.%s", daemonID)
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mirror for the cephfs-mirror daemons of the filesystems
+package mirror
+
+import (
+	"fmt"
+
+	"github.com/banzaicloud/k8s-objectmatcher/patch"
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-fs-mirror")
+
+const (
+	// AppName is the ceph cephfs-mirror application name
+	AppName = "rook-ceph-fs-mirror"
+	// minimum amount of memory in MB to run the pod
+	cephFSMirrorPodMinimumMemory uint64 = 512
+)
+
+// Mirror represents the cephfs-mirror daemon of a filesystem. The daemons register with the mirroring mgr module,
+// which distributes the mirrored directories of all the filesystems among them.
+type Mirror struct {
+	clusterInfo     *cephconfig.ClusterInfo
+	context         *clusterd.Context
+	clusterSpec     *cephv1.ClusterSpec
+	fs              cephv1.CephFilesystem
+	ownerRef        metav1.OwnerReference
+	dataDirHostPath string
+}
+
+// New creates an instance of the cephfs-mirror daemon of a filesystem
+func New(
+	clusterInfo *cephconfig.ClusterInfo,
+	context *clusterd.Context,
+	clusterSpec *cephv1.ClusterSpec,
+	fs cephv1.CephFilesystem,
+	ownerRef metav1.OwnerReference,
+	dataDirHostPath string,
+) *Mirror {
+	return &Mirror{
+		clusterInfo:     clusterInfo,
+		context:         context,
+		clusterSpec:     clusterSpec,
+		fs:              fs,
+		ownerRef:        ownerRef,
+		dataDirHostPath: dataDirHostPath,
+	}
+}
+
+var updateDeploymentAndWait = mon.UpdateCephDeploymentAndWait
+
+// Start creates or updates the cephfs-mirror daemon of the filesystem
+func (m *Mirror) Start() error {
+	// Validate pod's memory if specified
+	err := controller.CheckPodMemory(m.fs.Spec.Mirroring.Resources, cephFSMirrorPodMinimumMemory)
+	if err != nil {
+		return errors.Wrap(err, "error checking pod memory")
+	}
+
+	daemonConf := m.daemonConfig()
+	keyring, err := m.generateKeyring(daemonConf)
+	if err != nil {
+		return errors.Wrapf(err, "failed to generate keyring for %q", daemonConf.ResourceName)
+	}
+
+	d := m.makeDeployment(daemonConf)
+
+	// Set the deployment hash as an annotation
+	err = patch.DefaultAnnotator.SetLastAppliedAnnotation(d)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set annotation for deployment %q", d.Name)
+	}
+
+	if _, err := m.context.Clientset.AppsV1().Deployments(m.fs.Namespace).Create(d); err != nil {
+		if !kerrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "failed to create %s deployment", daemonConf.ResourceName)
+		}
+		logger.Infof("deployment for cephfs-mirror %s already exists. updating if needed", daemonConf.ResourceName)
+		if err := updateDeploymentAndWait(m.context, d, m.fs.Namespace, config.FilesystemMirrorType, daemonConf.DaemonID,
+			m.clusterSpec.SkipUpgradeChecks, m.clusterSpec.ContinueUpgradeAfterChecksEvenIfNotHealthy); err != nil {
+			return errors.Wrapf(err, "failed to update cephfs-mirror deployment %s", daemonConf.ResourceName)
+		}
+	}
+
+	if existingDeployment, err := m.context.Clientset.AppsV1().Deployments(m.fs.Namespace).Get(d.GetName(), metav1.GetOptions{}); err != nil {
+		logger.Warningf("failed to find cephfs-mirror deployment %q for keyring association. %v", daemonConf.ResourceName, err)
+	} else {
+		if err = m.associateKeyring(keyring, existingDeployment); err != nil {
+			logger.Warningf("failed to associate keyring with cephfs-mirror deployment %q. %v", daemonConf.ResourceName, err)
+		}
+	}
+	logger.Infof("%s deployment started", daemonConf.ResourceName)
+	return nil
+}
+
+// Remove deletes the cephfs-mirror daemon of the filesystem and its key
+func (m *Mirror) Remove() error {
+	daemonConf := m.daemonConfig()
+	err := m.context.Clientset.AppsV1().Deployments(m.fs.Namespace).Delete(daemonConf.ResourceName, &metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete cephfs-mirror deployment %s", daemonConf.ResourceName)
+	}
+
+	if err := client.AuthDelete(m.context, m.fs.Namespace, fullDaemonName(daemonConf.DaemonID)); err != nil {
+		return err
+	}
+	if err := keyring.GetSecretStore(m.context, m.fs.Namespace, &m.ownerRef).Delete(daemonConf.ResourceName); err != nil {
+		return err
+	}
+	logger.Infof("removed cephfs-mirror %s", daemonConf.ResourceName)
+	return nil
+}
+
+func (m *Mirror) daemonConfig() *daemonConfig {
+	return &daemonConfig{
+		DaemonID:     m.fs.Name,
+		ResourceName: fmt.Sprintf("%s-%s", AppName, m.fs.Name),
+		DataPathMap:  config.NewDatalessDaemonDataPathMap(m.fs.Namespace, m.dataDirHostPath),
+	}
+}
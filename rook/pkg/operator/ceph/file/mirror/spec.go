@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirror
+
+import (
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (m *Mirror) makeDeployment(daemonConfig *daemonConfig) *apps.Deployment {
+	spec := m.fs.Spec.Mirroring
+	podSpec := v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   daemonConfig.ResourceName,
+			Labels: m.podLabels(daemonConfig),
+		},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{
+				m.makeChownInitContainer(daemonConfig),
+			},
+			Containers: []v1.Container{
+				m.makeMirroringDaemonContainer(daemonConfig),
+			},
+			RestartPolicy:     v1.RestartPolicyAlways,
+			Volumes:           controller.DaemonVolumes(daemonConfig.DataPathMap, daemonConfig.ResourceName),
+			HostNetwork:       m.clusterSpec.Network.IsHost(),
+			PriorityClassName: spec.PriorityClassName,
+		},
+	}
+	// Replace default unreachable node toleration
+	k8sutil.AddUnreachableNodeToleration(&podSpec.Spec)
+
+	if m.clusterSpec.Network.IsHost() {
+		podSpec.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
+	} else if m.clusterSpec.Network.NetworkSpec.IsMultus() {
+		k8sutil.ApplyMultus(m.clusterSpec.Network.NetworkSpec, &podSpec.ObjectMeta)
+	}
+	spec.Annotations.ApplyToObjectMeta(&podSpec.ObjectMeta)
+	spec.Placement.ApplyToPodSpec(&podSpec.Spec)
+
+	replicas := int32(1)
+	d := &apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      daemonConfig.ResourceName,
+			Namespace: m.fs.Namespace,
+			Labels:    m.podLabels(daemonConfig),
+		},
+		Spec: apps.DeploymentSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: podSpec.Labels,
+			},
+			Template: podSpec,
+			Replicas: &replicas,
+		},
+	}
+	k8sutil.AddRookVersionLabelToDeployment(d)
+	spec.Annotations.ApplyToObjectMeta(&d.ObjectMeta)
+	controller.AddCephVersionLabelToDeployment(m.clusterInfo.CephVersion, d)
+	k8sutil.SetOwnerRef(&d.ObjectMeta, &m.ownerRef)
+	return d
+}
+
+func (m *Mirror) makeChownInitContainer(daemonConfig *daemonConfig) v1.Container {
+	return controller.ChownCephDataDirsInitContainer(
+		*daemonConfig.DataPathMap,
+		m.clusterSpec.CephVersion.Image,
+		controller.DaemonVolumeMounts(daemonConfig.DataPathMap, daemonConfig.ResourceName),
+		m.fs.Spec.Mirroring.Resources,
+		mon.PodSecurityContext(),
+	)
+}
+
+func (m *Mirror) makeMirroringDaemonContainer(daemonConfig *daemonConfig) v1.Container {
+	return v1.Container{
+		Name: "cephfs-mirror",
+		Command: []string{
+			"cephfs-mirror",
+		},
+		Args: append(
+			controller.DaemonFlags(m.clusterInfo, daemonConfig.DaemonID),
+			"--foreground",
+			"--name="+fullDaemonName(daemonConfig.DaemonID),
+		),
+		Image:           m.clusterSpec.CephVersion.Image,
+		VolumeMounts:    controller.DaemonVolumeMounts(daemonConfig.DataPathMap, daemonConfig.ResourceName),
+		Env:             controller.DaemonEnvVars(m.clusterSpec.CephVersion.Image),
+		Resources:       m.fs.Spec.Mirroring.Resources,
+		SecurityContext: mon.PodSecurityContext(),
+	}
+}
+
+func (m *Mirror) podLabels(daemonConfig *daemonConfig) map[string]string {
+	labels := controller.PodLabels(AppName, m.fs.Namespace, config.FilesystemMirrorType, daemonConfig.DaemonID)
+	labels["rook_file_system"] = m.fs.Name
+	return labels
+}
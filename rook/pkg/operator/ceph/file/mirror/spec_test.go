@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirror
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	cephtest "github.com/rook/rook/pkg/operator/ceph/test"
+	optest "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodSpec(t *testing.T) {
+	clientset := optest.New(t, 1)
+	fs := cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
+		Spec: cephv1.FilesystemSpec{
+			Mirroring: &cephv1.FilesystemMirroringSpec{
+				Resources: v1.ResourceRequirements{
+					Limits: v1.ResourceList{
+						v1.ResourceCPU:    *resource.NewQuantity(200.0, resource.BinarySI),
+						v1.ResourceMemory: *resource.NewQuantity(600.0, resource.BinarySI),
+					},
+					Requests: v1.ResourceList{
+						v1.ResourceCPU:    *resource.NewQuantity(100.0, resource.BinarySI),
+						v1.ResourceMemory: *resource.NewQuantity(300.0, resource.BinarySI),
+					},
+				},
+				PriorityClassName: "my-priority-class",
+			},
+		},
+	}
+	m := New(
+		&cephconfig.ClusterInfo{FSID: "myfsid"},
+		&clusterd.Context{Clientset: clientset},
+		&cephv1.ClusterSpec{CephVersion: cephv1.CephVersionSpec{Image: "ceph/ceph:myceph"}},
+		fs,
+		metav1.OwnerReference{},
+		"/var/lib/rook/",
+	)
+
+	d := m.makeDeployment(m.daemonConfig())
+	assert.Equal(t, "rook-ceph-fs-mirror-myfs", d.Name)
+	assert.Equal(t, "myfs", d.Spec.Template.Labels["rook_file_system"])
+	assert.Contains(t, d.Spec.Template.Spec.Containers[0].Args, "--name=client.fs-mirror.myfs")
+
+	// Deployment should have Ceph labels
+	cephtest.AssertLabelsContainCephRequirements(t, d.ObjectMeta.Labels,
+		config.FilesystemMirrorType, "myfs", AppName, "ns")
+
+	podTemplate := cephtest.NewPodTemplateSpecTester(t, &d.Spec.Template)
+	podTemplate.RunFullSuite(config.FilesystemMirrorType, "myfs", AppName, "ns", "ceph/ceph:myceph",
+		"200", "100", "600", "300", /* resources */
+		"my-priority-class")
+}
@@ -0,0 +1,323 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"path"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/file/mirror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// the key of the bootstrap token in the secrets of the mirror peers
+const mirrorPeerTokenKey = "token"
+
+// validateMirroring checks the mirroring settings of the filesystem spec
+func validateMirroring(fs *cephv1.CephFilesystem) error {
+	spec := fs.Spec.Mirroring
+	if spec == nil {
+		return nil
+	}
+	secrets := map[string]bool{}
+	for _, p := range spec.Peers {
+		if p.SecretName == "" {
+			return errors.New("missing secret name of mirror peer")
+		}
+		if secrets[p.SecretName] {
+			return errors.Errorf("mirror peer secret %q is specified more than once", p.SecretName)
+		}
+		secrets[p.SecretName] = true
+	}
+	paths := map[string]bool{}
+	for _, d := range spec.Directories {
+		if !path.IsAbs(d) {
+			return errors.Errorf("invalid mirrored directory %q, it must be an absolute path", d)
+		}
+		p := path.Clean(d)
+		if paths[p] {
+			return errors.Errorf("mirrored directory %q is specified more than once", p)
+		}
+		paths[p] = true
+	}
+	return nil
+}
+
+// reconcileMirroring starts the cephfs-mirror daemon of the filesystem, adds the peers and the directories of the
+// mirroring spec, removes the others and returns the status of the mirroring. The mirroring is disabled when it is
+// removed from the spec.
+func reconcileMirroring(
+	context *clusterd.Context,
+	clusterInfo *cephconfig.ClusterInfo,
+	clusterSpec *cephv1.ClusterSpec,
+	fs *cephv1.CephFilesystem,
+	ownerRef metav1.OwnerReference,
+) *cephv1.FilesystemMirroringStatus {
+	var previous *cephv1.FilesystemMirroringStatus
+	if fs.Status != nil {
+		previous = fs.Status.Mirroring
+	}
+	m := mirror.New(clusterInfo, context, clusterSpec, *fs, ownerRef, clusterSpec.DataDirHostPath)
+
+	if fs.Spec.Mirroring == nil {
+		if previous == nil {
+			return nil
+		}
+		if err := disableMirroring(context, clusterInfo, fs, m, previous); err != nil {
+			logger.Errorf("failed to disable the mirroring of filesystem %q. %v", fs.Name, err)
+			status := previous.DeepCopy()
+			status.Error = err.Error()
+			return status
+		}
+		return nil
+	}
+
+	status := &cephv1.FilesystemMirroringStatus{}
+	if !clusterInfo.CephVersion.IsAtLeastPacific() {
+		logger.Errorf("mirroring of filesystem %q requires ceph pacific or newer", fs.Name)
+		status.Error = "the mirroring of filesystems requires ceph pacific or newer"
+		return status
+	}
+
+	if err := m.Start(); err != nil {
+		logger.Errorf("failed to start the cephfs-mirror daemon of filesystem %q. %v", fs.Name, err)
+		status.Error = err.Error()
+		return status
+	}
+	if err := cephclient.MgrEnableModule(context, clusterInfo.Name, cephclient.FilesystemMirroringModule, false); err != nil {
+		logger.Errorf("failed to enable the mirroring of filesystem %q. %v", fs.Name, err)
+		status.Error = err.Error()
+		return status
+	}
+	if err := cephclient.EnableFilesystemMirroring(context, clusterInfo.Name, fs.Name); err != nil {
+		logger.Errorf("%v", err)
+		status.Error = err.Error()
+		return status
+	}
+
+	status.Peers = reconcileMirrorPeers(context, clusterInfo, fs)
+	status.Directories = reconcileMirrorDirectories(context, clusterInfo, fs, previous)
+	return status
+}
+
+// disableMirroring stops mirroring the directories of the previous status, disables the mirroring of the
+// filesystem and removes its cephfs-mirror daemon
+func disableMirroring(context *clusterd.Context, clusterInfo *cephconfig.ClusterInfo, fs *cephv1.CephFilesystem, m *mirror.Mirror, previous *cephv1.FilesystemMirroringStatus) error {
+	for _, d := range previous.Directories {
+		if err := cephclient.RemoveFilesystemMirrorDirectory(context, clusterInfo.Name, fs.Name, d.Path); err != nil {
+			return err
+		}
+	}
+	if clusterInfo.CephVersion.IsAtLeastPacific() {
+		if err := cephclient.DisableFilesystemMirroring(context, clusterInfo.Name, fs.Name); err != nil {
+			return err
+		}
+	}
+	if err := m.Remove(); err != nil {
+		return err
+	}
+	logger.Infof("disabled the mirroring of filesystem %q", fs.Name)
+	return nil
+}
+
+// reconcileMirrorPeers adds the peers of the secrets of the spec and removes the other peers of the filesystem,
+// unless a secret could not be read
+func reconcileMirrorPeers(context *clusterd.Context, clusterInfo *cephconfig.ClusterInfo, fs *cephv1.CephFilesystem) []cephv1.FilesystemMirrorPeerStatus {
+	spec := fs.Spec.Mirroring
+	statuses := make([]cephv1.FilesystemMirrorPeerStatus, len(spec.Peers))
+	tokens := make([]*cephclient.FilesystemMirrorBootstrapToken, len(spec.Peers))
+	setErrors := func(err error) []cephv1.FilesystemMirrorPeerStatus {
+		for i := range statuses {
+			statuses[i].Error = err.Error()
+		}
+		return statuses
+	}
+	for i, p := range spec.Peers {
+		statuses[i].SecretName = p.SecretName
+	}
+
+	existing, err := cephclient.ListFilesystemMirrorPeers(context, clusterInfo.Name, fs.Name)
+	if err != nil {
+		logger.Errorf("%v", err)
+		return setErrors(err)
+	}
+
+	complete := true
+	for i, p := range spec.Peers {
+		rawToken, token, err := mirrorPeerToken(context, fs.Namespace, p.SecretName)
+		if err != nil {
+			logger.Errorf("failed to get the mirror peer of filesystem %q. %v", fs.Name, err)
+			statuses[i].Error = err.Error()
+			complete = false
+			continue
+		}
+		tokens[i] = token
+		if _, found := findMirrorPeer(existing, token); found {
+			continue
+		}
+		if err := cephclient.ImportFilesystemMirrorPeer(context, clusterInfo.Name, fs.Name, rawToken); err != nil {
+			logger.Errorf("%v", err)
+			statuses[i].Error = err.Error()
+		}
+	}
+
+	if complete {
+		for uuid, peer := range existing {
+			found := false
+			for _, token := range tokens {
+				if peer.Matches(token) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				if err := cephclient.RemoveFilesystemMirrorPeer(context, clusterInfo.Name, fs.Name, uuid); err != nil {
+					logger.Errorf("%v", err)
+				}
+			}
+		}
+	}
+
+	// report the peers with their uuid
+	existing, err = cephclient.ListFilesystemMirrorPeers(context, clusterInfo.Name, fs.Name)
+	if err != nil {
+		logger.Errorf("%v", err)
+		return setErrors(err)
+	}
+	for i, token := range tokens {
+		if token == nil {
+			continue
+		}
+		statuses[i].SiteName = token.SiteName
+		statuses[i].Filesystem = token.Filesystem
+		statuses[i].ClientName = token.User
+		if uuid, found := findMirrorPeer(existing, token); found {
+			statuses[i].UUID = uuid
+		}
+	}
+	return statuses
+}
+
+// reconcileMirrorDirectories mirrors the directories of the spec, stops mirroring the directories of the previous
+// status that are no longer in the spec and returns the state of the directories
+func reconcileMirrorDirectories(context *clusterd.Context, clusterInfo *cephconfig.ClusterInfo, fs *cephv1.CephFilesystem, previous *cephv1.FilesystemMirroringStatus) []cephv1.FilesystemMirrorDirectoryStatus {
+	statuses := []cephv1.FilesystemMirrorDirectoryStatus{}
+	desired := map[string]bool{}
+	for _, d := range fs.Spec.Mirroring.Directories {
+		desired[path.Clean(d)] = true
+	}
+
+	if previous != nil {
+		for _, d := range previous.Directories {
+			if desired[d.Path] {
+				continue
+			}
+			if err := cephclient.RemoveFilesystemMirrorDirectory(context, clusterInfo.Name, fs.Name, d.Path); err != nil {
+				// keep reporting the directory to stop mirroring it at the next reconcile
+				logger.Errorf("%v", err)
+				statuses = append(statuses, cephv1.FilesystemMirrorDirectoryStatus{Path: d.Path, Error: err.Error()})
+			}
+		}
+	}
+
+	for _, d := range fs.Spec.Mirroring.Directories {
+		status := cephv1.FilesystemMirrorDirectoryStatus{Path: path.Clean(d)}
+		if err := cephclient.AddFilesystemMirrorDirectory(context, clusterInfo.Name, fs.Name, status.Path); err != nil {
+			logger.Errorf("%v", err)
+			status.Error = err.Error()
+			statuses = append(statuses, status)
+			continue
+		}
+		dirMap, err := cephclient.GetFilesystemMirrorDirMap(context, clusterInfo.Name, fs.Name, status.Path)
+		if err != nil {
+			logger.Errorf("%v", err)
+			status.Error = err.Error()
+		} else {
+			setMirrorDirectoryState(&status, dirMap)
+		}
+		statuses = append(statuses, status)
+	}
+	if len(statuses) == 0 {
+		return nil
+	}
+	return statuses
+}
+
+// mirrorDirectoryStates returns the synchronization state of the mirrored directories of the spec that are in the
+// status of the filesystem, without changing the mirroring. The directories whose state cannot be read are skipped.
+func mirrorDirectoryStates(context *clusterd.Context, fs *cephv1.CephFilesystem) map[string]*cephclient.FilesystemMirrorDirMap {
+	if fs.Spec.Mirroring == nil || fs.Status == nil || fs.Status.Mirroring == nil || fs.Status.Mirroring.Error != "" {
+		return nil
+	}
+	desired := map[string]bool{}
+	for _, d := range fs.Spec.Mirroring.Directories {
+		desired[path.Clean(d)] = true
+	}
+
+	states := map[string]*cephclient.FilesystemMirrorDirMap{}
+	for _, d := range fs.Status.Mirroring.Directories {
+		if !desired[d.Path] {
+			// the directory is still being removed by the reconcile of the filesystem
+			continue
+		}
+		dirMap, err := cephclient.GetFilesystemMirrorDirMap(context, fs.Namespace, fs.Name, d.Path)
+		if err != nil {
+			logger.Warningf("failed to refresh the state of mirrored directory %q. %v", d.Path, err)
+			continue
+		}
+		states[d.Path] = dirMap
+	}
+	return states
+}
+
+// setMirrorDirectoryState reports the synchronization state of a mirrored directory in its status
+func setMirrorDirectoryState(status *cephv1.FilesystemMirrorDirectoryStatus, dirMap *cephclient.FilesystemMirrorDirMap) {
+	status.State = dirMap.State
+	status.InstanceID = dirMap.InstanceID
+	status.Reason = dirMap.Reason
+	status.Error = ""
+}
+
+// mirrorPeerToken returns the bootstrap token of the secret of a mirror peer, raw and decoded
+func mirrorPeerToken(context *clusterd.Context, namespace, secretName string) (string, *cephclient.FilesystemMirrorBootstrapToken, error) {
+	secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to get mirror peer secret %q", secretName)
+	}
+	rawToken, ok := secret.Data[mirrorPeerTokenKey]
+	if !ok {
+		return "", nil, errors.Errorf("mirror peer secret %q has no %q key", secretName, mirrorPeerTokenKey)
+	}
+	token, err := cephclient.ParseFilesystemMirrorBootstrapToken(string(rawToken))
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "invalid bootstrap token in mirror peer secret %q", secretName)
+	}
+	return string(rawToken), token, nil
+}
+
+func findMirrorPeer(peers map[string]cephclient.FilesystemMirrorPeer, token *cephclient.FilesystemMirrorBootstrapToken) (string, bool) {
+	for uuid, peer := range peers {
+		if peer.Matches(token) {
+			return uuid, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newMirroringFilesystem(mirroring *cephv1.FilesystemMirroringSpec) *cephv1.CephFilesystem {
+	return &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
+		Spec: cephv1.FilesystemSpec{
+			MetadataServer: cephv1.MetadataServerSpec{ActiveCount: 1},
+			Mirroring:      mirroring,
+		},
+	}
+}
+
+func TestValidateMirroring(t *testing.T) {
+	assert.NoError(t, validateMirroring(newMirroringFilesystem(nil)))
+	assert.NoError(t, validateMirroring(newMirroringFilesystem(&cephv1.FilesystemMirroringSpec{
+		Peers:       []cephv1.FilesystemMirrorPeerSpec{{SecretName: "site-b"}, {SecretName: "site-c"}},
+		Directories: []string{"/", "/projects"},
+	})))
+
+	invalid := []*cephv1.FilesystemMirroringSpec{
+		{Peers: []cephv1.FilesystemMirrorPeerSpec{{}}},
+		{Peers: []cephv1.FilesystemMirrorPeerSpec{{SecretName: "site-b"}, {SecretName: "site-b"}}},
+		{Directories: []string{"projects"}},
+		{Directories: []string{"/projects", "/projects/"}},
+	}
+	for _, spec := range invalid {
+		assert.Error(t, validateMirroring(newMirroringFilesystem(spec)), spec)
+	}
+}
+
+func TestReconcileMirroring(t *testing.T) {
+	peerList := `{"3a1b":{"client_name":"client.mirror_remote","site_name":"site-c","fs_name":"backupfs"}}`
+	calls := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			for i, arg := range args {
+				if strings.HasPrefix(arg, "--connect-timeout") {
+					args = args[:i]
+					break
+				}
+			}
+			if args[0] == "auth" {
+				return `{"key":"mysecret"}`, nil
+			}
+			calls = append(calls, strings.Join(args, " "))
+			if len(args) > 3 && args[3] == "peer_list" {
+				return peerList, nil
+			}
+			if len(args) > 3 && args[3] == "dirmap" {
+				return `{"instance_id":"4242","state":"mapped"}`, nil
+			}
+			return "", nil
+		},
+	}
+	clientset := testop.New(t, 1)
+	context := &clusterd.Context{Executor: executor, Clientset: clientset}
+	clusterInfo := &cephconfig.ClusterInfo{Name: "ns", CephVersion: cephver.Pacific}
+	clusterSpec := &cephv1.ClusterSpec{CephVersion: cephv1.CephVersionSpec{Image: "ceph/ceph:v16"}}
+
+	token := base64.StdEncoding.EncodeToString([]byte(`{"fsid":"abc","filesystem":"backupfs","user":"client.mirror_remote","site_name":"site-b"}`))
+	_, err := clientset.CoreV1().Secrets("ns").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "site-b", Namespace: "ns"},
+		Data:       map[string][]byte{"token": []byte(token)},
+	})
+	assert.NoError(t, err)
+
+	// the peer of site-b is imported, the peer of site-c is removed, /old is no longer mirrored
+	fs := newMirroringFilesystem(&cephv1.FilesystemMirroringSpec{
+		Peers:       []cephv1.FilesystemMirrorPeerSpec{{SecretName: "site-b"}},
+		Directories: []string{"/projects/"},
+	})
+	fs.Status = &cephv1.CephFilesystemStatus{Mirroring: &cephv1.FilesystemMirroringStatus{
+		Directories: []cephv1.FilesystemMirrorDirectoryStatus{{Path: "/old"}, {Path: "/projects"}},
+	}}
+	status := reconcileMirroring(context, clusterInfo, clusterSpec, fs, metav1.OwnerReference{})
+	assert.Equal(t, []string{
+		"mgr module enable mirroring",
+		"fs snapshot mirror enable myfs",
+		"fs snapshot mirror peer_list myfs",
+		"fs snapshot mirror peer_bootstrap import myfs " + token,
+		"fs snapshot mirror peer_remove myfs 3a1b",
+		"fs snapshot mirror peer_list myfs",
+		"fs snapshot mirror remove myfs /old",
+		"fs snapshot mirror add myfs /projects",
+		"fs snapshot mirror dirmap myfs /projects",
+	}, calls)
+	assert.Equal(t, &cephv1.FilesystemMirroringStatus{
+		// the mock still doesn't report the new peer
+		Peers:       []cephv1.FilesystemMirrorPeerStatus{{SecretName: "site-b", SiteName: "site-b", Filesystem: "backupfs", ClientName: "client.mirror_remote"}},
+		Directories: []cephv1.FilesystemMirrorDirectoryStatus{{Path: "/projects", State: "mapped", InstanceID: "4242"}},
+	}, status)
+
+	deployment, err := clientset.AppsV1().Deployments("ns").Get("rook-ceph-fs-mirror-myfs", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "myfs", deployment.Spec.Template.Labels["rook_file_system"])
+
+	// the peers are not removed while a secret is missing
+	calls = []string{}
+	fs.Spec.Mirroring.Peers = append(fs.Spec.Mirroring.Peers, cephv1.FilesystemMirrorPeerSpec{SecretName: "site-d"})
+	peers := reconcileMirrorPeers(context, clusterInfo, fs)
+	assert.NotContains(t, calls, "fs snapshot mirror peer_remove myfs 3a1b")
+	assert.Equal(t, 2, len(peers))
+	assert.Empty(t, peers[0].Error)
+	assert.Contains(t, peers[1].Error, "site-d")
+
+	// removing the mirroring from the spec disables it and removes the daemon
+	calls = []string{}
+	fs.Spec.Mirroring = nil
+	fs.Status.Mirroring = status
+	assert.Nil(t, reconcileMirroring(context, clusterInfo, clusterSpec, fs, metav1.OwnerReference{}))
+	assert.Equal(t, []string{
+		"fs snapshot mirror remove myfs /projects",
+		"fs snapshot mirror disable myfs",
+	}, calls)
+	_, err = clientset.AppsV1().Deployments("ns").Get("rook-ceph-fs-mirror-myfs", metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+func TestMirroringRequiresPacific(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			assert.Fail(t, "no command expected", args)
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: testop.New(t, 1)}
+	clusterInfo := &cephconfig.ClusterInfo{Name: "ns", CephVersion: cephver.Octopus}
+
+	fs := newMirroringFilesystem(&cephv1.FilesystemMirroringSpec{Directories: []string{"/"}})
+	status := reconcileMirroring(context, clusterInfo, &cephv1.ClusterSpec{}, fs, metav1.OwnerReference{})
+	assert.Contains(t, status.Error, "pacific")
+
+	assert.Nil(t, reconcileMirroring(context, clusterInfo, &cephv1.ClusterSpec{}, newMirroringFilesystem(nil), metav1.OwnerReference{}))
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+var filesystemMonitorInterval = time.Minute
+
+// filesystemMonitor checks the ready filesystems at set intervals: it scales their active mds, evicts their stale
+// clients and refreshes their status
+type filesystemMonitor struct {
+	client     client.Client
+	autoscaler *mdsAutoscaler
+	evictor    *clientEvictor
+	reporter   *filesystemStatusReporter
+}
+
+func newFilesystemMonitor(client client.Client, context *clusterd.Context) *filesystemMonitor {
+	return &filesystemMonitor{
+		client:     client,
+		autoscaler: newMDSAutoscaler(client, context),
+		evictor:    newClientEvictor(client, context),
+		reporter:   newFilesystemStatusReporter(client, context),
+	}
+}
+
+// autoscaleEvents returns the channel of the filesystems whose number of active mds was changed by the autoscaler
+func (m *filesystemMonitor) autoscaleEvents() chan event.GenericEvent {
+	return m.autoscaler.events
+}
+
+// Start checks the filesystems at set intervals until the operator stops
+func (m *filesystemMonitor) Start(stopCh <-chan struct{}) error {
+	for {
+		select {
+		case <-time.After(filesystemMonitorInterval):
+			logger.Debug("checking the filesystems")
+			for _, fs := range m.checkFilesystems(time.Now()) {
+				select {
+				case m.autoscaler.events <- event.GenericEvent{Meta: fs, Object: fs}:
+				case <-stopCh:
+					return nil
+				}
+			}
+
+		case <-stopCh:
+			logger.Info("stopping the monitoring of the filesystems")
+			return nil
+		}
+	}
+}
+
+// checkFilesystems runs the checks of the ready filesystems and returns the filesystems scaled by the autoscaler
+func (m *filesystemMonitor) checkFilesystems(now time.Time) []*cephv1.CephFilesystem {
+	filesystems, err := readyFilesystems(m.client)
+	if err != nil {
+		logger.Warningf("failed to check the filesystems. %v", err)
+		return nil
+	}
+	if len(filesystems) == 0 {
+		return nil
+	}
+
+	scaled := m.autoscaler.checkFilesystems(filesystems, now)
+	m.evictor.checkFilesystems(filesystems, now)
+	m.reporter.refreshFilesystems(filesystems)
+	return scaled
+}
+
+// readyFilesystems returns the filesystems that are ready and not being deleted
+func readyFilesystems(client client.Client) ([]*cephv1.CephFilesystem, error) {
+	filesystems := &cephv1.CephFilesystemList{}
+	if err := client.List(context.TODO(), filesystems); err != nil {
+		return nil, errors.Wrapf(err, "failed to list the filesystems")
+	}
+
+	ready := []*cephv1.CephFilesystem{}
+	for i := range filesystems.Items {
+		fs := &filesystems.Items[i]
+		if !fs.GetDeletionTimestamp().IsZero() || fs.Status == nil || fs.Status.Phase != k8sutil.ReadyStatus {
+			continue
+		}
+		ready = append(ready, fs)
+	}
+	return ready, nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestFilesystemMonitor(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			assert.Fail(t, "no command expected", args)
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: testop.New(t, 1)}
+
+	// the filesystems that are not ready or are being deleted are not checked
+	now := metav1.Now()
+	policies := cephv1.FilesystemSpec{
+		MetadataServer: cephv1.MetadataServerSpec{ActiveCount: 1, Autoscale: &cephv1.MDSAutoscaleSpec{MaxActive: 2, ScaleUpRequestRate: 1000}},
+		ClientEviction: &cephv1.ClientEvictionSpec{},
+	}
+	created := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "created", Namespace: "ns"},
+		Spec:       policies,
+		Status:     &cephv1.CephFilesystemStatus{Phase: k8sutil.Created},
+	}
+	deleting := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "deleting", Namespace: "ns", DeletionTimestamp: &now},
+		Spec:       policies,
+		Status:     &cephv1.CephFilesystemStatus{Phase: k8sutil.ReadyStatus},
+	}
+	noStatus := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "nostatus", Namespace: "ns"},
+		Spec:       policies,
+	}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephFilesystem{}, &cephv1.CephFilesystemList{})
+	m := newFilesystemMonitor(fakeclient.NewFakeClientWithScheme(s, created, deleting, noStatus), context)
+
+	assert.Empty(t, listReadyFilesystems(t, m.client))
+	assert.Empty(t, m.checkFilesystems(time.Now()))
+}
+
+func listReadyFilesystems(t *testing.T, client client.Client) []*cephv1.CephFilesystem {
+	filesystems, err := readyFilesystems(client)
+	assert.NoError(t, err)
+	return filesystems
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil/cmdreporter"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	quotaAppName    = "rook-ceph-fs-quota"
+	quotaJobTimeout = 5 * time.Minute
+)
+
+// ApplyQuota sets the quota of bytes of an existing directory of the filesystem with a job mounting the filesystem, a
+// max bytes of 0 removes the quota. The name tells the directory apart in the name of the job, such as the name of a
+// subvolume group. Unlike the quota of a subvolume group set by ceph, the quota set on the directory works with any
+// version of ceph.
+func ApplyQuota(context *clusterd.Context, fs *cephv1.CephFilesystem, ownerRef metav1.OwnerReference, name, dirPath string, maxBytes int64) error {
+	rookImage, err := operatorImage(context)
+	if err != nil {
+		return err
+	}
+	reporter, err := newQuotaReporter(context, fs, ownerRef, rookImage, name, dirPath, maxBytes)
+	if err != nil {
+		return err
+	}
+
+	stdout, stderr, retcode, err := reporter.Run(quotaJobTimeout)
+	if err != nil {
+		return errors.Wrapf(err, "failed to complete the quota job of %q in filesystem %q", dirPath, fs.Name)
+	}
+	if retcode != 0 {
+		return errors.Errorf(`quota job of %q in filesystem %q returned failure with retcode %d.
+  stdout: %s
+  stderr: %s`, dirPath, fs.Name, retcode, stdout, stderr)
+	}
+
+	found, err := strconv.ParseInt(strings.TrimSpace(stdout), 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse the output %q of the quota job", stdout)
+	}
+	if found != maxBytes {
+		logger.Infof("changed the quota of %q in filesystem %q from %d to %d bytes", dirPath, fs.Name, found, maxBytes)
+	}
+	return nil
+}
+
+// newQuotaReporter builds the job mounting the filesystem with the admin credentials to set the quota of a directory
+func newQuotaReporter(context *clusterd.Context, fs *cephv1.CephFilesystem, ownerRef metav1.OwnerReference, rookImage, name, dirPath string, maxBytes int64) (*cmdreporter.CmdReporter, error) {
+	args := []string{"ceph", "fs-quota", "--filesystem-name", fs.Name, "--path", dirPath, "--max-bytes", strconv.FormatInt(maxBytes, 10)}
+	jobName := fmt.Sprintf("%s-%s", mountJobName(quotaAppName, fs.Name), name)
+	return newMountJobReporter(context, fs, ownerRef, rookImage, quotaAppName, jobName, args)
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	daemonutil "github.com/rook/rook/pkg/daemon/util"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestQuotaJob(t *testing.T) {
+	context := &clusterd.Context{Clientset: testop.New(t, 1)}
+	fs := &cephv1.CephFilesystem{ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"}}
+
+	reporter, err := newQuotaReporter(context, fs, metav1.OwnerReference{Name: "group-a"}, "rook/ceph:master", "group-a", "/volumes/group-a", 1024)
+	assert.NoError(t, err)
+	job := reporter.Job()
+	assert.Equal(t, "rook-ceph-fs-quota-myfs-group-a", job.Name)
+	assert.Equal(t, "ns", job.Namespace)
+	assert.Equal(t, "group-a", job.OwnerReferences[0].Name)
+
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "rook/ceph:master", container.Image)
+	assert.True(t, *container.SecurityContext.Privileged)
+	cmd, args, err := daemonutil.CmdReporterFlagArgumentToCommand(container.Args[2])
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rook"}, cmd)
+	assert.Equal(t, []string{"ceph", "fs-quota", "--filesystem-name", "myfs", "--path", "/volumes/group-a", "--max-bytes", "1024"}, args)
+}
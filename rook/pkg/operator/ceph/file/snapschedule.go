@@ -0,0 +1,245 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+)
+
+// the layout of the start time of the snapshot schedules, without time zone
+const snapScheduleTimeLayout = "2006-01-02T15:04:05"
+
+var snapScheduleIntervalPattern = regexp.MustCompile(`^[0-9]+[mhdwMy]$`)
+
+// validateSnapshotSchedules checks the snapshot schedules and retention of the filesystem spec
+func validateSnapshotSchedules(fs *cephv1.CephFilesystem) error {
+	for _, s := range fs.Spec.SnapshotSchedules {
+		if s.Path != "" && !path.IsAbs(s.Path) {
+			return errors.Errorf("invalid snapshot schedule path %q, it must be an absolute path", s.Path)
+		}
+		if !snapScheduleIntervalPattern.MatchString(s.Interval) {
+			return errors.Errorf("invalid snapshot schedule interval %q, it must be a number followed by m, h, d, w, M or y", s.Interval)
+		}
+		if s.StartTime != "" {
+			if _, err := parseSnapScheduleTime(s.StartTime); err != nil {
+				return errors.Errorf("invalid snapshot schedule start time %q, it must be in the format %s", s.StartTime, snapScheduleTimeLayout)
+			}
+		}
+	}
+
+	paths := map[string]bool{}
+	for _, r := range fs.Spec.SnapshotRetention {
+		if r.Path != "" && !path.IsAbs(r.Path) {
+			return errors.Errorf("invalid snapshot retention path %q, it must be an absolute path", r.Path)
+		}
+		p := snapSchedulePath(r.Path)
+		if paths[p] {
+			return errors.Errorf("snapshot retention of %q is specified more than once", p)
+		}
+		paths[p] = true
+		if _, err := cephclient.ParseSnapRetention(r.Duration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileSnapshotSchedules adds the snapshot schedules and retention of the filesystem spec, removes the
+// others and returns the status of the schedules of the spec
+func reconcileSnapshotSchedules(context *clusterd.Context, clusterInfo *cephconfig.ClusterInfo, fs *cephv1.CephFilesystem) []cephv1.SnapshotScheduleStatus {
+	statuses := []cephv1.SnapshotScheduleStatus{}
+	for _, s := range fs.Spec.SnapshotSchedules {
+		statuses = append(statuses, cephv1.SnapshotScheduleStatus{Path: snapSchedulePath(s.Path), Interval: s.Interval, StartTime: s.StartTime})
+	}
+	inSpec := len(fs.Spec.SnapshotSchedules) != 0 || len(fs.Spec.SnapshotRetention) != 0
+
+	if !clusterInfo.CephVersion.IsAtLeastOctopus() {
+		if inSpec {
+			logger.Errorf("snapshot schedules of filesystem %q require ceph octopus or newer", fs.Name)
+			return setSnapshotScheduleErrors(statuses, errors.New("snapshot schedules require ceph octopus or newer"))
+		}
+		return nil
+	}
+
+	if inSpec {
+		if err := cephclient.MgrEnableModule(context, clusterInfo.Name, cephclient.SnapScheduleModule, false); err != nil {
+			logger.Errorf("failed to enable the snapshot schedules of filesystem %q. %v", fs.Name, err)
+			return setSnapshotScheduleErrors(statuses, err)
+		}
+	}
+
+	existing, err := cephclient.ListSnapSchedules(context, clusterInfo.Name, fs.Name)
+	if err != nil {
+		if !inSpec {
+			// the module is not enabled when no snapshot schedule was ever set
+			logger.Debugf("no snapshot schedule to remove from filesystem %q. %v", fs.Name, err)
+			return nil
+		}
+		logger.Errorf("failed to list the snapshot schedules of filesystem %q. %v", fs.Name, err)
+		return setSnapshotScheduleErrors(statuses, err)
+	}
+
+	// remove the schedules that are not in the spec
+	for _, e := range existing {
+		found := false
+		for _, s := range fs.Spec.SnapshotSchedules {
+			if sameSnapSchedule(e, s) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			if err := cephclient.RemoveSnapSchedule(context, clusterInfo.Name, fs.Name, e.Path, e.Schedule, e.Start); err != nil {
+				logger.Errorf("failed to remove snapshot schedule. %v", err)
+			}
+		}
+	}
+
+	// add the schedules of the spec that don't exist
+	for i, s := range fs.Spec.SnapshotSchedules {
+		found := false
+		for _, e := range existing {
+			if sameSnapSchedule(e, s) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			if err := cephclient.AddSnapSchedule(context, clusterInfo.Name, fs.Name, snapSchedulePath(s.Path), s.Interval, s.StartTime); err != nil {
+				logger.Errorf("failed to add snapshot schedule. %v", err)
+				statuses[i].Error = err.Error()
+			}
+		}
+	}
+
+	reconcileSnapshotRetention(context, clusterInfo, fs, existing)
+
+	// report the last snapshots of the schedules
+	existing, err = cephclient.ListSnapSchedules(context, clusterInfo.Name, fs.Name)
+	if err != nil {
+		logger.Errorf("failed to list the snapshot schedules of filesystem %q. %v", fs.Name, err)
+		return setSnapshotScheduleErrors(statuses, err)
+	}
+	setSnapshotScheduleActivity(statuses, existing)
+	if len(statuses) == 0 {
+		return nil
+	}
+	return statuses
+}
+
+// setSnapshotScheduleActivity reports in the status of the schedules whether they are active and the time of their
+// last snapshot
+func setSnapshotScheduleActivity(statuses []cephv1.SnapshotScheduleStatus, existing []cephclient.SnapSchedule) {
+	for i := range statuses {
+		s := cephv1.SnapshotScheduleSpec{Path: statuses[i].Path, Interval: statuses[i].Interval, StartTime: statuses[i].StartTime}
+		statuses[i].Active = false
+		for _, e := range existing {
+			if sameSnapSchedule(e, s) {
+				statuses[i].Active = e.Active
+				statuses[i].LastSnapshot = e.Last
+				break
+			}
+		}
+	}
+}
+
+// reconcileSnapshotRetention sets the retention of the directories to the retention of the spec
+func reconcileSnapshotRetention(context *clusterd.Context, clusterInfo *cephconfig.ClusterInfo, fs *cephv1.CephFilesystem, existing []cephclient.SnapSchedule) {
+	desired := map[string]map[string]int{}
+	for _, r := range fs.Spec.SnapshotRetention {
+		// the retention was validated with the filesystem
+		retention, _ := cephclient.ParseSnapRetention(r.Duration)
+		desired[snapSchedulePath(r.Path)] = retention
+	}
+
+	// the retention is reported with every schedule of a directory
+	current := map[string]map[string]int{}
+	for _, e := range existing {
+		if len(e.Retention) != 0 {
+			current[e.Path] = e.Retention
+		}
+	}
+
+	for p, retention := range current {
+		if reflect.DeepEqual(retention, desired[p]) {
+			continue
+		}
+		if err := cephclient.RemoveSnapRetention(context, clusterInfo.Name, fs.Name, p, retention); err != nil {
+			logger.Errorf("failed to remove snapshot retention. %v", err)
+		}
+	}
+	for p, retention := range desired {
+		if reflect.DeepEqual(retention, current[p]) {
+			continue
+		}
+		if err := cephclient.AddSnapRetention(context, clusterInfo.Name, fs.Name, p, retention); err != nil {
+			logger.Errorf("failed to add snapshot retention. %v", err)
+		}
+	}
+}
+
+// sameSnapSchedule returns whether an existing schedule is the schedule of the spec. A schedule of the spec
+// without start time matches the schedules of the same path and interval starting at any time.
+func sameSnapSchedule(existing cephclient.SnapSchedule, s cephv1.SnapshotScheduleSpec) bool {
+	if existing.Path != snapSchedulePath(s.Path) || existing.Schedule != s.Interval {
+		return false
+	}
+	if s.StartTime == "" {
+		return true
+	}
+	start, err := parseSnapScheduleTime(s.StartTime)
+	if err != nil {
+		return false
+	}
+	existingStart, err := parseSnapScheduleTime(existing.Start)
+	if err != nil {
+		return false
+	}
+	return start.Equal(existingStart)
+}
+
+func parseSnapScheduleTime(value string) (time.Time, error) {
+	// ceph may report the start with fractional seconds
+	if i := strings.Index(value, "."); i != -1 {
+		value = value[:i]
+	}
+	return time.Parse(snapScheduleTimeLayout, value)
+}
+
+func snapSchedulePath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return path.Clean(p)
+}
+
+func setSnapshotScheduleErrors(statuses []cephv1.SnapshotScheduleStatus, err error) []cephv1.SnapshotScheduleStatus {
+	for i := range statuses {
+		statuses[i].Error = err.Error()
+	}
+	return statuses
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newSnapScheduleFilesystem(schedules []cephv1.SnapshotScheduleSpec, retention []cephv1.SnapshotRetentionSpec) *cephv1.CephFilesystem {
+	return &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
+		Spec: cephv1.FilesystemSpec{
+			MetadataServer:    cephv1.MetadataServerSpec{ActiveCount: 1},
+			SnapshotSchedules: schedules,
+			SnapshotRetention: retention,
+		},
+	}
+}
+
+func TestValidateSnapshotSchedules(t *testing.T) {
+	valid := newSnapScheduleFilesystem(
+		[]cephv1.SnapshotScheduleSpec{{Interval: "1h"}, {Path: "/projects", Interval: "1d", StartTime: "2020-06-01T00:00:00"}},
+		[]cephv1.SnapshotRetentionSpec{{Duration: "24h"}, {Path: "/projects", Duration: "d 7"}},
+	)
+	assert.NoError(t, validateSnapshotSchedules(valid))
+
+	invalid := []*cephv1.CephFilesystem{
+		newSnapScheduleFilesystem([]cephv1.SnapshotScheduleSpec{{Interval: "1 hour"}}, nil),
+		newSnapScheduleFilesystem([]cephv1.SnapshotScheduleSpec{{Interval: "h"}}, nil),
+		newSnapScheduleFilesystem([]cephv1.SnapshotScheduleSpec{{Path: "projects", Interval: "1h"}}, nil),
+		newSnapScheduleFilesystem([]cephv1.SnapshotScheduleSpec{{Interval: "1h", StartTime: "tomorrow"}}, nil),
+		newSnapScheduleFilesystem(nil, []cephv1.SnapshotRetentionSpec{{Duration: "forever"}}),
+		newSnapScheduleFilesystem(nil, []cephv1.SnapshotRetentionSpec{{Duration: "24h"}, {Path: "/", Duration: "7d"}}),
+	}
+	for _, fs := range invalid {
+		assert.Error(t, validateSnapshotSchedules(fs), fs.Spec)
+	}
+}
+
+func TestReconcileSnapshotSchedules(t *testing.T) {
+	// a schedule of the spec exists, another schedule must be removed
+	listOutput := `[{"path":"/","schedule":"1h","start":"2020-06-01T00:00:00","retention":{"h":12},"last":"2020-06-02T10:00:00","active":true},
+{"path":"/old","schedule":"1d","start":"2020-01-01T00:00:00","retention":{},"active":true}]`
+	calls := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			for i, arg := range args {
+				if strings.HasPrefix(arg, "--connect-timeout") {
+					args = args[:i]
+					break
+				}
+			}
+			calls = append(calls, strings.Join(args, " "))
+			if len(args) > 2 && args[2] == "list" {
+				return listOutput, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := &cephconfig.ClusterInfo{Name: "ns", CephVersion: cephver.Octopus}
+	fs := newSnapScheduleFilesystem(
+		[]cephv1.SnapshotScheduleSpec{{Path: "/", Interval: "1h"}, {Path: "/projects", Interval: "1d", StartTime: "2020-06-01T00:00:00"}},
+		[]cephv1.SnapshotRetentionSpec{{Duration: "24h7d"}},
+	)
+
+	statuses := reconcileSnapshotSchedules(context, clusterInfo, fs)
+	assert.Equal(t, []string{
+		"mgr module enable snap_schedule",
+		"fs snap-schedule list / --recursive=true --fs myfs",
+		"fs snap-schedule remove /old 1d 2020-01-01T00:00:00 --fs myfs",
+		"fs snap-schedule add /projects 1d 2020-06-01T00:00:00 --fs myfs",
+		"fs snap-schedule retention remove / 12h --fs myfs",
+		"fs snap-schedule retention add / 24h7d --fs myfs",
+		"fs snap-schedule list / --recursive=true --fs myfs",
+	}, calls)
+	assert.Equal(t, []cephv1.SnapshotScheduleStatus{
+		{Path: "/", Interval: "1h", Active: true, LastSnapshot: "2020-06-02T10:00:00"},
+		// the mock still doesn't report the new schedule
+		{Path: "/projects", Interval: "1d", StartTime: "2020-06-01T00:00:00"},
+	}, statuses)
+
+	// without schedules in the spec, the existing schedules are removed
+	calls = []string{}
+	listOutput = `[{"path":"/","schedule":"1h","start":"2020-06-01T00:00:00","retention":{},"active":true}]`
+	statuses = reconcileSnapshotSchedules(context, clusterInfo, newSnapScheduleFilesystem(nil, nil))
+	assert.Nil(t, statuses)
+	assert.Equal(t, []string{
+		"fs snap-schedule list / --recursive=true --fs myfs",
+		"fs snap-schedule remove / 1h 2020-06-01T00:00:00 --fs myfs",
+		"fs snap-schedule list / --recursive=true --fs myfs",
+	}, calls)
+}
+
+func TestSnapshotSchedulesRequireOctopus(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			assert.Fail(t, "no command expected", args)
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := &cephconfig.ClusterInfo{Name: "ns", CephVersion: cephver.Nautilus}
+
+	statuses := reconcileSnapshotSchedules(context, clusterInfo, newSnapScheduleFilesystem([]cephv1.SnapshotScheduleSpec{{Interval: "1h"}}, nil))
+	assert.Equal(t, 1, len(statuses))
+	assert.Equal(t, "/", statuses[0].Path)
+	assert.Contains(t, statuses[0].Error, "octopus")
+
+	assert.Nil(t, reconcileSnapshotSchedules(context, clusterInfo, newSnapScheduleFilesystem(nil, nil)))
+}
+
+func TestSameSnapSchedule(t *testing.T) {
+	spec := cephv1.SnapshotScheduleSpec{Path: "/a/", Interval: "1h"}
+	assert.True(t, sameSnapSchedule(cephclientSchedule("/a", "1h", "2020-06-01T00:00:00.000000"), spec))
+	assert.False(t, sameSnapSchedule(cephclientSchedule("/a", "2h", "2020-06-01T00:00:00"), spec))
+
+	spec.StartTime = "2020-06-01T00:00:00"
+	assert.True(t, sameSnapSchedule(cephclientSchedule("/a", "1h", "2020-06-01T00:00:00.000000"), spec))
+	assert.False(t, sameSnapSchedule(cephclientSchedule("/a", "1h", "2020-06-02T00:00:00"), spec))
+}
+
+func cephclientSchedule(path, schedule, start string) cephclient.SnapSchedule {
+	return cephclient.SnapSchedule{Path: path, Schedule: schedule, Start: start}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"fmt"
+	"sort"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/file/mds"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	standbyReplayState = "up:standby-replay"
+	failedRankState    = "failed"
+	damagedRankState   = "damaged"
+)
+
+// filesystemStatusReporter refreshes the ranks, the standby mds, the pool usage, the clients, the activity of the
+// snapshot schedules and the state of the mirrored directories in the status of the ready filesystems. It is run by
+// the filesystem monitor.
+type filesystemStatusReporter struct {
+	client  client.Client
+	context *clusterd.Context
+}
+
+func newFilesystemStatusReporter(client client.Client, context *clusterd.Context) *filesystemStatusReporter {
+	return &filesystemStatusReporter{client: client, context: context}
+}
+
+// refreshFilesystems refreshes the status of the ready filesystems
+func (r *filesystemStatusReporter) refreshFilesystems(filesystems []*cephv1.CephFilesystem) {
+	for _, fs := range filesystems {
+		info, err := filesystemInfo(r.context, fs)
+		if err != nil {
+			logger.Warningf("failed to get the status of filesystem %q. %v", fs.Name, err)
+		} else {
+			updateStatus(r.client, types.NamespacedName{Name: fs.Name, Namespace: fs.Namespace}, func(status *cephv1.CephFilesystemStatus) {
+				status.Info = info
+			})
+		}
+		r.refreshSnapshotSchedules(fs)
+		r.refreshMirrorDirectories(fs)
+	}
+}
+
+// refreshSnapshotSchedules refreshes whether the snapshot schedules in the status of the filesystem are active and the
+// time of their last snapshot. The schedules themselves are only changed by the reconcile of the filesystem.
+func (r *filesystemStatusReporter) refreshSnapshotSchedules(fs *cephv1.CephFilesystem) {
+	if fs.Status == nil || len(fs.Status.SnapshotSchedules) == 0 {
+		return
+	}
+	existing, err := cephclient.ListSnapSchedules(r.context, fs.Namespace, fs.Name)
+	if err != nil {
+		logger.Warningf("failed to refresh the snapshot schedules of filesystem %q. %v", fs.Name, err)
+		return
+	}
+	updateStatus(r.client, types.NamespacedName{Name: fs.Name, Namespace: fs.Namespace}, func(status *cephv1.CephFilesystemStatus) {
+		setSnapshotScheduleActivity(status.SnapshotSchedules, existing)
+	})
+}
+
+// refreshMirrorDirectories refreshes the synchronization state of the mirrored directories in the status of the
+// filesystem. The directories themselves are only changed by the reconcile of the filesystem.
+func (r *filesystemStatusReporter) refreshMirrorDirectories(fs *cephv1.CephFilesystem) {
+	states := mirrorDirectoryStates(r.context, fs)
+	if len(states) == 0 {
+		return
+	}
+	updateStatus(r.client, types.NamespacedName{Name: fs.Name, Namespace: fs.Namespace}, func(status *cephv1.CephFilesystemStatus) {
+		if status.Mirroring == nil {
+			return
+		}
+		for i := range status.Mirroring.Directories {
+			if dirMap, ok := states[status.Mirroring.Directories[i].Path]; ok {
+				setMirrorDirectoryState(&status.Mirroring.Directories[i], dirMap)
+			}
+		}
+	})
+}
+
+// filesystemInfo returns the ranks, the standby mds, the pool usage and the number of clients of the filesystem
+func filesystemInfo(context *clusterd.Context, fs *cephv1.CephFilesystem) (*cephv1.FilesystemInfoStatus, error) {
+	details, err := cephclient.GetFilesystem(context, fs.Namespace, fs.Name)
+	if err != nil {
+		return nil, err
+	}
+	dump, err := cephclient.GetFilesystemDump(context, fs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	fsStatus, err := cephclient.GetFilesystemStatus(context, fs.Namespace, fs.Name)
+	if err != nil {
+		return nil, err
+	}
+	poolNames, err := cephclient.GetPoolNamesByID(context, fs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	poolStats, err := cephclient.GetPoolStats(context, fs.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &cephv1.FilesystemInfoStatus{
+		Ranks:    rankStatus(details),
+		Standbys: standbyDaemons(fs, details, dump),
+	}
+	for _, clients := range fsStatus.Clients {
+		if clients.Filesystem == fs.Name {
+			info.Clients = clients.Clients
+		}
+	}
+	usage := poolUsage(poolStats)
+	if pool, ok := usage[poolNames[details.MDSMap.MetadataPool]]; ok {
+		info.MetadataPool = &pool
+	}
+	for _, id := range details.MDSMap.DataPools {
+		if pool, ok := usage[poolNames[id]]; ok {
+			info.DataPools = append(info.DataPools, pool)
+		}
+	}
+	return info, nil
+}
+
+// rankStatus returns the state of the ranks of the filesystem that are in the cluster or damaged, and the mds
+// serving and following them
+func rankStatus(details *cephclient.CephFilesystemDetails) []cephv1.MDSRankStatus {
+	ranks := map[int]*cephv1.MDSRankStatus{}
+	addRank := func(rank int, state string) *cephv1.MDSRankStatus {
+		if _, ok := ranks[rank]; !ok {
+			ranks[rank] = &cephv1.MDSRankStatus{Rank: rank, State: state}
+		}
+		return ranks[rank]
+	}
+	for _, rank := range details.MDSMap.Damaged {
+		addRank(rank, damagedRankState)
+	}
+	for _, rank := range details.MDSMap.Failed {
+		addRank(rank, failedRankState)
+	}
+	for _, rank := range details.MDSMap.In {
+		addRank(rank, failedRankState)
+	}
+	for _, info := range details.MDSMap.Info {
+		if info.Rank < 0 {
+			continue
+		}
+		rank := addRank(info.Rank, info.State)
+		if info.State == standbyReplayState {
+			rank.StandbyReplay = info.Name
+			continue
+		}
+		rank.Daemon = info.Name
+		rank.State = info.State
+	}
+
+	var status []cephv1.MDSRankStatus
+	for _, rank := range ranks {
+		status = append(status, *rank)
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Rank < status[j].Rank })
+	return status
+}
+
+// standbyDaemons returns the standby mds of the filesystem and the standby mds bound to it with mds_join_fs
+func standbyDaemons(fs *cephv1.CephFilesystem, details *cephclient.CephFilesystemDetails, dump *cephclient.FilesystemDump) []string {
+	daemons := map[string]bool{}
+	replicas := mds.ActiveCount(fs) * 2
+	for i := 0; i < int(replicas); i++ {
+		daemons[fmt.Sprintf("%s-%s", fs.Name, k8sutil.IndexToName(i))] = true
+	}
+
+	var standbys []string
+	for _, info := range dump.Standbys {
+		if daemons[info.Name] || (info.JoinFSCID == details.ID && info.JoinFSCID >= 0) {
+			standbys = append(standbys, info.Name)
+		}
+	}
+	sort.Strings(standbys)
+	return standbys
+}
+
+// poolUsage returns the usage of the pools by name
+func poolUsage(stats *cephclient.CephStoragePoolStats) map[string]cephv1.FilesystemPoolStatus {
+	usage := map[string]cephv1.FilesystemPoolStatus{}
+	for _, pool := range stats.Pools {
+		usage[pool.Name] = cephv1.FilesystemPoolStatus{
+			Name:      pool.Name,
+			BytesUsed: int64(pool.Stats.BytesUsed),
+			MaxAvail:  int64(pool.Stats.MaxAvail),
+			Objects:   int64(pool.Stats.Objects),
+		}
+	}
+	return usage
+}
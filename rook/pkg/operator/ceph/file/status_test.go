@@ -0,0 +1,171 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRankStatus(t *testing.T) {
+	details := &cephclient.CephFilesystemDetails{MDSMap: cephclient.MDSMap{
+		In:      []int{0, 1, 2},
+		Failed:  []int{2},
+		Damaged: []int{3},
+		Info: map[string]cephclient.MDSInfo{
+			"gid_1": {Name: "myfs-a", Rank: 0, State: "up:active"},
+			"gid_2": {Name: "myfs-b", Rank: 0, State: "up:standby-replay"},
+			"gid_3": {Name: "myfs-c", Rank: 1, State: "up:rejoin"},
+		},
+	}}
+
+	ranks := rankStatus(details)
+	assert.Equal(t, []cephv1.MDSRankStatus{
+		{Rank: 0, State: "up:active", Daemon: "myfs-a", StandbyReplay: "myfs-b"},
+		{Rank: 1, State: "up:rejoin", Daemon: "myfs-c"},
+		{Rank: 2, State: failedRankState},
+		{Rank: 3, State: damagedRankState},
+	}, ranks)
+
+	// no rank before the filesystem has an active mds
+	assert.Nil(t, rankStatus(&cephclient.CephFilesystemDetails{}))
+}
+
+func TestStandbyDaemons(t *testing.T) {
+	fs := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs"},
+		Spec:       cephv1.FilesystemSpec{MetadataServer: cephv1.MetadataServerSpec{ActiveCount: 1}},
+	}
+	details := &cephclient.CephFilesystemDetails{ID: 2}
+	dump := &cephclient.FilesystemDump{Standbys: []cephclient.MDSInfo{
+		{Name: "myfs-b", JoinFSCID: -1},
+		{Name: "myfs-c", JoinFSCID: -1},
+		{Name: "otherfs-a", JoinFSCID: -1},
+		{Name: "spare-a", JoinFSCID: 2},
+		{Name: "spare-b", JoinFSCID: 3},
+	}}
+
+	// the daemons of the filesystem and the daemons joining it
+	assert.Equal(t, []string{"myfs-b", "spare-a"}, standbyDaemons(fs, details, dump))
+}
+
+func TestFilesystemStatusReporter(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			for i, arg := range args {
+				if strings.HasPrefix(arg, "--connect-timeout") {
+					args = args[:i]
+					break
+				}
+			}
+			switch {
+			case args[0] == "fs" && args[1] == "get":
+				return `{"id":1,"mdsmap":{"fs_name":"myfs","metadata_pool":1,"data_pools":[2],"in":[0],"info":{
+"gid_4107":{"gid":4107,"name":"myfs-a","rank":0,"state":"up:active"}}}}`, nil
+			case args[0] == "fs" && args[1] == "dump":
+				return `{"standbys":[{"gid":4108,"name":"myfs-b","rank":-1,"state":"up:standby","join_fscid":-1}]}`, nil
+			case args[0] == "fs" && args[1] == "status":
+				return `{"clients":[{"fs":"myfs","clients":3},{"fs":"otherfs","clients":1}]}`, nil
+			case args[0] == "osd" && args[1] == "lspools":
+				return `[{"poolnum":1,"poolname":"myfs-metadata"},{"poolnum":2,"poolname":"myfs-data0"}]`, nil
+			case args[0] == "fs" && args[1] == "snap-schedule" && args[2] == "list":
+				return `[{"path":"/","schedule":"1h","start":"2020-06-01T00:00:00","retention":{},"last":"2020-06-02T10:00:00","active":true}]`, nil
+			case args[0] == "fs" && args[1] == "snapshot" && args[3] == "dirmap":
+				return `{"instance_id":"4243","state":"stalled","reason":"peer unreachable"}`, nil
+			case args[0] == "df":
+				return `{"pools":[{"name":"myfs-metadata","id":1,"stats":{"bytes_used":2048,"max_avail":4096,"objects":22}},
+{"name":"myfs-data0","id":2,"stats":{"bytes_used":1024,"max_avail":8192,"objects":5}}]}`, nil
+			}
+			assert.Fail(t, "unexpected command", args)
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	ready := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
+		Spec: cephv1.FilesystemSpec{
+			MetadataServer: cephv1.MetadataServerSpec{ActiveCount: 1},
+			Mirroring:      &cephv1.FilesystemMirroringSpec{Directories: []string{"/projects"}},
+		},
+		Status: &cephv1.CephFilesystemStatus{
+			Phase: k8sutil.ReadyStatus,
+			SnapshotSchedules: []cephv1.SnapshotScheduleStatus{
+				{Path: "/", Interval: "1h"},
+				{Path: "/projects", Interval: "1d", Active: true},
+			},
+			Mirroring: &cephv1.FilesystemMirroringStatus{Directories: []cephv1.FilesystemMirrorDirectoryStatus{
+				{Path: "/projects", State: "mapped", InstanceID: "4242"},
+				{Path: "/old", Error: "failed to stop mirroring"},
+			}},
+		},
+	}
+	notReady := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "otherfs", Namespace: "ns"},
+		Status:     &cephv1.CephFilesystemStatus{Phase: k8sutil.Created},
+	}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephFilesystem{}, &cephv1.CephFilesystemList{})
+	r := newFilesystemStatusReporter(fakeclient.NewFakeClientWithScheme(s, ready, notReady), context)
+	r.refreshFilesystems(listReadyFilesystems(t, r.client))
+
+	assert.Equal(t, &cephv1.FilesystemInfoStatus{
+		Ranks:        []cephv1.MDSRankStatus{{Rank: 0, State: "up:active", Daemon: "myfs-a"}},
+		Standbys:     []string{"myfs-b"},
+		MetadataPool: &cephv1.FilesystemPoolStatus{Name: "myfs-metadata", BytesUsed: 2048, MaxAvail: 4096, Objects: 22},
+		DataPools:    []cephv1.FilesystemPoolStatus{{Name: "myfs-data0", BytesUsed: 1024, MaxAvail: 8192, Objects: 5}},
+		Clients:      3,
+	}, getFilesystemInfo(t, r, "myfs"))
+
+	// the activity of the snapshot schedules is refreshed, the schedule that no longer exists is not active
+	assert.Equal(t, []cephv1.SnapshotScheduleStatus{
+		{Path: "/", Interval: "1h", Active: true, LastSnapshot: "2020-06-02T10:00:00"},
+		{Path: "/projects", Interval: "1d"},
+	}, getFilesystemStatus(t, r, "myfs").SnapshotSchedules)
+
+	// the state of the mirrored directory of the spec is refreshed, the directory being removed is left alone
+	assert.Equal(t, []cephv1.FilesystemMirrorDirectoryStatus{
+		{Path: "/projects", State: "stalled", InstanceID: "4243", Reason: "peer unreachable"},
+		{Path: "/old", Error: "failed to stop mirroring"},
+	}, getFilesystemStatus(t, r, "myfs").Mirroring.Directories)
+
+	// the filesystem that is not ready is not refreshed
+	assert.Nil(t, getFilesystemInfo(t, r, "otherfs"))
+}
+
+func getFilesystemInfo(t *testing.T, r *filesystemStatusReporter, name string) *cephv1.FilesystemInfoStatus {
+	return getFilesystemStatus(t, r, name).Info
+}
+
+func getFilesystemStatus(t *testing.T, r *filesystemStatusReporter, name string) *cephv1.CephFilesystemStatus {
+	fs := &cephv1.CephFilesystem{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "ns"}, fs)
+	assert.NoError(t, err)
+	return fs.Status
+}
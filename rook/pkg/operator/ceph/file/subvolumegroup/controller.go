@@ -0,0 +1,367 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package subvolumegroup manages the subvolume groups of a CephFS filesystem.
+package subvolumegroup
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/file"
+	opmetrics "github.com/rook/rook/pkg/operator/ceph/metrics"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ceph-fs-subvolumegroup-controller"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var (
+	// the octal permissions of the directory of a group
+	modePattern = regexp.MustCompile(`^[0-7]{3,4}$`)
+
+	// applyQuota sets the quota of the directory of a group with a job mounting the filesystem
+	applyQuota = file.ApplyQuota
+)
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       reflect.TypeOf(cephv1.CephFilesystemSubVolumeGroup{}).Name(),
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+// ReconcileCephFilesystemSubVolumeGroup reconciles a CephFilesystemSubVolumeGroup object
+type ReconcileCephFilesystemSubVolumeGroup struct {
+	client  client.Client
+	scheme  *runtime.Scheme
+	context *clusterd.Context
+}
+
+// Add creates a new CephFilesystemSubVolumeGroup Controller and adds it to the Manager. The Manager will set fields on
+// the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context) error {
+	return add(mgr, newReconciler(mgr, context))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Reconciler {
+	// Add the cephv1 scheme to the manager scheme so that the controller knows about it
+	mgrScheme := mgr.GetScheme()
+	cephv1.AddToScheme(mgr.GetScheme())
+
+	return &ReconcileCephFilesystemSubVolumeGroup{
+		client:  mgr.GetClient(),
+		scheme:  mgrScheme,
+		context: context,
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes on the CephFilesystemSubVolumeGroup CRD object
+	return c.Watch(&source.Kind{Type: &cephv1.CephFilesystemSubVolumeGroup{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestForObject{}, opcontroller.WatchControllerPredicate())
+}
+
+// Reconcile reads that state of the cluster for a CephFilesystemSubVolumeGroup object and makes changes based on the
+// state read and what is in the CephFilesystemSubVolumeGroup.Spec
+func (r *ReconcileCephFilesystemSubVolumeGroup) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	start := time.Now()
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
+	reconcileResponse, err := r.reconcile(request)
+	if err != nil {
+		logger.Errorf("failed to reconcile %v", err)
+	}
+	opmetrics.ObserveReconcile(controllerName, start, err)
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileCephFilesystemSubVolumeGroup) reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// Fetch the CephFilesystemSubVolumeGroup instance
+	group := &cephv1.CephFilesystemSubVolumeGroup{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, group)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephFilesystemSubVolumeGroup resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, errors.Wrap(err, "failed to get CephFilesystemSubVolumeGroup")
+	}
+
+	// The CR was just created, initializing status fields
+	if group.Status == nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.Created, "", nil)
+	}
+
+	// Make sure a CephCluster is present otherwise do nothing
+	_, isReadyToReconcile, cephClusterExists, reconcileResponse := opcontroller.IsReadyToReconcile(r.client, r.context, request.NamespacedName, controllerName)
+	if !isReadyToReconcile {
+		// The groups are gone with the cluster, only remove the finalizer if the CephCluster is gone
+		if !group.GetDeletionTimestamp().IsZero() && !cephClusterExists {
+			err := opcontroller.RemoveFinalizer(r.client, group)
+			if err != nil {
+				return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+			}
+			return reconcile.Result{}, nil
+		}
+		return reconcileResponse, nil
+	}
+
+	// Set a finalizer so the group is not deleted while it has subvolumes
+	err = opcontroller.AddFinalizerIfNotPresent(r.client, group)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to add finalizer")
+	}
+
+	// DELETE: the CR was deleted
+	if !group.GetDeletionTimestamp().IsZero() {
+		return r.reconcileDelete(group)
+	}
+
+	fs, err := r.validate(group)
+	if err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, err.Error(), nil)
+		return reconcile.Result{}, errors.Wrapf(err, "invalid subvolume group %q", group.Name)
+	}
+
+	// RECONCILE
+	created, err := r.reconcileCreate(group, fs)
+	if err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, err.Error(), nil)
+		return reconcile.Result{}, err
+	}
+
+	updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus, "", created)
+	logger.Debug("done reconciling")
+	return reconcile.Result{}, nil
+}
+
+// validate checks the spec of the group against its filesystem and the settings the group was created with, and returns
+// the filesystem
+func (r *ReconcileCephFilesystemSubVolumeGroup) validate(group *cephv1.CephFilesystemSubVolumeGroup) (*cephv1.CephFilesystem, error) {
+	spec := group.Spec
+	if spec.FilesystemName == "" {
+		return nil, errors.New("missing filesystem name")
+	}
+	fs := &cephv1.CephFilesystem{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: spec.FilesystemName, Namespace: group.Namespace}, fs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get filesystem %q", spec.FilesystemName)
+	}
+	if !fs.GetDeletionTimestamp().IsZero() {
+		return nil, errors.Errorf("filesystem %q is being deleted", spec.FilesystemName)
+	}
+
+	if spec.DataPoolName != "" {
+		found := false
+		for _, pool := range file.DataPoolNames(fs) {
+			if pool == spec.DataPoolName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.Errorf("pool %q is not a data pool of filesystem %q, the data pools are %v", spec.DataPoolName, fs.Name, file.DataPoolNames(fs))
+		}
+	}
+
+	if spec.Mode != "" && !modePattern.MatchString(spec.Mode) {
+		return nil, errors.Errorf("invalid mode %q, it must be octal permissions such as 755", spec.Mode)
+	}
+
+	if spec.Quota != nil && spec.Quota.Sign() < 0 {
+		return nil, errors.Errorf("invalid quota %q", spec.Quota.String())
+	}
+
+	// ceph cannot rename a group nor change the layout and the mode of an existing group
+	if created := group.Status; created != nil && created.Name != "" {
+		if groupName(group) != created.Name {
+			return nil, errors.Errorf("the name of the group cannot be changed from %q to %q", created.Name, groupName(group))
+		}
+		if spec.DataPoolName != created.DataPoolName {
+			return nil, errors.Errorf("the data pool of the group cannot be changed from %q to %q after its creation", created.DataPoolName, spec.DataPoolName)
+		}
+		if spec.Mode != created.Mode {
+			return nil, errors.Errorf("the mode of the group cannot be changed from %q to %q after its creation", created.Mode, spec.Mode)
+		}
+	}
+	return fs, nil
+}
+
+// reconcileCreate creates the group and applies its quota when it changed. Returns the status of the group with its
+// path, the settings it was created with and its quota.
+func (r *ReconcileCephFilesystemSubVolumeGroup) reconcileCreate(group *cephv1.CephFilesystemSubVolumeGroup, fs *cephv1.CephFilesystem) (*cephv1.SubVolumeGroupStatus, error) {
+	name := groupName(group)
+	fsName := fs.Name
+	err := cephclient.CreateSubVolumeGroup(r.context, group.Namespace, fsName, name, group.Spec.DataPoolName, group.Spec.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := cephclient.GetSubVolumeGroupPath(r.context, group.Namespace, fsName, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// the quota is set on the directory of the group since ceph only sets the quota of a group from quincy
+	var applied *resource.Quantity
+	if group.Status != nil {
+		applied = group.Status.Quota
+	}
+	if quotaBytes(group.Spec.Quota) != quotaBytes(applied) {
+		ownerRef, err := opcontroller.GetControllerObjectOwnerReference(group, r.scheme)
+		if err != nil || ownerRef == nil {
+			return nil, errors.Wrapf(err, "failed to get controller %q owner reference", group.Name)
+		}
+		logger.Infof("setting the quota of subvolume group %q of filesystem %q to %d bytes", name, fsName, quotaBytes(group.Spec.Quota))
+		if err := applyQuota(r.context, fs, *ownerRef, name, path, quotaBytes(group.Spec.Quota)); err != nil {
+			return nil, errors.Wrapf(err, "failed to set the quota of subvolume group %q", name)
+		}
+	}
+
+	created := &cephv1.SubVolumeGroupStatus{Path: path, Name: name, DataPoolName: group.Spec.DataPoolName, Mode: group.Spec.Mode}
+	if group.Spec.Quota != nil {
+		quota := group.Spec.Quota.DeepCopy()
+		created.Quota = &quota
+	}
+	return created, nil
+}
+
+// reconcileDelete removes the group and its finalizer. The deletion is refused while the group has subvolumes.
+func (r *ReconcileCephFilesystemSubVolumeGroup) reconcileDelete(group *cephv1.CephFilesystemSubVolumeGroup) (reconcile.Result, error) {
+	nsName := types.NamespacedName{Name: group.Name, Namespace: group.Namespace}
+
+	// there is nothing to remove if the group was never created, such as when its spec was invalid
+	if group.Status == nil || group.Status.Name == "" {
+		logger.Infof("subvolume group %q was not created, removing its finalizer", group.Name)
+		if err := opcontroller.RemoveFinalizer(r.client, group); err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+		}
+		return reconcile.Result{}, nil
+	}
+	// the group was created with the name of the status, the spec may have been changed since
+	name := group.Status.Name
+
+	// the group is removed with its filesystem
+	fs := &cephv1.CephFilesystem{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: group.Spec.FilesystemName, Namespace: group.Namespace}, fs)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to get filesystem %q", group.Spec.FilesystemName)
+	}
+	if err == nil && fs.GetDeletionTimestamp().IsZero() {
+		// the subvolumes of a group that no longer exists are listed as empty
+		subVolumes, err := cephclient.ListSubVolumes(r.context, group.Namespace, fs.Name, name)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if len(subVolumes) > 0 {
+			message := fmt.Sprintf("cannot delete subvolume group %q of filesystem %q while it has %d subvolumes", name, fs.Name, len(subVolumes))
+			logger.Warningf("%s", message)
+			updateStatus(r.client, nsName, k8sutil.ReconcileFailedStatus, message, nil)
+			return opcontroller.WaitForRequeueIfFinalizerBlocked, nil
+		}
+
+		logger.Infof("deleting subvolume group %q of filesystem %q", name, fs.Name)
+		if err := cephclient.DeleteSubVolumeGroup(r.context, group.Namespace, fs.Name, name); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	err = opcontroller.RemoveFinalizer(r.client, group)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+	}
+	return reconcile.Result{}, nil
+}
+
+// quotaBytes returns the bytes of a quota, 0 for no quota
+func quotaBytes(quota *resource.Quantity) int64 {
+	if quota == nil {
+		return 0
+	}
+	return quota.Value()
+}
+
+// groupName returns the name of the group in the filesystem, the name of the resource by default
+func groupName(group *cephv1.CephFilesystemSubVolumeGroup) string {
+	if group.Spec.Name != "" {
+		return group.Spec.Name
+	}
+	return group.Name
+}
+
+// updateStatus updates the phase and the message of the status of a group, and the path and the settings of the group
+// once it is created
+func updateStatus(client client.Client, name types.NamespacedName, phase, message string, created *cephv1.SubVolumeGroupStatus) {
+	group := &cephv1.CephFilesystemSubVolumeGroup{}
+	err := client.Get(context.TODO(), name, group)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephFilesystemSubVolumeGroup resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve subvolume group %q to update status to %q. %v", name, phase, err)
+		return
+	}
+
+	if group.Status == nil {
+		group.Status = &cephv1.SubVolumeGroupStatus{}
+	}
+	group.Status.Phase = phase
+	group.Status.Message = message
+	// keep the path and the settings while the group is being reconciled or deleted
+	if created != nil {
+		group.Status.Path = created.Path
+		group.Status.Name = created.Name
+		group.Status.DataPoolName = created.DataPoolName
+		group.Status.Mode = created.Mode
+		group.Status.Quota = created.Quota
+	}
+	if err := opcontroller.UpdateStatus(client, group); err != nil {
+		logger.Errorf("failed to set subvolume group %q status to %q. %v", group.Name, phase, err)
+		return
+	}
+	logger.Debugf("subvolume group %q status updated to %q", name, phase)
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subvolumegroup
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/file"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	namespace = "rook-ceph"
+)
+
+type testCluster struct {
+	context    *clusterd.Context
+	commands   []string
+	subVolumes string
+	// groupMissing is true if the group was removed from the filesystem outside of rook
+	groupMissing bool
+}
+
+func newTestCluster(t *testing.T) *testCluster {
+	c := &testCluster{subVolumes: "[]"}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			switch {
+			case args[0] == "status":
+				return `{"fsid":"c47cac40-9bee-4d52-823b-ccd803ba5bfe","health":{"checks":{},"status":"HEALTH_OK"}}`, nil
+			case args[0] == "fs":
+				// remove the common args added by the ceph command
+				for i, arg := range args {
+					if strings.HasPrefix(arg, "--connect-timeout") {
+						c.commands = append(c.commands, strings.Join(args[:i], " "))
+						break
+					}
+				}
+				if args[2] == "getpath" {
+					return "/volumes/" + args[4] + "\n", nil
+				}
+				if args[1] == "subvolume" && args[2] == "ls" {
+					if c.groupMissing {
+						return "", exec.Command("sh", "-c", fmt.Sprintf("exit %d", int(syscall.ENOENT))).Run()
+					}
+					return c.subVolumes, nil
+				}
+			}
+			return "", nil
+		},
+	}
+	clientset := test.New(t, 1)
+	c.context = &clusterd.Context{Executor: executor, RookClientset: rookclient.NewSimpleClientset(), Clientset: clientset}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon", Namespace: namespace},
+		Data: map[string][]byte{
+			"cluster-name": []byte("foo-cluster"),
+			"fsid":         []byte("fsid"),
+			"mon-secret":   []byte("monsecret"),
+			"admin-secret": []byte("adminsecret"),
+		},
+		Type: k8sutil.RookType,
+	}
+	_, err := clientset.CoreV1().Secrets(namespace).Create(secret)
+	assert.NoError(t, err)
+	return c
+}
+
+func newReconcile(c *testCluster, objects ...runtime.Object) *ReconcileCephFilesystemSubVolumeGroup {
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: namespace, Namespace: namespace},
+		Status: cephv1.ClusterStatus{
+			Phase:      k8sutil.ReadyStatus,
+			CephStatus: &cephv1.CephStatus{Health: "HEALTH_OK"},
+		},
+	}
+	fs := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: namespace},
+		Spec:       cephv1.FilesystemSpec{DataPools: []cephv1.PoolSpec{{}, {}}},
+	}
+	objects = append(objects, cephCluster, fs)
+	s := scheme.Scheme
+	cl := fake.NewFakeClientWithScheme(s, objects...)
+	return &ReconcileCephFilesystemSubVolumeGroup{client: cl, scheme: s, context: c.context}
+}
+
+func newGroup(spec cephv1.SubVolumeGroupSpec) *cephv1.CephFilesystemSubVolumeGroup {
+	return &cephv1.CephFilesystemSubVolumeGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: namespace},
+		Spec:       spec,
+		TypeMeta:   controllerTypeMeta,
+	}
+}
+
+var request = reconcile.Request{NamespacedName: types.NamespacedName{Name: "team-a", Namespace: namespace}}
+
+func getGroup(t *testing.T, r *ReconcileCephFilesystemSubVolumeGroup) *cephv1.CephFilesystemSubVolumeGroup {
+	group := &cephv1.CephFilesystemSubVolumeGroup{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, group)
+	assert.NoError(t, err)
+	return group
+}
+
+func TestCreateSubVolumeGroup(t *testing.T) {
+	c := newTestCluster(t)
+	r := newReconcile(c, newGroup(cephv1.SubVolumeGroupSpec{FilesystemName: "myfs", DataPoolName: "myfs-data1", Mode: "750"}))
+
+	res, err := r.Reconcile(request)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	group := getGroup(t, r)
+	assert.Equal(t, &cephv1.SubVolumeGroupStatus{Phase: k8sutil.ReadyStatus, Path: "/volumes/team-a", Name: "team-a", DataPoolName: "myfs-data1", Mode: "750"}, group.Status)
+	assert.Equal(t, []string{"cephfilesystemsubvolumegroup.ceph.rook.io"}, group.Finalizers)
+	assert.Equal(t, []string{
+		"fs subvolumegroup create myfs team-a --pool_layout myfs-data1 --mode 750",
+		"fs subvolumegroup getpath myfs team-a",
+	}, c.commands)
+}
+
+func TestChangeSubVolumeGroup(t *testing.T) {
+	c := newTestCluster(t)
+	r := newReconcile(c, newGroup(cephv1.SubVolumeGroupSpec{FilesystemName: "myfs", Name: "team-a-group", Mode: "750"}))
+	_, err := r.Reconcile(request)
+	assert.NoError(t, err)
+
+	// the name, the data pool and the mode cannot be changed once the group is created
+	changes := []func(spec *cephv1.SubVolumeGroupSpec){
+		func(spec *cephv1.SubVolumeGroupSpec) { spec.Name = "team-b-group" },
+		func(spec *cephv1.SubVolumeGroupSpec) { spec.DataPoolName = "myfs-data1" },
+		func(spec *cephv1.SubVolumeGroupSpec) { spec.Mode = "755" },
+	}
+	for _, change := range changes {
+		c.commands = nil
+		group := getGroup(t, r)
+		original := group.Spec
+		change(&group.Spec)
+		assert.NoError(t, r.client.Update(context.TODO(), group))
+		_, err = r.Reconcile(request)
+		assert.Error(t, err)
+		assert.Empty(t, c.commands)
+		group = getGroup(t, r)
+		assert.Equal(t, k8sutil.ReconcileFailedStatus, group.Status.Phase)
+		assert.Contains(t, group.Status.Message, "cannot be changed")
+		assert.Equal(t, "/volumes/team-a-group", group.Status.Path)
+
+		// the group is reconciled again when the change is reverted
+		group.Spec = original
+		assert.NoError(t, r.client.Update(context.TODO(), group))
+		_, err = r.Reconcile(request)
+		assert.NoError(t, err)
+		assert.Equal(t, k8sutil.ReadyStatus, getGroup(t, r).Status.Phase)
+	}
+}
+
+func TestSubVolumeGroupQuota(t *testing.T) {
+	quotas := []string{}
+	applyQuota = func(context *clusterd.Context, fs *cephv1.CephFilesystem, ownerRef metav1.OwnerReference, name, dirPath string, maxBytes int64) error {
+		assert.Equal(t, "team-a", ownerRef.Name)
+		quotas = append(quotas, fmt.Sprintf("%s %s %d", fs.Name, dirPath, maxBytes))
+		return nil
+	}
+	defer func() { applyQuota = file.ApplyQuota }()
+
+	c := newTestCluster(t)
+	quota := resource.MustParse("1Gi")
+	r := newReconcile(c, newGroup(cephv1.SubVolumeGroupSpec{FilesystemName: "myfs", Quota: &quota}))
+
+	// the quota is set on the directory of the group when the group is created
+	_, err := r.Reconcile(request)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"myfs /volumes/team-a 1073741824"}, quotas)
+	group := getGroup(t, r)
+	assert.Equal(t, int64(1073741824), group.Status.Quota.Value())
+
+	// the quota is not set again while it does not change
+	_, err = r.Reconcile(request)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(quotas))
+
+	// the quota is changed
+	quota = resource.MustParse("2Gi")
+	group = getGroup(t, r)
+	group.Spec.Quota = &quota
+	assert.NoError(t, r.client.Update(context.TODO(), group))
+	_, err = r.Reconcile(request)
+	assert.NoError(t, err)
+	assert.Equal(t, "myfs /volumes/team-a 2147483648", quotas[1])
+
+	// removing the quota from the spec removes the quota of the group
+	group = getGroup(t, r)
+	group.Spec.Quota = nil
+	assert.NoError(t, r.client.Update(context.TODO(), group))
+	_, err = r.Reconcile(request)
+	assert.NoError(t, err)
+	assert.Equal(t, "myfs /volumes/team-a 0", quotas[2])
+	assert.Nil(t, getGroup(t, r).Status.Quota)
+
+	// a failure to set the quota is retried by the next reconcile
+	applyQuota = func(context *clusterd.Context, fs *cephv1.CephFilesystem, ownerRef metav1.OwnerReference, name, dirPath string, maxBytes int64) error {
+		return errors.New("job failed")
+	}
+	group = getGroup(t, r)
+	group.Spec.Quota = &quota
+	assert.NoError(t, r.client.Update(context.TODO(), group))
+	_, err = r.Reconcile(request)
+	assert.Error(t, err)
+	group = getGroup(t, r)
+	assert.Equal(t, k8sutil.ReconcileFailedStatus, group.Status.Phase)
+	assert.Nil(t, group.Status.Quota)
+}
+
+func TestValidateSubVolumeGroup(t *testing.T) {
+	c := newTestCluster(t)
+	r := newReconcile(c)
+
+	fs, err := r.validate(newGroup(cephv1.SubVolumeGroupSpec{FilesystemName: "myfs", DataPoolName: "myfs-data0", Mode: "0755"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "myfs", fs.Name)
+
+	_, err = r.validate(newGroup(cephv1.SubVolumeGroupSpec{}))
+	assert.Error(t, err)
+	_, err = r.validate(newGroup(cephv1.SubVolumeGroupSpec{FilesystemName: "otherfs"}))
+	assert.Error(t, err)
+	_, err = r.validate(newGroup(cephv1.SubVolumeGroupSpec{FilesystemName: "myfs", DataPoolName: "myfs-data2"}))
+	assert.Error(t, err)
+	_, err = r.validate(newGroup(cephv1.SubVolumeGroupSpec{FilesystemName: "myfs", Mode: "rwx"}))
+	assert.Error(t, err)
+	_, err = r.validate(newGroup(cephv1.SubVolumeGroupSpec{FilesystemName: "myfs", Mode: "800"}))
+	assert.Error(t, err)
+	negative := resource.MustParse("-1Gi")
+	_, err = r.validate(newGroup(cephv1.SubVolumeGroupSpec{FilesystemName: "myfs", Quota: &negative}))
+	assert.Error(t, err)
+}
+
+func TestDeleteSubVolumeGroup(t *testing.T) {
+	c := newTestCluster(t)
+	group := newGroup(cephv1.SubVolumeGroupSpec{FilesystemName: "myfs"})
+	now := metav1.Now()
+	group.DeletionTimestamp = &now
+	group.Finalizers = []string{"cephfilesystemsubvolumegroup.ceph.rook.io"}
+	group.Status = &cephv1.SubVolumeGroupStatus{Phase: k8sutil.ReadyStatus, Path: "/volumes/team-a", Name: "team-a"}
+	r := newReconcile(c, group)
+
+	// the deletion is refused while the group has subvolumes
+	c.subVolumes = `[{"name":"csi-vol-1"}]`
+	res, err := r.Reconcile(request)
+	assert.NoError(t, err)
+	assert.True(t, res.Requeue)
+	group = getGroup(t, r)
+	assert.Equal(t, k8sutil.ReconcileFailedStatus, group.Status.Phase)
+	assert.Equal(t, `cannot delete subvolume group "team-a" of filesystem "myfs" while it has 1 subvolumes`, group.Status.Message)
+	assert.Equal(t, "/volumes/team-a", group.Status.Path)
+	assert.NotEmpty(t, group.Finalizers)
+	assert.Equal(t, []string{"fs subvolume ls myfs --group_name team-a"}, c.commands)
+
+	c.subVolumes = "[]"
+	res, err = r.Reconcile(request)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	assert.Equal(t, "fs subvolumegroup rm myfs team-a --force", c.commands[2])
+	assert.Empty(t, getGroup(t, r).Finalizers)
+}
+
+func TestDeleteSubVolumeGroupNotCreated(t *testing.T) {
+	now := metav1.Now()
+	newDeletedGroup := func(status *cephv1.SubVolumeGroupStatus) *cephv1.CephFilesystemSubVolumeGroup {
+		group := newGroup(cephv1.SubVolumeGroupSpec{FilesystemName: "myfs"})
+		group.DeletionTimestamp = &now
+		group.Finalizers = []string{"cephfilesystemsubvolumegroup.ceph.rook.io"}
+		group.Status = status
+		return group
+	}
+
+	// the finalizer of a group that was never created is removed without calling ceph
+	c := newTestCluster(t)
+	r := newReconcile(c, newDeletedGroup(&cephv1.SubVolumeGroupStatus{Phase: k8sutil.ReconcileFailedStatus, Message: "invalid mode"}))
+	res, err := r.Reconcile(request)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	assert.Empty(t, c.commands)
+	assert.Empty(t, getGroup(t, r).Finalizers)
+
+	// a group removed from the filesystem outside of rook has no subvolumes
+	c = newTestCluster(t)
+	c.groupMissing = true
+	r = newReconcile(c, newDeletedGroup(&cephv1.SubVolumeGroupStatus{Phase: k8sutil.ReadyStatus, Path: "/volumes/team-a", Name: "team-a"}))
+	res, err = r.Reconcile(request)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	assert.Equal(t, []string{"fs subvolume ls myfs --group_name team-a", "fs subvolumegroup rm myfs team-a --force"}, c.commands)
+	assert.Empty(t, getGroup(t, r).Finalizers)
+}
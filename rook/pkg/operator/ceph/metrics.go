@@ -0,0 +1,125 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"net"
+	"path"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	operatorAppName            = "rook-ceph-operator"
+	operatorMetricsServiceName = "rook-ceph-operator-metrics"
+	operatorMetricsPortName    = "http-metrics"
+	monitoringPath             = "/etc/ceph-monitoring/"
+	operatorServiceMonitorFile = "operator-service-monitor.yaml"
+)
+
+var (
+	// The address the metrics of the operator are served on, "0" disables the metrics
+	MetricsBindAddress = ":8080"
+	// Whether to create a service and a ServiceMonitor so Prometheus scrapes the metrics of the operator
+	EnableMetricsServiceMonitor = false
+)
+
+// enableMetricsServiceMonitor exposes the metrics of the operator with a service and a ServiceMonitor
+func (o *Operator) enableMetricsServiceMonitor() error {
+	port, err := metricsPort(MetricsBindAddress)
+	if err != nil {
+		return err
+	}
+	if port == 0 {
+		logger.Infof("operator metrics are disabled, not creating the service monitor")
+		return nil
+	}
+
+	service := o.makeMetricsService(port)
+	if _, err := o.context.Clientset.CoreV1().Services(o.operatorNamespace).Create(service); err != nil {
+		if !kerrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "failed to create operator metrics service")
+		}
+		existing, err := o.context.Clientset.CoreV1().Services(o.operatorNamespace).Get(service.Name, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to get operator metrics service")
+		}
+		existing.Spec.Ports = service.Spec.Ports
+		existing.Spec.Selector = service.Spec.Selector
+		if _, err := o.context.Clientset.CoreV1().Services(o.operatorNamespace).Update(existing); err != nil {
+			return errors.Wrapf(err, "failed to update operator metrics service")
+		}
+	}
+
+	serviceMonitor, err := k8sutil.GetServiceMonitor(path.Join(monitoringPath, operatorServiceMonitorFile))
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the operator service monitor")
+	}
+	serviceMonitor.SetNamespace(o.operatorNamespace)
+	serviceMonitor.Spec.NamespaceSelector.MatchNames = []string{o.operatorNamespace}
+	serviceMonitor.Spec.Selector.MatchLabels = service.GetLabels()
+	if _, err := k8sutil.CreateOrUpdateServiceMonitor(serviceMonitor); err != nil {
+		return errors.Wrapf(err, "failed to create the operator service monitor")
+	}
+	logger.Infof("operator service monitor enabled")
+	return nil
+}
+
+// makeMetricsService returns the service that exposes the metrics port of the operator pod
+func (o *Operator) makeMetricsService(port int) *v1.Service {
+	labels := map[string]string{k8sutil.AppAttr: operatorAppName}
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      operatorMetricsServiceName,
+			Namespace: o.operatorNamespace,
+			Labels:    labels,
+		},
+		Spec: v1.ServiceSpec{
+			Selector: labels,
+			Ports: []v1.ServicePort{
+				{
+					Name:       operatorMetricsPortName,
+					Port:       int32(port),
+					TargetPort: intstr.FromInt(port),
+					Protocol:   v1.ProtocolTCP,
+				},
+			},
+		},
+	}
+}
+
+// metricsPort returns the port of the metrics bind address, 0 if the metrics are disabled
+func metricsPort(bindAddress string) (int, error) {
+	if bindAddress == "0" || bindAddress == "" {
+		return 0, nil
+	}
+	_, portStr, err := net.SplitHostPort(bindAddress)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid metrics bind address %q", bindAddress)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid metrics port in bind address %q", bindAddress)
+	}
+	return port, nil
+}
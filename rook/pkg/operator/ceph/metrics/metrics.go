@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics for the Prometheus metrics of the operator. The metrics are registered in the registry of the
+// controller-runtime manager, which serves them on the metrics bind address of the operator.
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	namespace     = "rook"
+	subsystem     = "ceph"
+	resultSuccess = "success"
+	resultFailure = "failure"
+)
+
+var (
+	cephCommands = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "commands_total",
+		Help:      "Number of ceph CLI invocations by tool, command and result",
+	}, []string{"tool", "command", "result"})
+
+	cephCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "command_duration_seconds",
+		Help:      "Duration of the ceph CLI invocations by tool and command",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"tool", "command"})
+
+	reconciles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "reconciles_total",
+		Help:      "Number of reconciles by controller and result",
+	}, []string{"controller", "result"})
+
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Duration of the reconciles by controller",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"controller"})
+
+	orchestrations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "cluster_orchestrations_total",
+		Help:      "Number of orchestrations of the CephClusters by namespace and result",
+	}, []string{"namespace", "result"})
+
+	orchestrationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "cluster_orchestration_duration_seconds",
+		Help:      "Duration of the orchestrations of the CephClusters by namespace",
+		Buckets:   []float64{10, 30, 60, 120, 300, 600, 1200, 1800, 3600},
+	}, []string{"namespace"})
+
+	monFailovers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "mon_failovers_total",
+		Help:      "Number of mon failovers by namespace",
+	}, []string{"namespace"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(cephCommands, cephCommandDuration, reconciles, reconcileDuration,
+		orchestrations, orchestrationDuration, monFailovers)
+}
+
+// ObserveCephCommand records an invocation of a ceph CLI tool started at the given time
+func ObserveCephCommand(tool string, args []string, start time.Time, err error) {
+	command := commandName(args)
+	cephCommands.WithLabelValues(tool, command, result(err)).Inc()
+	cephCommandDuration.WithLabelValues(tool, command).Observe(time.Since(start).Seconds())
+}
+
+// ObserveReconcile records a reconcile of a controller started at the given time
+func ObserveReconcile(controller string, start time.Time, err error) {
+	reconciles.WithLabelValues(controller, result(err)).Inc()
+	reconcileDuration.WithLabelValues(controller).Observe(time.Since(start).Seconds())
+}
+
+// ObserveOrchestration records an orchestration of the CephCluster in a namespace started at the given time
+func ObserveOrchestration(clusterNamespace string, start time.Time, err error) {
+	orchestrations.WithLabelValues(clusterNamespace, result(err)).Inc()
+	orchestrationDuration.WithLabelValues(clusterNamespace).Observe(time.Since(start).Seconds())
+}
+
+// IncMonFailovers records a mon failover in the cluster of a namespace
+func IncMonFailovers(clusterNamespace string) {
+	monFailovers.WithLabelValues(clusterNamespace).Inc()
+}
+
+// commandName returns the command of the arguments of a ceph CLI tool, such as "osd pool", without the names of
+// the resources or the flags to keep the cardinality of the metrics low
+func commandName(args []string) string {
+	words := []string{}
+	for _, arg := range args {
+		if len(words) == 2 || strings.HasPrefix(arg, "-") {
+			break
+		}
+		if strings.ContainsAny(arg, "./=0123456789") {
+			break
+		}
+		words = append(words, arg)
+	}
+	return strings.Join(words, " ")
+}
+
+func result(err error) string {
+	if err != nil {
+		return resultFailure
+	}
+	return resultSuccess
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandName(t *testing.T) {
+	assert.Equal(t, "status", commandName([]string{"status", "--format", "json"}))
+	assert.Equal(t, "osd pool", commandName([]string{"osd", "pool", "create", "mypool"}))
+	assert.Equal(t, "osd out", commandName([]string{"osd", "out", "3"}))
+	assert.Equal(t, "config get", commandName([]string{"config", "get", "mgr.a"}))
+	assert.Equal(t, "config", commandName([]string{"config", "mgr.a"}))
+	assert.Equal(t, "", commandName([]string{"--version"}))
+}
+
+func TestObserve(t *testing.T) {
+	start := time.Now()
+	ObserveCephCommand("ceph", []string{"osd", "dump"}, start, nil)
+	ObserveCephCommand("ceph", []string{"osd", "dump"}, start, errors.New("failed"))
+	ObserveCephCommand("ceph", []string{"osd", "dump"}, start, nil)
+	assert.Equal(t, float64(2), testutil.ToFloat64(cephCommands.WithLabelValues("ceph", "osd dump", resultSuccess)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(cephCommands.WithLabelValues("ceph", "osd dump", resultFailure)))
+
+	ObserveReconcile("ceph-block-pool-controller", start, nil)
+	assert.Equal(t, float64(1), testutil.ToFloat64(reconciles.WithLabelValues("ceph-block-pool-controller", resultSuccess)))
+
+	ObserveOrchestration("rook-ceph", start, errors.New("failed"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(orchestrations.WithLabelValues("rook-ceph", resultFailure)))
+
+	IncMonFailovers("rook-ceph")
+	IncMonFailovers("rook-ceph")
+	assert.Equal(t, float64(2), testutil.ToFloat64(monFailovers.WithLabelValues("rook-ceph")))
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsPort(t *testing.T) {
+	port, err := metricsPort(":8080")
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, port)
+
+	port, err = metricsPort("127.0.0.1:9090")
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, port)
+
+	port, err = metricsPort("0")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, port)
+
+	_, err = metricsPort("8080")
+	assert.Error(t, err)
+}
+
+func TestMakeMetricsService(t *testing.T) {
+	o := &Operator{operatorNamespace: "rook-ceph"}
+	service := o.makeMetricsService(8080)
+	assert.Equal(t, operatorMetricsServiceName, service.Name)
+	assert.Equal(t, "rook-ceph", service.Namespace)
+	assert.Equal(t, operatorAppName, service.Spec.Selector["app"])
+	assert.Equal(t, int32(8080), service.Spec.Ports[0].Port)
+	assert.Equal(t, operatorMetricsPortName, service.Spec.Ports[0].Name)
+}
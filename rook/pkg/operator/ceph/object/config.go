@@ -23,6 +23,7 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	cephconfig "github.com/rook/rook/pkg/operator/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
 )
@@ -40,6 +41,14 @@ caps osd = "allow rwx"
 	certKeyName               = "cert"
 	certFilename              = "rgw-cert.pem"
 	rgwPortInternalPort int32 = 8080
+
+	// the default ratio of the memory limit of the rgw containers used by the client requests
+	defaultRequestMemoryRatio = 0.5
+	// the memory a client request may buffer: a GET reads ahead up to rgw_get_obj_window_size and a PUT buffers up to
+	// rgw_put_obj_min_window_size before writing to RADOS, both 16MiB by default. See
+	// https://docs.ceph.com/docs/master/radosgw/config-ref/. This is an estimate, it does not follow the window sizes
+	// when they are changed in the config.
+	rgwRequestMemory = 16 * 1024 * 1024
 )
 
 var (
@@ -112,6 +121,36 @@ func (c *clusterConfig) setDefaultFlagsMonConfigStore(rgwName string) error {
 	return nil
 }
 
+// derivedConfig returns the Ceph config options derived from the resources of the gateways of the object store. The
+// number of concurrent requests is bounded so the buffers of the requests use at most the ratio of the memory limit
+// of the rgw containers.
+func derivedConfig(store *cephv1.CephObjectStore) (map[string]string, error) {
+	ratio, err := cephconfig.ParseMemoryRatio(store.Spec.Gateway.RequestMemoryRatio, defaultRequestMemoryRatio)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid request memory ratio")
+	}
+	memory := cephconfig.MemoryFromLimit(store.Spec.Gateway.Resources, ratio)
+	if memory == 0 {
+		return nil, nil
+	}
+	requests := memory / rgwRequestMemory
+	if requests < 1 {
+		requests = 1
+	}
+	return map[string]string{"rgw_max_concurrent_requests": strconv.FormatInt(requests, 10)}, nil
+}
+
+// setDerivedConfig sets the options derived from the resources of a gateway in the mon config store, and removes
+// the options previously derived as reported in the object store status
+func (c *clusterConfig) setDerivedConfig(rgwName string, derived map[string]string) error {
+	var previous map[string]string
+	if c.store.Status != nil {
+		previous = c.store.Status.DerivedConfig
+	}
+	monStore := cephconfig.GetMonStore(c.context, c.store.Namespace)
+	return monStore.SetDerived(generateCephXUser(rgwName), derived, previous)
+}
+
 func (c *clusterConfig) deleteFlagsMonConfigStore(rgwName string) error {
 	monStore := cephconfig.GetMonStore(c.context, c.store.Namespace)
 	who := generateCephXUser(rgwName)
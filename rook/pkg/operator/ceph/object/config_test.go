@@ -23,6 +23,8 @@ import (
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func newConfig() *clusterConfig {
@@ -92,3 +94,33 @@ func TestGenerateCephXUser(t *testing.T) {
 	fakeUser := generateCephXUser("rook-ceph-rgw-fake-store-fake-user")
 	assert.Equal(t, "client.rgw.fake.store.fake.user", fakeUser)
 }
+
+func TestDerivedConfig(t *testing.T) {
+	store := &cephv1.CephObjectStore{}
+	derived, err := derivedConfig(store)
+	assert.NoError(t, err)
+	assert.Nil(t, derived)
+
+	// half of the memory limit by default, at 16MiB per request
+	store.Spec.Gateway.Resources = v1.ResourceRequirements{
+		Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("4Gi")},
+	}
+	derived, err = derivedConfig(store)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"rgw_max_concurrent_requests": "128"}, derived)
+
+	store.Spec.Gateway.RequestMemoryRatio = "0.25"
+	derived, err = derivedConfig(store)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"rgw_max_concurrent_requests": "64"}, derived)
+
+	// at least one request
+	store.Spec.Gateway.Resources.Limits[v1.ResourceMemory] = resource.MustParse("8Mi")
+	derived, err = derivedConfig(store)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"rgw_max_concurrent_requests": "1"}, derived)
+
+	store.Spec.Gateway.RequestMemoryRatio = "0"
+	_, err = derivedConfig(store)
+	assert.Error(t, err)
+}
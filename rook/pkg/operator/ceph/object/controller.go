@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/coreos/pkg/capnslog"
 	bktclient "github.com/kube-object-storage/lib-bucket-provisioner/pkg/client/clientset/versioned"
@@ -32,6 +33,7 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	opmetrics "github.com/rook/rook/pkg/operator/ceph/metrics"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -131,11 +133,13 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileCephObjectStore) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	start := time.Now()
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
 	reconcileResponse, err := r.reconcile(request)
 	if err != nil {
 		logger.Errorf("failed to reconcile %v", err)
 	}
+	opmetrics.ObserveReconcile(controllerName, start, err)
 
 	return reconcileResponse, err
 }
@@ -242,6 +246,10 @@ func (r *ReconcileCephObjectStore) reconcile(request reconcile.Request) (reconci
 		return r.setFailedStatus(request.NamespacedName, "failed to create object store deployments", err)
 	}
 
+	// Report the options derived from the resources of the gateways, they were validated with the store
+	derived, _ := derivedConfig(cephObjectStore)
+	updateDerivedConfigStatus(r.client, request.NamespacedName, derived)
+
 	// Set Ready status, we are done reconciling
 	updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus)
 
@@ -320,7 +328,7 @@ func updateStatus(client client.Client, name types.NamespacedName, status string
 		return
 	}
 	if objectStore.Status == nil {
-		objectStore.Status = &cephv1.Status{}
+		objectStore.Status = &cephv1.ObjectStoreStatus{}
 	}
 
 	objectStore.Status.Phase = status
@@ -331,6 +339,33 @@ func updateStatus(client client.Client, name types.NamespacedName, status string
 	logger.Debugf("object store %q status updated to %q", name, status)
 }
 
+// updateDerivedConfigStatus updates the status of the options derived from the resources of the gateways of an
+// object store
+func updateDerivedConfigStatus(client client.Client, name types.NamespacedName, derivedConfig map[string]string) {
+	objectStore := &cephv1.CephObjectStore{}
+	if err := client.Get(context.TODO(), name, objectStore); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephObjectStore resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve object store %q to update the status of the derived config. %v", name, err)
+		return
+	}
+	if objectStore.Status == nil {
+		objectStore.Status = &cephv1.ObjectStoreStatus{}
+	}
+	if reflect.DeepEqual(objectStore.Status.DerivedConfig, derivedConfig) {
+		return
+	}
+
+	objectStore.Status.DerivedConfig = derivedConfig
+	if err := opcontroller.UpdateStatus(client, objectStore); err != nil {
+		logger.Errorf("failed to set the status of the derived config of object store %q. %v", name, err)
+		return
+	}
+	logger.Debugf("object store %q derived config status updated", name)
+}
+
 func (r *ReconcileCephObjectStore) verifyObjectBucketCleanup(objectstore *cephv1.CephObjectStore) (reconcile.Result, bool) {
 	bktProvsioner := GetObjectBucketProvisioner(r.context, objectstore.Namespace)
 	bktProvsioner = strings.Replace(bktProvsioner, "/", "-", -1)
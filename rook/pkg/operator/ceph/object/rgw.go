@@ -97,6 +97,11 @@ func (c *clusterConfig) startRGWPods() error {
 	}
 	c.ownerRef = ref
 
+	derived, err := derivedConfig(c.store)
+	if err != nil {
+		return err
+	}
+
 	// start a new deployment and scale up
 	desiredRgwInstances := int(c.store.Spec.Gateway.Instances)
 	for i := 0; i < desiredRgwInstances; i++ {
@@ -134,6 +139,11 @@ func (c *clusterConfig) startRGWPods() error {
 			}
 		}
 
+		// Set the options derived from the resources on every reconcile so they follow the changes of the resources
+		if err := c.setDerivedConfig(rgwConfig.ResourceName, derived); err != nil {
+			return errors.Wrap(err, "failed to set derived rgw config options")
+		}
+
 		// Create deployment
 		deployment := c.createDeployment(rgwConfig)
 		logger.Infof("object store %q deployment %q started", c.store.Name, deployment.Name)
@@ -328,6 +338,9 @@ func validateStore(context *clusterd.Context, s *cephv1.CephObjectStore) error {
 	if securePort < 0 || securePort > 65535 {
 		return errors.Errorf("securePort value of %d must be between 0 and 65535", securePort)
 	}
+	if _, err := derivedConfig(s); err != nil {
+		return err
+	}
 
 	// Validate the pool settings, but allow for empty pools specs in case they have already been created
 	// such as by the ceph mgr
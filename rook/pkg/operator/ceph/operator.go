@@ -28,9 +28,11 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/attachment"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/operator/ceph/agent"
 	"github.com/rook/rook/pkg/operator/ceph/cluster"
 	"github.com/rook/rook/pkg/operator/ceph/csi"
+	opmetrics "github.com/rook/rook/pkg/operator/ceph/metrics"
 	"github.com/rook/rook/pkg/operator/ceph/provisioner"
 	"github.com/rook/rook/pkg/operator/discover"
 	"github.com/rook/rook/pkg/operator/k8sutil"
@@ -111,6 +113,10 @@ func (o *Operator) Run() error {
 		return errors.Errorf("rook operator namespace is not provided. expose it via downward API in the rook operator manifest file using environment variable %s", k8sutil.PodNamespaceEnvVar)
 	}
 
+	// the ceph commands are served with the metrics of the controller-runtime manager, the observer is set before the
+	// goroutines running the commands are started
+	cephclient.SetCommandObserver(opmetrics.ObserveCephCommand)
+
 	if EnableDiscoveryDaemon {
 		rookDiscover := discover.New(o.context.Clientset)
 		if err := rookDiscover.Start(o.operatorNamespace, o.rookImage, o.securityAccount, true); err != nil {
@@ -152,6 +158,12 @@ func (o *Operator) Run() error {
 	// Start the controller-runtime Manager.
 	go o.startManager(namespaceToWatch, stopChan)
 
+	if EnableMetricsServiceMonitor {
+		if err := o.enableMetricsServiceMonitor(); err != nil {
+			logger.Errorf("failed to enable the operator service monitor. %v", err)
+		}
+	}
+
 	// watch for changes to the rook clusters
 	o.clusterController.StartWatch(namespaceToWatch, stopChan)
 
@@ -0,0 +1,329 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package supportbundle collects the state of a Rook Ceph cluster in an archive to troubleshoot it offline.
+package supportbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-support-bundle")
+
+const (
+	redacted        = "<redacted>"
+	operatorAppName = "rook-ceph-operator"
+	timestampFormat = "20060102-150405"
+)
+
+var (
+	// the cephx keys, such as in keyrings or in the arguments of the daemons
+	cephxKey = regexp.MustCompile(`AQ[A-Za-z0-9+/]{36}==`)
+	// the secrets, passwords and tokens in the config files, the arguments and the json or yaml documents
+	secretSetting = regexp.MustCompile(`(?i)((secret|password|passwd|token)"?\s*[=:]\s*)("?)[^\s",]+("?)`)
+
+	// getPodLogs returns the logs of a container, it is a variable so it can be mocked in the tests
+	getPodLogs = func(clientset kubernetes.Interface, namespace, pod, container string, tailLines *int64) ([]byte, error) {
+		opts := &v1.PodLogOptions{Container: container, TailLines: tailLines}
+		return clientset.CoreV1().Pods(namespace).GetLogs(pod, opts).Do().Raw()
+	}
+)
+
+// Collector gathers the ceph status, the custom resources, the kubernetes resources and the pod logs of a cluster
+type Collector struct {
+	context           *clusterd.Context
+	namespace         string
+	operatorNamespace string
+	logTailLines      int64
+	files             map[string][]byte
+}
+
+// New creates a support bundle collector for the cluster in the namespace. The logs of the operator are collected
+// from the operator namespace if it is set. The logs are limited to their last lines if logTailLines is positive.
+func New(context *clusterd.Context, namespace, operatorNamespace string, logTailLines int64) *Collector {
+	return &Collector{
+		context:           context,
+		namespace:         namespace,
+		operatorNamespace: operatorNamespace,
+		logTailLines:      logTailLines,
+		files:             map[string][]byte{},
+	}
+}
+
+// Write collects the support bundle and writes it to a timestamped tar.gz archive in the output directory. Returns
+// the path of the archive. The data that cannot be collected is reported in an error file of the archive so that an
+// unhealthy cluster can still be troubleshot.
+func (c *Collector) Write(outputDir string, now time.Time) (string, error) {
+	c.collectCephStatus()
+	c.collectCustomResources()
+	c.collectKubernetesResources()
+	c.collectLogs(c.namespace, "")
+	if c.operatorNamespace != "" {
+		c.collectLogs(c.operatorNamespace, fmt.Sprintf("%s=%s", k8sutil.AppAttr, operatorAppName))
+	}
+
+	name := fmt.Sprintf("rook-ceph-support-bundle-%s-%s", c.namespace, now.UTC().Format(timestampFormat))
+	archivePath := filepath.Join(outputDir, name+".tar.gz")
+	if err := c.writeArchive(archivePath, name); err != nil {
+		return "", err
+	}
+	logger.Infof("support bundle written to %q", archivePath)
+	return archivePath, nil
+}
+
+// collectCephStatus gathers the status of ceph
+func (c *Collector) collectCephStatus() {
+	commands := []struct {
+		file string
+		run  func() (interface{}, error)
+	}{
+		{"status", func() (interface{}, error) { return client.Status(c.context, c.namespace) }},
+		{"osd-tree", func() (interface{}, error) { return client.HostTree(c.context, c.namespace) }},
+		{"osd-dump", func() (interface{}, error) { return client.GetOSDDump(c.context, c.namespace) }},
+		{"crush-map", func() (interface{}, error) { return client.GetCrushMap(c.context, c.namespace) }},
+		{"pools", func() (interface{}, error) { return client.ListPoolSummaries(c.context, c.namespace) }},
+		{"mon-quorum-status", func() (interface{}, error) { return client.GetMonQuorumStatus(c.context, c.namespace) }},
+	}
+	for _, command := range commands {
+		file := path.Join("ceph", command.file+".json")
+		result, err := command.run()
+		if err != nil {
+			c.addError(file, err)
+			continue
+		}
+		c.addJSON(file, result)
+	}
+}
+
+// collectCustomResources gathers the Rook custom resources of the namespace
+func (c *Collector) collectCustomResources() {
+	cephV1 := c.context.RookClientset.CephV1()
+	lists := []struct {
+		kind string
+		list func() (runtime.Object, error)
+	}{
+		{"CephCluster", func() (runtime.Object, error) { return cephV1.CephClusters(c.namespace).List(metav1.ListOptions{}) }},
+		{"CephBlockPool", func() (runtime.Object, error) { return cephV1.CephBlockPools(c.namespace).List(metav1.ListOptions{}) }},
+		{"CephFilesystem", func() (runtime.Object, error) { return cephV1.CephFilesystems(c.namespace).List(metav1.ListOptions{}) }},
+		{"CephObjectStore", func() (runtime.Object, error) { return cephV1.CephObjectStores(c.namespace).List(metav1.ListOptions{}) }},
+		{"CephObjectStoreUser", func() (runtime.Object, error) {
+			return cephV1.CephObjectStoreUsers(c.namespace).List(metav1.ListOptions{})
+		}},
+		{"CephNFS", func() (runtime.Object, error) { return cephV1.CephNFSes(c.namespace).List(metav1.ListOptions{}) }},
+		{"CephClient", func() (runtime.Object, error) { return cephV1.CephClients(c.namespace).List(metav1.ListOptions{}) }},
+		{"CephFilesystemSubVolumeGroup", func() (runtime.Object, error) {
+			return cephV1.CephFilesystemSubVolumeGroups(c.namespace).List(metav1.ListOptions{})
+		}},
+	}
+	for _, l := range lists {
+		c.addList(path.Join("crs", l.kind), cephv1.SchemeGroupVersion.String(), l.kind, l.list)
+	}
+}
+
+// collectKubernetesResources gathers the kubernetes resources of the namespace. The values of the secrets are not
+// collected.
+func (c *Collector) collectKubernetesResources() {
+	core := c.context.Clientset.CoreV1()
+	apps := c.context.Clientset.AppsV1()
+	lists := []struct {
+		kind       string
+		apiVersion string
+		list       func() (runtime.Object, error)
+	}{
+		{"Pod", "v1", func() (runtime.Object, error) { return core.Pods(c.namespace).List(metav1.ListOptions{}) }},
+		{"Service", "v1", func() (runtime.Object, error) { return core.Services(c.namespace).List(metav1.ListOptions{}) }},
+		{"ConfigMap", "v1", func() (runtime.Object, error) { return core.ConfigMaps(c.namespace).List(metav1.ListOptions{}) }},
+		{"PersistentVolumeClaim", "v1", func() (runtime.Object, error) {
+			return core.PersistentVolumeClaims(c.namespace).List(metav1.ListOptions{})
+		}},
+		{"Event", "v1", func() (runtime.Object, error) { return core.Events(c.namespace).List(metav1.ListOptions{}) }},
+		{"Secret", "v1", func() (runtime.Object, error) { return c.listScrubbedSecrets() }},
+		{"Deployment", "apps/v1", func() (runtime.Object, error) { return apps.Deployments(c.namespace).List(metav1.ListOptions{}) }},
+		{"DaemonSet", "apps/v1", func() (runtime.Object, error) { return apps.DaemonSets(c.namespace).List(metav1.ListOptions{}) }},
+	}
+	for _, l := range lists {
+		c.addList(path.Join("k8s", l.kind), l.apiVersion, l.kind, l.list)
+	}
+}
+
+// listScrubbedSecrets returns the secrets of the namespace with their values redacted
+func (c *Collector) listScrubbedSecrets() (runtime.Object, error) {
+	secrets, err := c.context.Clientset.CoreV1().Secrets(c.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		for key := range secret.Data {
+			secret.Data[key] = []byte(redacted)
+		}
+		for key := range secret.StringData {
+			secret.StringData[key] = redacted
+		}
+		// the last applied configuration would reveal the values
+		delete(secret.Annotations, v1.LastAppliedConfigAnnotation)
+	}
+	return secrets, nil
+}
+
+// collectLogs gathers the logs of the containers of the pods of a namespace that match the label selector
+func (c *Collector) collectLogs(namespace, labelSelector string) {
+	dir := path.Join("logs", namespace)
+	pods, err := c.context.Clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		c.addError(dir, errors.Wrapf(err, "failed to list pods"))
+		return
+	}
+	var tailLines *int64
+	if c.logTailLines > 0 {
+		tailLines = &c.logTailLines
+	}
+	for _, pod := range pods.Items {
+		containers := append([]v1.Container{}, pod.Spec.InitContainers...)
+		containers = append(containers, pod.Spec.Containers...)
+		for _, container := range containers {
+			file := path.Join(dir, pod.Name, container.Name+".log")
+			logs, err := getPodLogs(c.context.Clientset, namespace, pod.Name, container.Name, tailLines)
+			if err != nil {
+				c.addError(file, err)
+				continue
+			}
+			c.files[file] = logs
+		}
+	}
+}
+
+// addList adds each item of a list as a yaml file in the directory
+func (c *Collector) addList(dir, apiVersion, kind string, list func() (runtime.Object, error)) {
+	result, err := list()
+	if err != nil {
+		c.addError(dir, errors.Wrapf(err, "failed to list %s", kind))
+		return
+	}
+	// the items of the list are unmarshaled generically so the lists of all the types are handled the same way
+	b, err := json.Marshal(result)
+	if err != nil {
+		c.addError(dir, errors.Wrapf(err, "failed to marshal %s", kind))
+		return
+	}
+	var items struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+	if err := json.Unmarshal(b, &items); err != nil {
+		c.addError(dir, errors.Wrapf(err, "failed to unmarshal %s", kind))
+		return
+	}
+	for _, item := range items.Items {
+		item["apiVersion"] = apiVersion
+		item["kind"] = kind
+		name := ""
+		if metadata, ok := item["metadata"].(map[string]interface{}); ok {
+			name, _ = metadata["name"].(string)
+		}
+		y, err := yaml.Marshal(item)
+		if err != nil {
+			c.addError(path.Join(dir, name), err)
+			continue
+		}
+		c.files[path.Join(dir, name+".yaml")] = y
+	}
+}
+
+// addJSON adds an indented json file
+func (c *Collector) addJSON(file string, v interface{}) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		c.addError(file, err)
+		return
+	}
+	c.files[file] = b
+}
+
+// addError reports the failure to collect a file in an error file next to it
+func (c *Collector) addError(file string, err error) {
+	logger.Warningf("failed to collect %q. %v", file, err)
+	c.files[file+".error"] = []byte(err.Error() + "\n")
+}
+
+// writeArchive writes the scrubbed files in a tar.gz archive under the root directory
+func (c *Collector) writeArchive(archivePath, root string) error {
+	f, err := ioutil.TempFile(filepath.Dir(archivePath), ".support-bundle")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the support bundle")
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	names := []string{}
+	for name := range c.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	now := time.Now()
+	for _, name := range names {
+		content := Scrub(c.files[name])
+		header := &tar.Header{Name: path.Join(root, name), Mode: 0600, Size: int64(len(content)), ModTime: now}
+		if err := tw.WriteHeader(header); err != nil {
+			return errors.Wrapf(err, "failed to write %q in the support bundle", name)
+		}
+		if _, err := tw.Write(content); err != nil {
+			return errors.Wrapf(err, "failed to write %q in the support bundle", name)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close the support bundle")
+	}
+	if err := gz.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close the support bundle")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close the support bundle")
+	}
+	if err := os.Rename(f.Name(), archivePath); err != nil {
+		return errors.Wrapf(err, "failed to write the support bundle %q", archivePath)
+	}
+	return nil
+}
+
+// Scrub redacts the cephx keys and the secrets, passwords and tokens of a content
+func Scrub(content []byte) []byte {
+	content = cephxKey.ReplaceAll(content, []byte(redacted))
+	return secretSetting.ReplaceAll(content, []byte("${1}${3}"+redacted+"${4}"))
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package supportbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testKey = "AQBdwqNeAAAAABAAy7DUPUQNW1x5m2Mu/f7GsA=="
+
+func TestScrub(t *testing.T) {
+	assert.Equal(t, "[client.admin]\nkey = <redacted>\n", string(Scrub([]byte("[client.admin]\nkey = "+testKey+"\n"))))
+	assert.Equal(t, `--mon-secret=<redacted> --keyring=/etc/ceph/keyring`, string(Scrub([]byte(`--mon-secret=abc --keyring=/etc/ceph/keyring`))))
+	assert.Equal(t, `{"password": "<redacted>", "user": "admin"}`, string(Scrub([]byte(`{"password": "s3cr3t", "user": "admin"}`))))
+	assert.Equal(t, "rgw_keystone_admin_token: <redacted>\n", string(Scrub([]byte("rgw_keystone_admin_token: abc\n"))))
+	// the keys of the selectors and tolerations are kept
+	assert.Equal(t, "key: node-role.kubernetes.io/storage\n", string(Scrub([]byte("key: node-role.kubernetes.io/storage\n"))))
+}
+
+func TestWrite(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-a", Namespace: "ns"},
+			Spec: v1.PodSpec{
+				InitContainers: []v1.Container{{Name: "chown"}},
+				Containers:     []v1.Container{{Name: "mon", Args: []string{"--mon-secret=" + testKey}}},
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-operator-abc", Namespace: "operator-ns", Labels: map[string]string{"app": "rook-ceph-operator"}},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "operator"}}},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-app", Namespace: "operator-ns"},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "other"}}},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon", Namespace: "ns"},
+			Data:       map[string][]byte{"mon-secret": []byte(testKey), "admin-secret": []byte(testKey)},
+		},
+	)
+	rookClientset := rookclient.NewSimpleClientset(
+		&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "ns"}},
+		&cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: "ns"}},
+	)
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outFileArg string, args ...string) (string, error) {
+			switch args[0] {
+			case "status":
+				return `{"fsid":"abc","health":{"status":"HEALTH_OK"}}`, nil
+			case "quorum_status":
+				return "", errors.New("timed out")
+			}
+			if args[1] == "lspools" {
+				return `[{"poolnum":1,"poolname":"replicapool"}]`, nil
+			}
+			return "{}", nil
+		},
+	}
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookClientset, Executor: executor}
+
+	getPodLogs = func(clientset kubernetes.Interface, namespace, pod, container string, tailLines *int64) ([]byte, error) {
+		assert.Equal(t, int64(100), *tailLines)
+		if container == "chown" {
+			return nil, errors.New("container not started")
+		}
+		return []byte("starting " + pod + " with key " + testKey + "\n"), nil
+	}
+
+	dir, err := ioutil.TempDir("", "support-bundle")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2020, 4, 14, 10, 30, 0, 0, time.UTC)
+	archive, err := New(context, "ns", "operator-ns", 100).Write(dir, now)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "rook-ceph-support-bundle-ns-20200414-103000.tar.gz"), archive)
+
+	files := readArchive(t, archive)
+	root := "rook-ceph-support-bundle-ns-20200414-103000/"
+	assert.Contains(t, files[root+"ceph/status.json"], "HEALTH_OK")
+	assert.Contains(t, files[root+"ceph/pools.json"], "replicapool")
+	assert.Contains(t, files, root+"ceph/osd-tree.json")
+	assert.Contains(t, files, root+"ceph/osd-dump.json")
+	assert.Contains(t, files, root+"ceph/crush-map.json")
+	assert.Contains(t, files[root+"ceph/mon-quorum-status.json.error"], "timed out")
+	assert.NotContains(t, files, root+"ceph/mon-quorum-status.json")
+
+	assert.Contains(t, files[root+"crs/CephCluster/my-cluster.yaml"], "kind: CephCluster")
+	assert.Contains(t, files[root+"crs/CephCluster/my-cluster.yaml"], "apiVersion: ceph.rook.io/v1")
+	assert.Contains(t, files, root+"crs/CephBlockPool/replicapool.yaml")
+	assert.Contains(t, files, root+"k8s/Pod/rook-ceph-mon-a.yaml")
+
+	// the logs of the cluster and of the operator are collected
+	assert.Equal(t, "starting rook-ceph-mon-a with key <redacted>\n", files[root+"logs/ns/rook-ceph-mon-a/mon.log"])
+	assert.Contains(t, files[root+"logs/ns/rook-ceph-mon-a/chown.log.error"], "container not started")
+	assert.Contains(t, files, root+"logs/operator-ns/rook-ceph-operator-abc/operator.log")
+	assert.NotContains(t, files, root+"logs/operator-ns/other-app/other.log")
+
+	// no key is left in the archive
+	secret := files[root+"k8s/Secret/rook-ceph-mon.yaml"]
+	assert.Contains(t, secret, "mon-secret")
+	for name, content := range files {
+		assert.NotContains(t, content, testKey, name)
+		assert.NotContains(t, content, "AQBdwqNe", name)
+	}
+}
+
+func readArchive(t *testing.T, archive string) map[string]string {
+	f, err := os.Open(archive)
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		files[header.Name] = string(content)
+	}
+	return files
+}
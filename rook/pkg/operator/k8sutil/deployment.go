@@ -95,48 +95,58 @@ func UpdateDeploymentAndWait(context *clusterd.Context, modifiedDeployment *apps
 		}
 
 		// wait for the deployment to be restarted
-		sleepTime := 2
-		attempts := 30
-		if currentDeployment.Spec.ProgressDeadlineSeconds != nil {
-			// make the attempts double the progress deadline since the pod is both stopping and starting
-			attempts = 2 * (int(*currentDeployment.Spec.ProgressDeadlineSeconds) / sleepTime)
+		d, err := WaitForDeploymentUpdate(context.Clientset, namespace, currentDeployment)
+		if err != nil {
+			return nil, err
 		}
-		for i := 0; i < attempts; i++ {
-			// check for the status of the deployment
-			d, err := context.Clientset.AppsV1().Deployments(namespace).Get(modifiedDeployment.Name, metav1.GetOptions{})
-			if err != nil {
-				return nil, fmt.Errorf("failed to get deployment %q. %v", modifiedDeployment.Name, err)
-			}
-			if d.Status.ObservedGeneration != currentDeployment.Status.ObservedGeneration && d.Status.UpdatedReplicas > 0 && d.Status.ReadyReplicas > 0 {
-				logger.Infof("finished waiting for updated deployment %q", d.Name)
-
-				// Now we check if we can go to the next daemon
-				err = verifyCallback("continue")
-				if err != nil {
-					return nil, fmt.Errorf("failed to check if deployment %q can continue: %v", modifiedDeployment.Name, err)
-				}
-
-				return d, nil
-			}
 
-			// If ProgressDeadlineExceeded is reached let's fail earlier
-			// This can happen if one of the deployment cannot be scheduled on a node and stays in "pending" state
-			for _, condition := range d.Status.Conditions {
-				if condition.Type == v1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
-					return nil, fmt.Errorf("gave up waiting for deployment %q to update because %q", modifiedDeployment.Name, condition.Reason)
-				}
-			}
-
-			logger.Debugf("deployment %q status=%+v", d.Name, d.Status)
-			time.Sleep(time.Duration(sleepTime) * time.Second)
+		// Now we check if we can go to the next daemon
+		err = verifyCallback("continue")
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if deployment %q can continue: %v", modifiedDeployment.Name, err)
 		}
-		return nil, fmt.Errorf("gave up waiting for deployment %q to update", modifiedDeployment.Name)
+
+		return d, nil
 	}
 
 	logger.Infof("deployment %q did not change, nothing to update", currentDeployment.Name)
 	return nil, nil
 }
 
+// WaitForDeploymentUpdate waits for the pods of an updated deployment to be ready. The deployment passed is the
+// deployment as it was before the update.
+func WaitForDeploymentUpdate(clientset kubernetes.Interface, namespace string, currentDeployment *apps.Deployment) (*apps.Deployment, error) {
+	sleepTime := 2
+	attempts := 30
+	if currentDeployment.Spec.ProgressDeadlineSeconds != nil {
+		// make the attempts double the progress deadline since the pod is both stopping and starting
+		attempts = 2 * (int(*currentDeployment.Spec.ProgressDeadlineSeconds) / sleepTime)
+	}
+	for i := 0; i < attempts; i++ {
+		// check for the status of the deployment
+		d, err := clientset.AppsV1().Deployments(namespace).Get(currentDeployment.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get deployment %q. %v", currentDeployment.Name, err)
+		}
+		if d.Status.ObservedGeneration != currentDeployment.Status.ObservedGeneration && d.Status.UpdatedReplicas > 0 && d.Status.ReadyReplicas > 0 {
+			logger.Infof("finished waiting for updated deployment %q", d.Name)
+			return d, nil
+		}
+
+		// If ProgressDeadlineExceeded is reached let's fail earlier
+		// This can happen if one of the deployment cannot be scheduled on a node and stays in "pending" state
+		for _, condition := range d.Status.Conditions {
+			if condition.Type == v1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+				return nil, fmt.Errorf("gave up waiting for deployment %q to update because %q", currentDeployment.Name, condition.Reason)
+			}
+		}
+
+		logger.Debugf("deployment %q status=%+v", d.Name, d.Status)
+		time.Sleep(time.Duration(sleepTime) * time.Second)
+	}
+	return nil, fmt.Errorf("gave up waiting for deployment %q to update", currentDeployment.Name)
+}
+
 // GetDeployments returns a list of deployment names labels matching a given selector
 // example of a label selector might be "app=rook-ceph-mon, mon!=b"
 // more: https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/